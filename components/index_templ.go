@@ -45,7 +45,7 @@ func Index(options []Option) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, " <div id=\"select\" class=\"container mx-auto p-4\"><div class=\"max-w-2xl mx-auto\"><div class=\"card bg-base-100 shadow-2xl border border-base-300\"><div class=\"card-body\"><h1 class=\"card-title text-3xl mb-6\">Select games to track</h1><form id=\"game-form\" hx-post=\"/lts\" hx-target=\"#select\" hx-swap=\"innerHTML\" hx-push-url=\"true\"><div class=\"space-y-4\" id=\"game-options\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, " <div id=\"select\" class=\"container mx-auto p-4\"><div class=\"max-w-2xl mx-auto\"><div class=\"card bg-base-100 shadow-2xl border border-base-300\"><div class=\"card-body\"><h1 class=\"card-title text-3xl mb-6\">Select games to track</h1><form id=\"game-form\" method=\"get\" action=\"/lts\"><div class=\"space-y-4\" id=\"game-options\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -102,7 +102,7 @@ func Index(options []Option) templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" class=\"checkbox checkbox-primary game-checkbox\" onchange=\"document.getElementById('continue-btn').disabled=!document.querySelectorAll('.game-checkbox:checked').length\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" class=\"checkbox checkbox-primary game-checkbox\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -125,7 +125,20 @@ func Index(options []Option) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</button></div></form><script>\n\t\t\t\t\t\t// Restore selections and handle form submission\n\t\t\t\t\t\t!function(){const e=document.querySelectorAll('.game-checkbox'),t=sessionStorage.getItem('selectedGameOptions');if(t)try{JSON.parse(t).forEach(t=>{e.forEach(e=>{e.value===t&&(e.checked=!0)})})}catch{}const n=document.getElementById('continue-btn'),o=document.getElementById('game-form');n.disabled=!document.querySelectorAll('.game-checkbox:checked').length,o.addEventListener('submit',async t=>{t.preventDefault();const s=Array.from(e).filter(e=>e.checked).map(e=>e.value);sessionStorage.setItem('selectedGameOptions',JSON.stringify(s));try{const e=await fetch('/lts',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({options:s})});if(e.ok){const t=await e.text();document.open(),document.write(t),document.close(),window.history.pushState({},'','/lts'),document.title='Leagues & Teams - EsportsCalendar'}else alert('Request failed: '+e.statusText)}catch(e){alert('Error: '+e.message)}}),document.addEventListener('keydown',e=>{'Enter'===e.key&&!n.disabled&&(e.preventDefault(),o.requestSubmit(n))})}();\n\t\t\t\t\t</script></div></div></div></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</button></div></form><script nonce=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(templ.GetNonce(ctx))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `components/index.templ`, Line: 42, Col: 41}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "\">\n\t\t\t\t\t\t// Restore selections, keep the button state current and remember the choice on submit\n\t\t\t\t\t\t!function(){const e=document.querySelectorAll('.game-checkbox'),t=sessionStorage.getItem('selectedGameOptions');if(t)try{JSON.parse(t).forEach(t=>{e.forEach(e=>{e.value===t&&(e.checked=!0)})})}catch{}const n=document.getElementById('continue-btn'),o=document.getElementById('game-form'),c=()=>{n.disabled=!document.querySelectorAll('.game-checkbox:checked').length};c(),e.forEach(e=>e.addEventListener('change',c)),o.addEventListener('submit',()=>{sessionStorage.setItem('selectedGameOptions',JSON.stringify(Array.from(e).filter(e=>e.checked).map(e=>e.value)))}),document.addEventListener('keydown',e=>{'Enter'===e.key&&!n.disabled&&(e.preventDefault(),o.requestSubmit(n))})}();\n\t\t\t\t\t</script></div></div></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -1,5 +1,7 @@
 package components
 
+import "github.com/jackc/pgx/v5/pgtype"
+
 type Option struct {
 	ID      string
 	Label   string
@@ -21,3 +23,101 @@ func LogoPath(filename string) string {
 func DefaultLogo() string {
 	return "/static/images/default-logo.png"
 }
+
+// EmbedOptions controls how the embeddable schedule widget is rendered.
+type EmbedOptions struct {
+	// Theme is the DaisyUI theme, "light" or "dark".
+	Theme   string
+	Compact bool
+	// Timezone is the IANA zone match times were converted to; empty means the viewer's local timezone.
+	Timezone    string
+	CalendarURL string
+}
+
+// teamLabel prefers a team's acronym over its full name, falling back to TBD.
+func teamLabel(acronym, name pgtype.Text) string {
+	if acronym.Valid && acronym.String != "" {
+		return acronym.String
+	}
+	if name.Valid {
+		return name.String
+	}
+	return "TBD"
+}
+
+// NotificationChannel is a registered webhook as listed on the settings page.
+type NotificationChannel struct {
+	ID   int32
+	Kind string
+	// Target is the destination URL with its secret path elided.
+	Target    string
+	CreatedAt string
+}
+
+// SettingsData is everything the notification settings page of a calendar renders.
+type SettingsData struct {
+	Hash        string
+	CalendarURL string
+	Webhooks    []NotificationChannel
+	// EmailEnabled is false when no SMTP server is configured.
+	EmailEnabled bool
+	Emails       []EmailChannel
+	// PushPublicKey is the VAPID key browsers subscribe with; empty when Web Push is disabled.
+	PushPublicKey string
+	PushDevices   []PushDevice
+	// Notice and Error report the outcome of the last form submission.
+	Notice string
+	Error  string
+	// Secret is shown once after registering a raw JSON webhook.
+	Secret string
+}
+
+// EmailChannel is a weekly digest subscription as listed on the settings page.
+type EmailChannel struct {
+	ID int32
+	// Address is the email address with most of its local part elided.
+	Address   string
+	Timezone  string
+	Confirmed bool
+}
+
+// PushDevice is a browser receiving push reminders, as listed on the settings page.
+type PushDevice struct {
+	ID int32
+	// Target is the push service endpoint with its device token elided.
+	Target      string
+	LeadMinutes int32
+	CreatedAt   string
+}
+
+// DigestMatch is one line of the weekly email digest, formatted in the subscriber's timezone.
+type DigestMatch struct {
+	Time  string
+	Teams string
+	// Event names the game, league and tournament.
+	Event string
+}
+
+// DigestDay groups the digest's matches by local day.
+type DigestDay struct {
+	Day     string
+	Matches []DigestMatch
+}
+
+// DigestEmailData is the weekly digest email of a calendar.
+type DigestEmailData struct {
+	Period   string
+	Timezone string
+	Days     []DigestDay
+	// More counts matches left out to keep the email short.
+	More           int
+	SettingsURL    string
+	UnsubscribeURL string
+}
+
+// ConfirmEmailData is the double opt-in email sent when an address subscribes.
+type ConfirmEmailData struct {
+	Email       string
+	ConfirmURL  string
+	SettingsURL string
+}
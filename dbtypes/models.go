@@ -8,6 +8,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type EmailSubscription struct {
+	ID                 int32
+	HashedKey          string
+	Email              string
+	Timezone           string
+	Token              string
+	ConfirmedAt        pgtype.Timestamp
+	LastSentAt         pgtype.Timestamp
+	CreatedAt          pgtype.Timestamp
+	ConfirmationSentAt pgtype.Timestamp
+	OwnerKey           pgtype.Text
+}
+
 type Game struct {
 	ID   int32
 	Name string
@@ -40,6 +53,44 @@ type Match struct {
 	TournamentID      int32
 }
 
+type MatchChange struct {
+	ID                int64
+	MatchID           int32
+	Kind              string
+	PreviousStartTime pgtype.Timestamp
+	Dispatched        bool
+	CreatedAt         pgtype.Timestamp
+}
+
+type MatchRevision struct {
+	Revision  int64
+	MatchID   int32
+	ChangedAt pgtype.Timestamp
+}
+
+type PreviewSelection struct {
+	HashedKey string
+	ValueList []byte
+	ExpiresAt pgtype.Timestamp
+}
+
+type PushReminder struct {
+	SubscriptionID int32
+	MatchID        int32
+	SentAt         pgtype.Timestamp
+}
+
+type PushSubscription struct {
+	ID          int32
+	HashedKey   string
+	Endpoint    string
+	P256dh      string
+	Auth        string
+	LeadMinutes int32
+	CreatedAt   pgtype.Timestamp
+	OwnerKey    pgtype.Text
+}
+
 type Series struct {
 	ID       int32
 	Name     string
@@ -74,3 +125,43 @@ type UrlMapping struct {
 	CreatedAt   pgtype.Timestamp
 	AccessedAt  pgtype.Timestamp
 }
+
+type VapidKey struct {
+	ID         int16
+	PublicKey  string
+	PrivateKey string
+	CreatedAt  pgtype.Timestamp
+}
+
+type Webhook struct {
+	ID        int32
+	HashedKey string
+	Url       string
+	Secret    string
+	CreatedAt pgtype.Timestamp
+	Kind      string
+	OwnerKey  pgtype.Text
+}
+
+type WebhookDeadLetter struct {
+	ID        int64
+	WebhookID int32
+	ChangeID  int64
+	Event     string
+	Payload   []byte
+	Attempts  int32
+	LastError string
+	FailedAt  pgtype.Timestamp
+}
+
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     int32
+	ChangeID      int64
+	Event         string
+	Payload       []byte
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamp
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamp
+}
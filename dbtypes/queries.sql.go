@@ -11,6 +11,459 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimDueEmailDigests = `-- name: ClaimDueEmailDigests :many
+UPDATE email_subscriptions e
+SET last_sent_at = NOW() AT TIME ZONE 'UTC'
+FROM url_mappings u
+WHERE e.hashed_key = u.hashed_key
+  AND e.id IN (
+    SELECT s.id FROM email_subscriptions s
+    WHERE s.confirmed_at IS NOT NULL
+      AND NOW() AT TIME ZONE s.timezone
+          >= date_trunc('week', NOW() AT TIME ZONE s.timezone) + make_interval(hours => $1::int)
+      AND NOW() AT TIME ZONE s.timezone
+          < date_trunc('week', NOW() AT TIME ZONE s.timezone) + INTERVAL '1 day'
+      AND (s.last_sent_at IS NULL
+          OR s.last_sent_at AT TIME ZONE 'UTC' < date_trunc('week', NOW() AT TIME ZONE s.timezone) AT TIME ZONE s.timezone)
+    ORDER BY s.id
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING e.id, e.email, e.timezone, e.token, e.hashed_key, u.value_list
+`
+
+type ClaimDueEmailDigestsParams struct {
+	SendHour   int32
+	LimitCount int32
+}
+
+type ClaimDueEmailDigestsRow struct {
+	ID        int32
+	Email     string
+	Timezone  string
+	Token     string
+	HashedKey string
+	ValueList []byte
+}
+
+// Claims confirmed subscriptions whose local time is past the send hour on Monday
+// and that have not been mailed since that Monday began. Times are stored in UTC.
+func (q *Queries) ClaimDueEmailDigests(ctx context.Context, arg ClaimDueEmailDigestsParams) ([]ClaimDueEmailDigestsRow, error) {
+	rows, err := q.db.Query(ctx, claimDueEmailDigests, arg.SendHour, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueEmailDigestsRow
+	for rows.Next() {
+		var i ClaimDueEmailDigestsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Timezone,
+			&i.Token,
+			&i.HashedKey,
+			&i.ValueList,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = NOW() + make_interval(secs => $1::int)
+FROM webhooks w
+WHERE d.webhook_id = w.id
+    AND d.id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT $2::int
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+`
+
+type ClaimDueWebhookDeliveriesParams struct {
+	LeaseSeconds int32
+	LimitCount   int32
+}
+
+type ClaimDueWebhookDeliveriesRow struct {
+	ID        int64
+	WebhookID int32
+	Event     string
+	Payload   []byte
+	Attempts  int32
+	Url       string
+	Secret    string
+}
+
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, arg.LeaseSeconds, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimEmailConfirmation = `-- name: ClaimEmailConfirmation :execrows
+UPDATE email_subscriptions
+SET confirmation_sent_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = $1
+  AND confirmed_at IS NULL
+  AND (confirmation_sent_at IS NULL
+      OR confirmation_sent_at < NOW() AT TIME ZONE 'UTC' - make_interval(secs => $2::int))
+`
+
+type ClaimEmailConfirmationParams struct {
+	ID              int32
+	CooldownSeconds int32
+}
+
+// Records that the confirmation mail of a pending subscription is being sent, unless one
+// went out within the cooldown. Times are stored in UTC.
+func (q *Queries) ClaimEmailConfirmation(ctx context.Context, arg ClaimEmailConfirmationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimEmailConfirmation, arg.ID, arg.CooldownSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimMatchChanges = `-- name: ClaimMatchChanges :many
+UPDATE match_changes
+SET dispatched = TRUE
+WHERE id IN (
+    SELECT id FROM match_changes
+    WHERE NOT dispatched
+    ORDER BY id
+    LIMIT $1::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, match_id, kind, previous_start_time, created_at
+`
+
+type ClaimMatchChangesRow struct {
+	ID                int64
+	MatchID           int32
+	Kind              string
+	PreviousStartTime pgtype.Timestamp
+	CreatedAt         pgtype.Timestamp
+}
+
+func (q *Queries) ClaimMatchChanges(ctx context.Context, limitCount int32) ([]ClaimMatchChangesRow, error) {
+	rows, err := q.db.Query(ctx, claimMatchChanges, limitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimMatchChangesRow
+	for rows.Next() {
+		var i ClaimMatchChangesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MatchID,
+			&i.Kind,
+			&i.PreviousStartTime,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimPushReminder = `-- name: ClaimPushReminder :execrows
+INSERT INTO push_reminders (subscription_id, match_id)
+VALUES ($1, $2)
+ON CONFLICT (subscription_id, match_id) DO NOTHING
+`
+
+type ClaimPushReminderParams struct {
+	SubscriptionID int32
+	MatchID        int32
+}
+
+func (q *Queries) ClaimPushReminder(ctx context.Context, arg ClaimPushReminderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimPushReminder, arg.SubscriptionID, arg.MatchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const confirmEmailSubscription = `-- name: ConfirmEmailSubscription :one
+UPDATE email_subscriptions
+SET confirmed_at = COALESCE(confirmed_at, NOW() AT TIME ZONE 'UTC')
+WHERE token = $1
+RETURNING hashed_key, email
+`
+
+type ConfirmEmailSubscriptionRow struct {
+	HashedKey string
+	Email     string
+}
+
+func (q *Queries) ConfirmEmailSubscription(ctx context.Context, token string) (ConfirmEmailSubscriptionRow, error) {
+	row := q.db.QueryRow(ctx, confirmEmailSubscription, token)
+	var i ConfirmEmailSubscriptionRow
+	err := row.Scan(&i.HashedKey, &i.Email)
+	return i, err
+}
+
+const countURLMappings = `-- name: CountURLMappings :one
+SELECT COUNT(*) FROM url_mappings
+`
+
+func (q *Queries) CountURLMappings(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countURLMappings)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deadLetterWebhookDelivery = `-- name: DeadLetterWebhookDelivery :exec
+WITH failed AS (
+    DELETE FROM webhook_deliveries
+    WHERE id = $1
+    RETURNING webhook_id, change_id, event, payload, attempts
+)
+INSERT INTO webhook_dead_letters (webhook_id, change_id, event, payload, attempts, last_error)
+SELECT webhook_id, change_id, event, payload, attempts + 1, $2::text
+FROM failed
+`
+
+type DeadLetterWebhookDeliveryParams struct {
+	ID        int64
+	LastError string
+}
+
+func (q *Queries) DeadLetterWebhookDelivery(ctx context.Context, arg DeadLetterWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, deadLetterWebhookDelivery, arg.ID, arg.LastError)
+	return err
+}
+
+const deleteDispatchedMatchChanges = `-- name: DeleteDispatchedMatchChanges :execrows
+DELETE FROM match_changes c
+WHERE c.dispatched
+    AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.change_id = c.id)
+`
+
+// Changes already fanned out whose deliveries have all been sent or dead-lettered.
+func (q *Queries) DeleteDispatchedMatchChanges(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDispatchedMatchChanges)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteEmailSubscription = `-- name: DeleteEmailSubscription :execrows
+DELETE FROM email_subscriptions
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text
+`
+
+type DeleteEmailSubscriptionParams struct {
+	ID        int32
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) DeleteEmailSubscription(ctx context.Context, arg DeleteEmailSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEmailSubscription, arg.ID, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteEmailSubscriptionByToken = `-- name: DeleteEmailSubscriptionByToken :one
+DELETE FROM email_subscriptions
+WHERE token = $1
+RETURNING hashed_key
+`
+
+func (q *Queries) DeleteEmailSubscriptionByToken(ctx context.Context, token string) (string, error) {
+	row := q.db.QueryRow(ctx, deleteEmailSubscriptionByToken, token)
+	var hashed_key string
+	err := row.Scan(&hashed_key)
+	return hashed_key, err
+}
+
+const deleteExpiredPreviewSelections = `-- name: DeleteExpiredPreviewSelections :execrows
+DELETE FROM preview_selections
+WHERE expires_at <= CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredPreviewSelections(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredPreviewSelections)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOldMatchRevisions = `-- name: DeleteOldMatchRevisions :execrows
+DELETE FROM match_revisions
+WHERE changed_at < CURRENT_TIMESTAMP - make_interval(hours => $1::int)
+    AND revision < (SELECT MAX(revision) FROM match_revisions)
+`
+
+// Revisions older than every valid sync token. The latest revision is kept, so new tokens never go back.
+func (q *Queries) DeleteOldMatchRevisions(ctx context.Context, maxAgeHours int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOldMatchRevisions, maxAgeHours)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOldPushReminders = `-- name: DeleteOldPushReminders :exec
+DELETE FROM push_reminders
+WHERE sent_at < NOW() - INTERVAL '7 days'
+`
+
+func (q *Queries) DeleteOldPushReminders(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteOldPushReminders)
+	return err
+}
+
+const deleteOwnedWebhook = `-- name: DeleteOwnedWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text
+`
+
+type DeleteOwnedWebhookParams struct {
+	ID        int32
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) DeleteOwnedWebhook(ctx context.Context, arg DeleteOwnedWebhookParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOwnedWebhook, arg.ID, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePushEndpoint = `-- name: DeletePushEndpoint :exec
+DELETE FROM push_subscriptions
+WHERE endpoint = $1
+`
+
+func (q *Queries) DeletePushEndpoint(ctx context.Context, endpoint string) error {
+	_, err := q.db.Exec(ctx, deletePushEndpoint, endpoint)
+	return err
+}
+
+const deletePushSubscription = `-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text
+`
+
+type DeletePushSubscriptionParams struct {
+	ID        int32
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePushSubscription, arg.ID, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePushSubscriptionByEndpoint = `-- name: DeletePushSubscriptionByEndpoint :execrows
+DELETE FROM push_subscriptions
+WHERE hashed_key = $1 AND endpoint = $2
+`
+
+type DeletePushSubscriptionByEndpointParams struct {
+	HashedKey string
+	Endpoint  string
+}
+
+func (q *Queries) DeletePushSubscriptionByEndpoint(ctx context.Context, arg DeletePushSubscriptionByEndpointParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePushSubscriptionByEndpoint, arg.HashedKey, arg.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhookDelivery = `-- name: DeleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookDelivery(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteWebhookDelivery, id)
+	return err
+}
+
+const ensureVAPIDKeys = `-- name: EnsureVAPIDKeys :one
+
+INSERT INTO vapid_keys (id, public_key, private_key)
+VALUES (1, $1, $2)
+ON CONFLICT (id) DO UPDATE
+SET id = vapid_keys.id
+RETURNING public_key, private_key
+`
+
+type EnsureVAPIDKeysParams struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+type EnsureVAPIDKeysRow struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+// ============================================================================
+// Web Push Queries (for Match Reminders)
+// ============================================================================
+func (q *Queries) EnsureVAPIDKeys(ctx context.Context, arg EnsureVAPIDKeysParams) (EnsureVAPIDKeysRow, error) {
+	row := q.db.QueryRow(ctx, ensureVAPIDKeys, arg.PublicKey, arg.PrivateKey)
+	var i EnsureVAPIDKeysRow
+	err := row.Scan(&i.PublicKey, &i.PrivateKey)
+	return i, err
+}
+
 const gameExist = `-- name: GameExist :one
 
 SELECT COUNT(*) FROM games WHERE id = $1
@@ -286,6 +739,65 @@ func (q *Queries) GetFutureMatchesBySelections(ctx context.Context, arg GetFutur
 	return items, nil
 }
 
+const getLatestMatchChange = `-- name: GetLatestMatchChange :one
+SELECT MAX(changed_at)::timestamp AS changed_at
+FROM match_revisions
+`
+
+func (q *Queries) GetLatestMatchChange(ctx context.Context) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getLatestMatchChange)
+	var changed_at pgtype.Timestamp
+	err := row.Scan(&changed_at)
+	return changed_at, err
+}
+
+const getLatestMatchRevision = `-- name: GetLatestMatchRevision :one
+
+SELECT COALESCE(MAX(revision), 0)::bigint AS revision
+FROM match_revisions
+`
+
+// ============================================================================
+// Match Revision Queries (for CalDAV Sync)
+// ============================================================================
+func (q *Queries) GetLatestMatchRevision(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, getLatestMatchRevision)
+	var revision int64
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const getLeagueGameIDs = `-- name: GetLeagueGameIDs :many
+SELECT id, game_id
+FROM leagues
+WHERE id = ANY($1::int[])
+`
+
+type GetLeagueGameIDsRow struct {
+	ID     int32
+	GameID int32
+}
+
+func (q *Queries) GetLeagueGameIDs(ctx context.Context, ids []int32) ([]GetLeagueGameIDsRow, error) {
+	rows, err := q.db.Query(ctx, getLeagueGameIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLeagueGameIDsRow
+	for rows.Next() {
+		var i GetLeagueGameIDsRow
+		if err := rows.Scan(&i.ID, &i.GameID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLeaguesByGameID = `-- name: GetLeaguesByGameID :many
 SELECT
     l.id,
@@ -337,30 +849,198 @@ func (q *Queries) GetLeaguesByGameID(ctx context.Context, gameID int32) ([]GetLe
 	return items, nil
 }
 
-const getPastMatchesBySelections = `-- name: GetPastMatchesBySelections :many
+const getMatchForWebhook = `-- name: GetMatchForWebhook :one
 SELECT
-    id, name, slug, expected_start_time, finished,
-    team1_id, team2_id, team1_score, team2_score, amount_of_games,
-    game_id, league_id, series_id, tournament_id,
-    game_name, league_name,
-    team1_name, team1_acronym, team1_image,
-    team2_name, team2_acronym, team2_image
-FROM (
-    SELECT
-        m.id, m.name, m.slug, m.expected_start_time, m.finished,
-        m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.amount_of_games,
-        m.game_id, m.league_id, m.series_id, m.tournament_id,
-        g.name AS game_name,
-        l.name AS league_name,
-        t1.name AS team1_name, t1.acronym AS team1_acronym, t1.image_link AS team1_image,
-        t2.name AS team2_name, t2.acronym AS team2_acronym, t2.image_link AS team2_image
-    FROM matches m
-    JOIN games g ON m.game_id = g.id
-    JOIN leagues l ON m.league_id = l.id
-    JOIN tournaments tour ON m.tournament_id = tour.id
-    LEFT JOIN teams t1 ON m.team1_id = t1.id
-    LEFT JOIN teams t2 ON m.team2_id = t2.id
-    WHERE m.expected_start_time < NOW()
+    m.id, m.name, m.expected_start_time, m.finished,
+    m.team1_id, m.team2_id, m.team1_score, m.team2_score,
+    m.game_id, m.league_id,
+    g.name AS game_name,
+    l.name AS league_name,
+    tour.name AS tournament_name,
+    tour.tier AS tournament_tier,
+    t1.name AS team1_name, t1.image_link AS team1_image,
+    t2.name AS team2_name, t2.image_link AS team2_image
+FROM matches m
+JOIN games g ON m.game_id = g.id
+JOIN leagues l ON m.league_id = l.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+LEFT JOIN teams t1 ON m.team1_id = t1.id
+LEFT JOIN teams t2 ON m.team2_id = t2.id
+WHERE m.id = $1
+`
+
+type GetMatchForWebhookRow struct {
+	ID                int32
+	Name              string
+	ExpectedStartTime pgtype.Timestamp
+	Finished          bool
+	Team1ID           int32
+	Team2ID           int32
+	Team1Score        int32
+	Team2Score        int32
+	GameID            int32
+	LeagueID          int32
+	GameName          string
+	LeagueName        string
+	TournamentName    string
+	TournamentTier    pgtype.Int4
+	Team1Name         pgtype.Text
+	Team1Image        pgtype.Text
+	Team2Name         pgtype.Text
+	Team2Image        pgtype.Text
+}
+
+func (q *Queries) GetMatchForWebhook(ctx context.Context, id int32) (GetMatchForWebhookRow, error) {
+	row := q.db.QueryRow(ctx, getMatchForWebhook, id)
+	var i GetMatchForWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExpectedStartTime,
+		&i.Finished,
+		&i.Team1ID,
+		&i.Team2ID,
+		&i.Team1Score,
+		&i.Team2Score,
+		&i.GameID,
+		&i.LeagueID,
+		&i.GameName,
+		&i.LeagueName,
+		&i.TournamentName,
+		&i.TournamentTier,
+		&i.Team1Name,
+		&i.Team1Image,
+		&i.Team2Name,
+		&i.Team2Image,
+	)
+	return i, err
+}
+
+const getMatchRevisionsSince = `-- name: GetMatchRevisionsSince :many
+SELECT r.match_id, MAX(r.revision)::bigint AS revision
+FROM match_revisions r
+JOIN matches m ON r.match_id = m.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE r.revision > $1::bigint
+    AND m.game_id = ANY($2::int[])
+    AND (
+        (CARDINALITY($3::int[]) > 0 AND (m.team1_id = ANY($3::int[]) OR m.team2_id = ANY($3::int[])))
+        OR (CARDINALITY($4::int[]) > 0 AND m.league_id = ANY($4::int[]) AND COALESCE(tour.tier, 0) <= $5::int)
+    )
+GROUP BY r.match_id
+`
+
+type GetMatchRevisionsSinceParams struct {
+	Since     int64
+	GameIds   []int32
+	TeamIds   []int32
+	LeagueIds []int32
+	MaxTier   int32
+}
+
+type GetMatchRevisionsSinceRow struct {
+	MatchID  int32
+	Revision int64
+}
+
+func (q *Queries) GetMatchRevisionsSince(ctx context.Context, arg GetMatchRevisionsSinceParams) ([]GetMatchRevisionsSinceRow, error) {
+	rows, err := q.db.Query(ctx, getMatchRevisionsSince,
+		arg.Since,
+		arg.GameIds,
+		arg.TeamIds,
+		arg.LeagueIds,
+		arg.MaxTier,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMatchRevisionsSinceRow
+	for rows.Next() {
+		var i GetMatchRevisionsSinceRow
+		if err := rows.Scan(&i.MatchID, &i.Revision); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMatchesLeftCalendarWindow = `-- name: GetMatchesLeftCalendarWindow :many
+SELECT m.id
+FROM matches m
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE m.expected_start_time >= TO_TIMESTAMP($1::bigint) - INTERVAL '3 days'
+    AND m.expected_start_time < NOW() - INTERVAL '3 days'
+    AND m.game_id = ANY($2::int[])
+    AND (
+        (CARDINALITY($3::int[]) > 0 AND (m.team1_id = ANY($3::int[]) OR m.team2_id = ANY($3::int[])))
+        OR (CARDINALITY($4::int[]) > 0 AND m.league_id = ANY($4::int[]) AND COALESCE(tour.tier, 0) <= $5::int)
+    )
+`
+
+type GetMatchesLeftCalendarWindowParams struct {
+	IssuedAt  int64
+	GameIds   []int32
+	TeamIds   []int32
+	LeagueIds []int32
+	MaxTier   int32
+}
+
+// Matches of a selection that fell out of the calendar window since issued_at, a Unix time.
+func (q *Queries) GetMatchesLeftCalendarWindow(ctx context.Context, arg GetMatchesLeftCalendarWindowParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getMatchesLeftCalendarWindow,
+		arg.IssuedAt,
+		arg.GameIds,
+		arg.TeamIds,
+		arg.LeagueIds,
+		arg.MaxTier,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPastMatchesBySelections = `-- name: GetPastMatchesBySelections :many
+SELECT
+    id, name, slug, expected_start_time, finished,
+    team1_id, team2_id, team1_score, team2_score, amount_of_games,
+    game_id, league_id, series_id, tournament_id,
+    game_name, league_name,
+    team1_name, team1_acronym, team1_image,
+    team2_name, team2_acronym, team2_image
+FROM (
+    SELECT
+        m.id, m.name, m.slug, m.expected_start_time, m.finished,
+        m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.amount_of_games,
+        m.game_id, m.league_id, m.series_id, m.tournament_id,
+        g.name AS game_name,
+        l.name AS league_name,
+        t1.name AS team1_name, t1.acronym AS team1_acronym, t1.image_link AS team1_image,
+        t2.name AS team2_name, t2.acronym AS team2_acronym, t2.image_link AS team2_image
+    FROM matches m
+    JOIN games g ON m.game_id = g.id
+    JOIN leagues l ON m.league_id = l.id
+    JOIN tournaments tour ON m.tournament_id = tour.id
+    LEFT JOIN teams t1 ON m.team1_id = t1.id
+    LEFT JOIN teams t2 ON m.team2_id = t2.id
+    WHERE m.expected_start_time < NOW()
         AND m.game_id = ANY($1::int[])
         AND (
             (CARDINALITY($2::int[]) > 0 AND (m.team1_id = ANY($2::int[]) OR m.team2_id = ANY($2::int[])))
@@ -454,6 +1134,19 @@ func (q *Queries) GetPastMatchesBySelections(ctx context.Context, arg GetPastMat
 	return items, nil
 }
 
+const getPreviewSelection = `-- name: GetPreviewSelection :one
+SELECT value_list
+FROM preview_selections
+WHERE hashed_key = $1 AND expires_at > CURRENT_TIMESTAMP
+`
+
+func (q *Queries) GetPreviewSelection(ctx context.Context, hashedKey string) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getPreviewSelection, hashedKey)
+	var value_list []byte
+	err := row.Scan(&value_list)
+	return value_list, err
+}
+
 const getSeriesByGameID = `-- name: GetSeriesByGameID :many
 SELECT id, name, slug, game_id, league_id
 FROM series
@@ -487,6 +1180,37 @@ func (q *Queries) GetSeriesByGameID(ctx context.Context, gameID int32) ([]Series
 	return items, nil
 }
 
+const getTeamGameIDs = `-- name: GetTeamGameIDs :many
+SELECT id, game_id
+FROM teams
+WHERE id = ANY($1::int[])
+`
+
+type GetTeamGameIDsRow struct {
+	ID     int32
+	GameID int32
+}
+
+func (q *Queries) GetTeamGameIDs(ctx context.Context, ids []int32) ([]GetTeamGameIDsRow, error) {
+	rows, err := q.db.Query(ctx, getTeamGameIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTeamGameIDsRow
+	for rows.Next() {
+		var i GetTeamGameIDsRow
+		if err := rows.Scan(&i.ID, &i.GameID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTeamsByGameID = `-- name: GetTeamsByGameID :many
 SELECT id, name, slug, acronym, image_link, game_id
 FROM teams
@@ -771,6 +1495,68 @@ func (q *Queries) InsertURLMapping(ctx context.Context, arg InsertURLMappingPara
 	return err
 }
 
+const insertWebhook = `-- name: InsertWebhook :one
+
+INSERT INTO webhooks (hashed_key, url, secret, kind, owner_key)
+VALUES ($1, $2, $3, $4, $5::text)
+RETURNING id, hashed_key, url, secret, created_at, kind, owner_key
+`
+
+type InsertWebhookParams struct {
+	HashedKey string
+	Url       string
+	Secret    string
+	Kind      string
+	OwnerKey  string
+}
+
+// ============================================================================
+// Webhook Queries (for Match Change Notifications)
+// ============================================================================
+func (q *Queries) InsertWebhook(ctx context.Context, arg InsertWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, insertWebhook,
+		arg.HashedKey,
+		arg.Url,
+		arg.Secret,
+		arg.Kind,
+		arg.OwnerKey,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.HashedKey,
+		&i.Url,
+		&i.Secret,
+		&i.CreatedAt,
+		&i.Kind,
+		&i.OwnerKey,
+	)
+	return i, err
+}
+
+const insertWebhookDelivery = `-- name: InsertWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, change_id, event, payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (webhook_id, change_id) DO NOTHING
+`
+
+type InsertWebhookDeliveryParams struct {
+	WebhookID int32
+	ChangeID  int64
+	Event     string
+	Payload   []byte
+}
+
+func (q *Queries) InsertWebhookDelivery(ctx context.Context, arg InsertWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, insertWebhookDelivery,
+		arg.WebhookID,
+		arg.ChangeID,
+		arg.Event,
+		arg.Payload,
+	)
+	return err
+}
+
 const leagueExist = `-- name: LeagueExist :one
 SELECT COUNT(*) FROM leagues WHERE id = $1
 `
@@ -782,6 +1568,403 @@ func (q *Queries) LeagueExist(ctx context.Context, id int32) (int64, error) {
 	return count, err
 }
 
+const listEmailSubscriptionsByOwner = `-- name: ListEmailSubscriptionsByOwner :many
+SELECT id, hashed_key, email, timezone, token, confirmed_at, last_sent_at, created_at, confirmation_sent_at, owner_key
+FROM email_subscriptions
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY created_at
+`
+
+type ListEmailSubscriptionsByOwnerParams struct {
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) ListEmailSubscriptionsByOwner(ctx context.Context, arg ListEmailSubscriptionsByOwnerParams) ([]EmailSubscription, error) {
+	rows, err := q.db.Query(ctx, listEmailSubscriptionsByOwner, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EmailSubscription
+	for rows.Next() {
+		var i EmailSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedKey,
+			&i.Email,
+			&i.Timezone,
+			&i.Token,
+			&i.ConfirmedAt,
+			&i.LastSentAt,
+			&i.CreatedAt,
+			&i.ConfirmationSentAt,
+			&i.OwnerKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHotURLMappings = `-- name: ListHotURLMappings :many
+SELECT hashed_key, value_list
+FROM url_mappings
+WHERE accessed_at > CURRENT_TIMESTAMP - make_interval(mins => $1::int)
+ORDER BY access_count DESC, accessed_at DESC
+LIMIT $2::int
+`
+
+type ListHotURLMappingsParams struct {
+	ActiveMinutes int32
+	LimitCount    int32
+}
+
+type ListHotURLMappingsRow struct {
+	HashedKey string
+	ValueList []byte
+}
+
+func (q *Queries) ListHotURLMappings(ctx context.Context, arg ListHotURLMappingsParams) ([]ListHotURLMappingsRow, error) {
+	rows, err := q.db.Query(ctx, listHotURLMappings, arg.ActiveMinutes, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListHotURLMappingsRow
+	for rows.Next() {
+		var i ListHotURLMappingsRow
+		if err := rows.Scan(&i.HashedKey, &i.ValueList); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchUpdatesSince = `-- name: ListMatchUpdatesSince :many
+
+SELECT
+    m.id, m.finished, m.expected_start_time,
+    m.team1_id, m.team2_id, m.team1_score, m.team2_score,
+    m.game_id, m.league_id,
+    tour.tier AS tournament_tier,
+    MAX(r.revision)::bigint AS revision
+FROM match_revisions r
+JOIN matches m ON r.match_id = m.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE r.revision > $1::bigint
+GROUP BY m.id, tour.tier
+ORDER BY revision ASC
+LIMIT $2::int
+`
+
+type ListMatchUpdatesSinceParams struct {
+	Since      int64
+	LimitCount int32
+}
+
+type ListMatchUpdatesSinceRow struct {
+	ID                int32
+	Finished          bool
+	ExpectedStartTime pgtype.Timestamp
+	Team1ID           int32
+	Team2ID           int32
+	Team1Score        int32
+	Team2Score        int32
+	GameID            int32
+	LeagueID          int32
+	TournamentTier    pgtype.Int4
+	Revision          int64
+}
+
+// ============================================================================
+// Live Score Queries (for Preview Streams)
+// ============================================================================
+func (q *Queries) ListMatchUpdatesSince(ctx context.Context, arg ListMatchUpdatesSinceParams) ([]ListMatchUpdatesSinceRow, error) {
+	rows, err := q.db.Query(ctx, listMatchUpdatesSince, arg.Since, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMatchUpdatesSinceRow
+	for rows.Next() {
+		var i ListMatchUpdatesSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Finished,
+			&i.ExpectedStartTime,
+			&i.Team1ID,
+			&i.Team2ID,
+			&i.Team1Score,
+			&i.Team2Score,
+			&i.GameID,
+			&i.LeagueID,
+			&i.TournamentTier,
+			&i.Revision,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMissingTables = `-- name: ListMissingTables :many
+
+SELECT t.name::text AS table_name
+FROM unnest($1::text[]) AS t(name)
+WHERE to_regclass(t.name) IS NULL
+`
+
+// ============================================================================
+// Health Queries (for Readiness and Diagnostics)
+// ============================================================================
+func (q *Queries) ListMissingTables(ctx context.Context, tableNames []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, listMissingTables, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var table_name string
+		if err := rows.Scan(&table_name); err != nil {
+			return nil, err
+		}
+		items = append(items, table_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPushReminderTargets = `-- name: ListPushReminderTargets :many
+SELECT p.id, p.hashed_key, p.endpoint, p.p256dh, p.auth, p.lead_minutes, u.value_list
+FROM push_subscriptions p
+JOIN url_mappings u ON p.hashed_key = u.hashed_key
+ORDER BY p.hashed_key, p.id
+`
+
+type ListPushReminderTargetsRow struct {
+	ID          int32
+	HashedKey   string
+	Endpoint    string
+	P256dh      string
+	Auth        string
+	LeadMinutes int32
+	ValueList   []byte
+}
+
+func (q *Queries) ListPushReminderTargets(ctx context.Context) ([]ListPushReminderTargetsRow, error) {
+	rows, err := q.db.Query(ctx, listPushReminderTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPushReminderTargetsRow
+	for rows.Next() {
+		var i ListPushReminderTargetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedKey,
+			&i.Endpoint,
+			&i.P256dh,
+			&i.Auth,
+			&i.LeadMinutes,
+			&i.ValueList,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPushSubscriptionsByOwner = `-- name: ListPushSubscriptionsByOwner :many
+SELECT id, hashed_key, endpoint, p256dh, auth, lead_minutes, created_at, owner_key
+FROM push_subscriptions
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY id
+`
+
+type ListPushSubscriptionsByOwnerParams struct {
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) ListPushSubscriptionsByOwner(ctx context.Context, arg ListPushSubscriptionsByOwnerParams) ([]PushSubscription, error) {
+	rows, err := q.db.Query(ctx, listPushSubscriptionsByOwner, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PushSubscription
+	for rows.Next() {
+		var i PushSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedKey,
+			&i.Endpoint,
+			&i.P256dh,
+			&i.Auth,
+			&i.LeadMinutes,
+			&i.CreatedAt,
+			&i.OwnerKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeadLettersByOwner = `-- name: ListWebhookDeadLettersByOwner :many
+SELECT dl.id, dl.webhook_id, dl.event, dl.attempts, dl.last_error, dl.failed_at
+FROM webhook_dead_letters dl
+JOIN webhooks w ON dl.webhook_id = w.id
+WHERE w.hashed_key = $1 AND w.owner_key = $2::text
+ORDER BY dl.failed_at DESC
+LIMIT 100
+`
+
+type ListWebhookDeadLettersByOwnerParams struct {
+	HashedKey string
+	OwnerKey  string
+}
+
+type ListWebhookDeadLettersByOwnerRow struct {
+	ID        int64
+	WebhookID int32
+	Event     string
+	Attempts  int32
+	LastError string
+	FailedAt  pgtype.Timestamp
+}
+
+func (q *Queries) ListWebhookDeadLettersByOwner(ctx context.Context, arg ListWebhookDeadLettersByOwnerParams) ([]ListWebhookDeadLettersByOwnerRow, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeadLettersByOwner, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookDeadLettersByOwnerRow
+	for rows.Next() {
+		var i ListWebhookDeadLettersByOwnerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Attempts,
+			&i.LastError,
+			&i.FailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT w.id, w.hashed_key, w.kind, u.value_list
+FROM webhooks w
+JOIN url_mappings u ON w.hashed_key = u.hashed_key
+ORDER BY w.id
+`
+
+type ListWebhookSubscriptionsRow struct {
+	ID        int32
+	HashedKey string
+	Kind      string
+	ValueList []byte
+}
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]ListWebhookSubscriptionsRow, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhookSubscriptionsRow
+	for rows.Next() {
+		var i ListWebhookSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedKey,
+			&i.Kind,
+			&i.ValueList,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksByOwner = `-- name: ListWebhooksByOwner :many
+SELECT id, hashed_key, url, secret, created_at, kind, owner_key
+FROM webhooks
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY id
+`
+
+type ListWebhooksByOwnerParams struct {
+	HashedKey string
+	OwnerKey  string
+}
+
+func (q *Queries) ListWebhooksByOwner(ctx context.Context, arg ListWebhooksByOwnerParams) ([]Webhook, error) {
+	rows, err := q.db.Query(ctx, listWebhooksByOwner, arg.HashedKey, arg.OwnerKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedKey,
+			&i.Url,
+			&i.Secret,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.OwnerKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const matchExist = `-- name: MatchExist :one
 SELECT COUNT(*) FROM matches WHERE id = $1
 `
@@ -793,6 +1976,64 @@ func (q *Queries) MatchExist(ctx context.Context, id int32) (int64, error) {
 	return count, err
 }
 
+const releaseEmailConfirmation = `-- name: ReleaseEmailConfirmation :exec
+UPDATE email_subscriptions
+SET confirmation_sent_at = NULL
+WHERE id = $1
+`
+
+// Lets the confirmation mail be sent again right away after a failed send.
+func (q *Queries) ReleaseEmailConfirmation(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, releaseEmailConfirmation, id)
+	return err
+}
+
+const releaseEmailDigest = `-- name: ReleaseEmailDigest :exec
+UPDATE email_subscriptions
+SET last_sent_at = NULL
+WHERE id = $1
+`
+
+// Makes a claimed digest due again after a failed send.
+func (q *Queries) ReleaseEmailDigest(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, releaseEmailDigest, id)
+	return err
+}
+
+const releasePushReminder = `-- name: ReleasePushReminder :exec
+DELETE FROM push_reminders
+WHERE subscription_id = $1 AND match_id = $2
+`
+
+type ReleasePushReminderParams struct {
+	SubscriptionID int32
+	MatchID        int32
+}
+
+func (q *Queries) ReleasePushReminder(ctx context.Context, arg ReleasePushReminderParams) error {
+	_, err := q.db.Exec(ctx, releasePushReminder, arg.SubscriptionID, arg.MatchID)
+	return err
+}
+
+const rescheduleWebhookDelivery = `-- name: RescheduleWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => $1::int),
+    last_error = $2::text
+WHERE id = $3
+`
+
+type RescheduleWebhookDeliveryParams struct {
+	DelaySeconds int32
+	LastError    string
+	ID           int64
+}
+
+func (q *Queries) RescheduleWebhookDelivery(ctx context.Context, arg RescheduleWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, rescheduleWebhookDelivery, arg.DelaySeconds, arg.LastError, arg.ID)
+	return err
+}
+
 const seriesExist = `-- name: SeriesExist :one
 SELECT COUNT(*) FROM series WHERE id = $1
 `
@@ -836,3 +2077,110 @@ func (q *Queries) UpdateURLMappingAccessCount(ctx context.Context, hashedKey str
 	_, err := q.db.Exec(ctx, updateURLMappingAccessCount, hashedKey)
 	return err
 }
+
+const upsertEmailSubscription = `-- name: UpsertEmailSubscription :one
+
+INSERT INTO email_subscriptions (hashed_key, email, timezone, token, owner_key)
+VALUES ($1, $2, $3, $4, $5::text)
+ON CONFLICT (hashed_key, email) DO UPDATE
+SET timezone = EXCLUDED.timezone
+WHERE email_subscriptions.owner_key = EXCLUDED.owner_key
+RETURNING id, hashed_key, email, timezone, token, confirmed_at, last_sent_at, created_at, confirmation_sent_at, owner_key
+`
+
+type UpsertEmailSubscriptionParams struct {
+	HashedKey string
+	Email     string
+	Timezone  string
+	Token     string
+	OwnerKey  string
+}
+
+// ============================================================================
+// Email Digest Queries (for Weekly Emails)
+// ============================================================================
+// Returns no row when the address was signed up for the calendar with another owner key.
+func (q *Queries) UpsertEmailSubscription(ctx context.Context, arg UpsertEmailSubscriptionParams) (EmailSubscription, error) {
+	row := q.db.QueryRow(ctx, upsertEmailSubscription,
+		arg.HashedKey,
+		arg.Email,
+		arg.Timezone,
+		arg.Token,
+		arg.OwnerKey,
+	)
+	var i EmailSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.HashedKey,
+		&i.Email,
+		&i.Timezone,
+		&i.Token,
+		&i.ConfirmedAt,
+		&i.LastSentAt,
+		&i.CreatedAt,
+		&i.ConfirmationSentAt,
+		&i.OwnerKey,
+	)
+	return i, err
+}
+
+const upsertPreviewSelection = `-- name: UpsertPreviewSelection :exec
+INSERT INTO preview_selections (hashed_key, value_list, expires_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP + make_interval(mins => $3::int))
+ON CONFLICT (hashed_key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+`
+
+type UpsertPreviewSelectionParams struct {
+	HashedKey  string
+	ValueList  []byte
+	TtlMinutes int32
+}
+
+func (q *Queries) UpsertPreviewSelection(ctx context.Context, arg UpsertPreviewSelectionParams) error {
+	_, err := q.db.Exec(ctx, upsertPreviewSelection, arg.HashedKey, arg.ValueList, arg.TtlMinutes)
+	return err
+}
+
+const upsertPushSubscription = `-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (hashed_key, endpoint, p256dh, auth, lead_minutes, owner_key)
+VALUES ($1, $2, $3, $4, $5, $6::text)
+ON CONFLICT (hashed_key, endpoint) DO UPDATE
+SET p256dh = EXCLUDED.p256dh,
+    auth = EXCLUDED.auth,
+    lead_minutes = EXCLUDED.lead_minutes,
+    owner_key = EXCLUDED.owner_key
+RETURNING id, hashed_key, endpoint, p256dh, auth, lead_minutes, created_at, owner_key
+`
+
+type UpsertPushSubscriptionParams struct {
+	HashedKey   string
+	Endpoint    string
+	P256dh      string
+	Auth        string
+	LeadMinutes int32
+	OwnerKey    string
+}
+
+// Only the browser knows its endpoint, so subscribing again moves the subscription to its current owner key.
+func (q *Queries) UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error) {
+	row := q.db.QueryRow(ctx, upsertPushSubscription,
+		arg.HashedKey,
+		arg.Endpoint,
+		arg.P256dh,
+		arg.Auth,
+		arg.LeadMinutes,
+		arg.OwnerKey,
+	)
+	var i PushSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.HashedKey,
+		&i.Endpoint,
+		&i.P256dh,
+		&i.Auth,
+		&i.LeadMinutes,
+		&i.CreatedAt,
+		&i.OwnerKey,
+	)
+	return i, err
+}
@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
+	"flag"
 	"io/fs"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"strings"
+	"sync"
 	"syscall"
-	"time"
 
 	"github.com/feimaomiao/esportscalendar/middleware"
 	"github.com/gin-gonic/gin"
@@ -23,6 +26,19 @@ import (
 var staticFS embed.FS
 
 func main() {
+	cfg, printConfig, err := middleware.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		_, _ = os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(2) //nolint:mnd // Usage error, like the flag package
+	}
+	if printConfig {
+		_, _ = os.Stdout.WriteString(cfg.String())
+		return
+	}
+
 	logger, err := zap.NewProduction()
 	if err != nil {
 		panic(err)
@@ -31,20 +47,35 @@ func main() {
 		_ = logger.Sync() // Ignore error on shutdown
 	}()
 
-	logger.Info("Starting application")
+	logger.Info("Starting application", zap.String("config", cfg.String()))
+
+	// Embedded static files (CSS, JS, images, icons). Built CSS and vendored JS are not committed,
+	// so a binary built without make assets would serve unstyled pages
+	staticSubFS, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		logger.Fatal("Failed to create sub filesystem", zap.Error(err))
+	}
+	if err = middleware.CheckBuiltAssets(staticSubFS); err != nil {
+		logger.Fatal("Binary was built without its front-end assets", zap.Error(err))
+	}
 
 	// Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// Client IPs, which per-IP rate limits count, are taken from X-Forwarded-For of trusted proxies only
+	if err = router.SetTrustedProxies(cfg.HTTP.TrustedProxyList()); err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
 
-	// Add Gin's built-in recovery and logging middleware
-	router.Use(gin.Recovery())
-
-	// Serve embedded static files (CSS, JS, images, icons)
-	staticSubFS, err := fs.Sub(staticFS, "static")
+	mw, err := middleware.InitMiddleHandler(cfg, logger)
 	if err != nil {
-		logger.Fatal("Failed to create sub filesystem", zap.Error(err))
+		logger.Fatal("Failed to initialize middleware", zap.Error(err))
 	}
+
+	// Add Gin's built-in recovery middleware, then count, time, trace and log every request
+	router.Use(gin.Recovery(), mw.Metrics.Instrument, mw.Tracing.Middleware(), mw.AccessLog, mw.SecurityHeaders)
+
+	// Serve embedded static files
 	fileServer := http.FileServer(http.FS(staticSubFS))
 	router.GET("/static/*filepath", func(c *gin.Context) {
 		// Set proper MIME types for CSS and JS files
@@ -57,8 +88,6 @@ func main() {
 		fileServer.ServeHTTP(c.Writer, c.Request)
 	})
 
-	mw := middleware.InitMiddleHandler(logger)
-
 	// Serve robots.txt and sitemap.xml from embedded static files
 	router.GET("/robots.txt", func(c *gin.Context) {
 		c.Header("Content-Type", "text/plain; charset=utf-8")
@@ -78,38 +107,100 @@ func main() {
 		}
 		c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
 	})
+	// The push service worker is served from the root so its scope covers every page
+	router.GET("/push-sw.js", func(c *gin.Context) {
+		data, readErr := staticFS.ReadFile("static/js/push-sw.js")
+		if readErr != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "application/javascript; charset=utf-8", data)
+	})
+
+	// Liveness, readiness and operator diagnostics answer even while the database is down
+	router.GET("/healthz", mw.HealthzHandler)
+	router.GET("/readyz", mw.ReadyzHandler)
+	router.GET("/debug/status", mw.RequireDebugToken, mw.DebugStatusHandler)
+	router.GET("/metrics", mw.RequireDebugToken, mw.Metrics.Handler())
 
 	// Routes
-	router.GET("/", mw.IndexHandler)
-	router.Any("/lts", mw.SecondPageHandler)
-	router.POST("/preview", mw.PreviewHandler)
-	router.POST("/export", mw.ExportHandler)
 	router.GET("/how-to-use", mw.HowToUseHandler)
 	router.GET("/about", mw.AboutHandler)
-	router.GET("/api/league-options/*param", mw.LeagueOptionsHandler)
-	router.GET("/api/team-options/*param", mw.TeamOptionsHandler)
 
-	// NoRoute handler for .ics files (calendar downloads)
+	// Everything below reads the database and shows the maintenance page while it is unreachable
+	app := router.Group("", mw.RequireDatabase)
+	app.GET("/", mw.IndexHandler)
+	app.Any("/lts", mw.SecondPageHandler)
+	// Previews keep their selection only until it expires, so they have their own, looser limit
+	app.POST("/preview", mw.RateLimitByIP("preview", cfg.RateLimit.Preview), mw.PreviewHandler)
+	app.GET("/preview", mw.PreviewPageHandler)
+	app.POST("/export", mw.RateLimitByIP("export", cfg.RateLimit.Export), mw.ExportHandler)
+	app.GET("/api/league-options/*param", mw.LeagueOptionsHandler)
+	app.GET("/api/team-options/*param", mw.TeamOptionsHandler)
+
+	// Embeddable schedule widget (/embed/:hash and /embed/:hash.js) and its JSON API
+	app.GET("/embed/:hash", mw.EmbedHandler)
+	app.GET("/api/matches/:hash", mw.EmbedMatchesHandler)
+
+	// Live score stream of the preview page (Server-Sent Events)
+	app.GET("/live", mw.LiveScoresHandler)
+
+	// Outgoing webhooks for match changes, registered per calendar link
+	notifyLimit := mw.RateLimitByIP("notifications", cfg.RateLimit.Notifications)
+	app.POST("/api/webhooks/:hash", notifyLimit, mw.CreateWebhookHandler)
+	app.GET("/api/webhooks/:hash", mw.ListWebhooksHandler)
+	app.DELETE("/api/webhooks/:hash/:id", mw.DeleteWebhookHandler)
+	app.POST("/api/webhooks/:hash/:id/ping", notifyLimit, mw.PingWebhookHandler)
+
+	// Web Push reminders before matches start
+	app.GET("/api/push/vapid-public-key", mw.PushPublicKeyHandler)
+	app.POST("/api/push/:hash", notifyLimit, mw.CreatePushSubscriptionHandler)
+	app.DELETE("/api/push/:hash", mw.DeletePushSubscriptionHandler)
+
+	// Notification settings page for a calendar link
+	app.GET("/settings/:hash", mw.SettingsHandler)
+	app.POST("/settings/:hash/webhooks", notifyLimit, mw.SettingsWebhookCreateHandler)
+	app.POST("/settings/:hash/webhooks/:id/test", notifyLimit, mw.SettingsWebhookTestHandler)
+	app.POST("/settings/:hash/webhooks/:id/delete", mw.SettingsWebhookDeleteHandler)
+	app.POST("/settings/:hash/email", notifyLimit, mw.SettingsEmailSubscribeHandler)
+	app.POST("/settings/:hash/email/:id/delete", mw.SettingsEmailDeleteHandler)
+	app.POST("/settings/:hash/push/:id/test", notifyLimit, mw.SettingsPushTestHandler)
+	app.POST("/settings/:hash/push/:id/delete", mw.SettingsPushDeleteHandler)
+
+	// Double opt-in and unsubscribe links of weekly email digests
+	app.GET("/email/confirm/:token", mw.EmailConfirmHandler)
+	app.GET("/email/unsubscribe/:token", mw.EmailUnsubscribeHandler)
+	app.POST("/email/unsubscribe/:token", mw.EmailUnsubscribeHandler)
+
+	// Read-only CalDAV access to stored calendars
+	for _, method := range middleware.CalDAVMethods() {
+		app.Handle(method, "/caldav/*path", mw.CalDAVHandler)
+	}
+
+	// NoRoute handler for calendar downloads: iCalendar (.ics), jCal (.json), xCal (.xml)
+	// Atom/RSS news feeds (.atom, .rss) and spreadsheet exports (.csv)
 	router.NoRoute(func(c *gin.Context) {
-		if strings.HasSuffix(c.Request.URL.Path, ".ics") {
+		switch path.Ext(c.Request.URL.Path) {
+		case ".ics", ".json", ".xml", ".atom", ".rss", ".csv":
 			mw.CalendarHandler(c)
-		} else {
+		default:
 			c.Status(http.StatusNotFound)
 		}
 	})
 
-	logger.Info("Server starting", zap.String("port", "8080"))
-	secsInMin := 60
-	minute := time.Duration(secsInMin) * time.Second
+	logger.Info("Server starting", zap.String("addr", cfg.HTTP.Addr))
 	// Create server with timeouts for security
 	server := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.HTTP.Addr,
 		Handler:           router,
-		ReadTimeout:       minute,
-		ReadHeaderTimeout: minute,
-		WriteTimeout:      minute,
-		IdleTimeout:       minute,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
+	// End live score streams on shutdown; they would otherwise hold it until the timeout
+	server.RegisterOnShutdown(mw.Live.Close)
 
 	// Channel to listen for interrupt signals
 	quit := make(chan os.Signal, 1)
@@ -124,13 +215,41 @@ func main() {
 
 	logger.Info("Server started successfully")
 
+	// Background workers run until shutdown; those reading the database wait until it is reachable
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+	startDatabaseWorker := func(run func(context.Context)) {
+		startWorker(func(ctx context.Context) {
+			if mw.Readiness.WaitForDatabase(ctx) {
+				run(ctx)
+			}
+		})
+	}
+	startWorker(mw.RunDatabaseMonitor)
+	startDatabaseWorker(mw.RunWebhookDispatcher)
+	startDatabaseWorker(mw.RunEmailDigestScheduler)
+	startDatabaseWorker(mw.RunPushReminderScheduler)
+	startDatabaseWorker(mw.RunLiveScorePublisher)
+	startWorker(mw.RunLiveScoreRelay)
+	startWorker(mw.RunCacheInvalidationListener)
+	startWorker(mw.RunCalendarCacheJanitor)
+	startDatabaseWorker(mw.RunCalendarPrerenderer)
+	startDatabaseWorker(mw.RunMetricsCollector)
+	startDatabaseWorker(mw.RunDatabaseJanitor)
+
 	// Wait for interrupt signal
 	<-quit
 	logger.Info("Shutdown signal received")
 
 	// Create shutdown context with timeout
-	const shutdownTimeout = 10 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
 
 	// Gracefully shutdown the server
@@ -140,6 +259,11 @@ func main() {
 		logger.Info("Server shutdown gracefully")
 	}
 
-	// Perform cleanup
+	// Stop background workers before closing their connections
+	stopWorkers()
+	workers.Wait()
+
+	// Perform cleanup, then flush the spans of the last requests and jobs
 	mw.Cleanup()
+	mw.Tracing.Shutdown(ctx)
 }
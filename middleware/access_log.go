@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds request IDs taken over from clients and proxies.
+	maxRequestIDLength = 128
+	requestIDBytes     = 16
+	// cacheOutcomeKey holds the cache outcome of a request for its access log.
+	cacheOutcomeKey = "cache_outcome"
+)
+
+// loggerContextKey is the request context key of the request-scoped logger.
+type loggerContextKey struct{}
+
+// AccessLog assigns each request an ID, taking over a valid X-Request-ID from the caller, and
+// attaches a logger carrying it to the request context. Once the request is done it writes one
+// access log entry. Probes and metrics scrapes are logged at debug level.
+func (m *Middleware) AccessLog(c *gin.Context) {
+	start := time.Now()
+	requestID := c.GetHeader(requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	c.Header(requestIDHeader, requestID)
+
+	ctx := c.Request.Context()
+	logger := m.Logger.With(zap.String("request_id", requestID))
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		logger = logger.With(zap.String("trace_id", spanContext.TraceID().String()))
+	}
+	c.Request = c.Request.WithContext(context.WithValue(ctx, loggerContextKey{}, logger))
+
+	c.Next()
+
+	status := c.Writer.Status()
+	route := requestRoute(c)
+	level := zapcore.InfoLevel
+	switch {
+	case status >= http.StatusInternalServerError:
+		// The handler logged the error itself; warn without another stack trace
+		level = zapcore.WarnLevel
+	case route == "/healthz", route == "/readyz", route == "/metrics":
+		level = zapcore.DebugLevel
+	}
+	fields := []zap.Field{
+		zap.String("method", c.Request.Method),
+		zap.String("route", route),
+		zap.Int("status", status),
+		zap.Duration("latency", time.Since(start)),
+		zap.Int("bytes", max(c.Writer.Size(), 0)),
+		zap.String("client_ip", c.ClientIP()),
+	}
+	// Paths of matched routes can hold calendar hashes and email tokens, so only the route is logged
+	if route == unmatchedRoute {
+		fields = append(fields, zap.String("path", c.Request.URL.Path))
+	}
+	if outcome := c.GetString(cacheOutcomeKey); outcome != "" {
+		fields = append(fields, zap.String("cache", outcome))
+	}
+	if len(c.Errors) > 0 {
+		fields = append(fields, zap.String("errors", c.Errors.String()))
+	}
+	logger.Log(level, "Request", fields...)
+}
+
+// requestLogger returns the logger of the request ctx belongs to, or the server logger outside of
+// requests.
+func (m *Middleware) requestLogger(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return m.Logger
+}
+
+// validRequestID reports whether a caller's request ID is short and printable enough to log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return !strings.ContainsFunc(id, func(r rune) bool { return r <= ' ' || r > '~' })
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	id := make([]byte, requestIDBytes)
+	_, _ = rand.Read(id) // Never fails, see crypto/rand.Read
+	return hex.EncodeToString(id)
+}
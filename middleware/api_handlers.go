@@ -1,21 +1,20 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 func (m *Middleware) LeagueOptionsHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "LeagueOptionsHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
+	logger := m.requestLogger(c.Request.Context())
 	// Extract game ID from URL path or param
 	path := strings.TrimPrefix(c.Param("param"), "/")
 	if path == "" {
@@ -31,47 +30,50 @@ func (m *Middleware) LeagueOptionsHandler(c *gin.Context) {
 		return
 	}
 
-	// Check cache first
+	setSpanAttributes(c, attrGameID.Int64(gameID))
+
+	// Serve from cache; misses and stale entries are rendered once across concurrent requests
 	cacheKey := fmt.Sprintf("league-options:%d", gameID)
-	if m.RedisCache != nil {
-		if jsonBytes, ok := m.RedisCache.GetBytes(cacheKey); ok {
-			m.Logger.Info("Cache HIT",
-				zap.String("handler", "LeagueOptionsHandler"),
-				zap.String("cache_key", cacheKey),
-				zap.Int64("game_id", gameID),
-				zap.Int("response_size_bytes", len(jsonBytes)))
-			c.Header("Content-Type", "application/json")
-			c.Header("Cache-Control", "public, max-age=600")
-			c.Header("X-Cache", "HIT")
-			if _, writeErr := c.Writer.Write(jsonBytes); writeErr != nil {
-				m.Logger.Error("Failed to write cached response", zap.Error(writeErr))
-			}
-			return
-		}
+	render := func(ctx context.Context) (string, error) {
+		return m.renderLeagueOptions(ctx, cacheKey, int32(gameID))
 	}
-
-	m.Logger.Info("Cache MISS",
-		zap.String("handler", "LeagueOptionsHandler"),
-		zap.String("cache_key", cacheKey),
-		zap.Int64("game_id", gameID))
-
-	// Fetch leagues from database
-	leagues, err := m.DBConn.GetLeaguesByGameID(m.Context, int32(gameID))
+	responseJSON, cacheStatus, err := m.Coalescer.load(c.Request.Context(), cacheKey,
+		m.optionsLookup(cacheKey), m.optionsProbe(cacheKey), render)
 	if err != nil {
-		var message string
-		// Check if it's a connection error or other database issue
-		if strings.Contains(err.Error(), "connection") || strings.Contains(err.Error(), "connect") {
-			message = "Database connection error. Please try again later."
-		} else {
-			message = "Unable to load leagues. Please refresh the page: " + err.Error()
-		}
 		c.JSON(http.StatusInternalServerError, map[string]any{
 			"error":   true,
-			"message": message,
+			"message": optionsErrorMessage(err, "leagues"),
 			"leagues": []any{},
 		})
 		return
 	}
+	m.recordCacheLookup(c, cacheFamilyLeagueOptions, cacheStatus)
+	logger.Info("Cache "+cacheStatus,
+		zap.String("handler", "LeagueOptionsHandler"),
+		zap.String("cache_key", cacheKey),
+		zap.Int64("game_id", gameID),
+		zap.Int("response_size_bytes", len(responseJSON)))
+
+	// Return JSON response with HTTP cache headers (cache for 10 minutes)
+	c.Header("Content-Type", "application/json")
+	c.Header("Cache-Control", "public, max-age=600")
+	c.Header("X-Cache", cacheStatus)
+	if _, writeErr := c.Writer.WriteString(responseJSON); writeErr != nil {
+		logger.Error("Failed to write response", zap.Error(writeErr))
+	}
+}
+
+// renderLeagueOptions loads the leagues of a game and caches the JSON response.
+func (m *Middleware) renderLeagueOptions(ctx context.Context, cacheKey string, gameID int32) (string, error) {
+	m.Logger.Info("Loading leagues from database",
+		zap.String("cache_key", cacheKey),
+		zap.Int32("game_id", gameID))
+
+	// Fetch leagues from database
+	leagues, err := m.DBConn.GetLeaguesByGameID(ctx, gameID)
+	if err != nil {
+		return "", err
+	}
 
 	// Convert to response format
 	type LeagueResponse struct {
@@ -116,38 +118,25 @@ func (m *Middleware) LeagueOptionsHandler(c *gin.Context) {
 	// Marshal to JSON bytes
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		m.Logger.Error("Failed to marshal response", zap.Error(err))
-		c.String(http.StatusInternalServerError, "Internal server error")
-		return
+		return "", fmt.Errorf("failed to marshal response: %w", err)
 	}
 
 	// Cache the response
-	if m.RedisCache != nil {
-		if cacheErr := m.RedisCache.SetBytes(cacheKey, responseBytes); cacheErr != nil {
-			m.Logger.Warn("Failed to cache response", zap.Error(cacheErr))
-		} else {
-			m.Logger.Info("Data cached",
-				zap.String("handler", "LeagueOptionsHandler"),
-				zap.String("cache_key", cacheKey),
-				zap.Int("num_leagues", len(leagueList)),
-				zap.Int("response_size_bytes", len(responseBytes)))
-		}
+	if cacheErr := m.Cache.SetBytes(ctx, cacheKey, responseBytes); cacheErr != nil {
+		m.Logger.Warn("Failed to cache response", zap.Error(cacheErr))
+	} else {
+		m.Logger.Info("Data cached",
+			zap.String("handler", "LeagueOptionsHandler"),
+			zap.String("cache_key", cacheKey),
+			zap.Int("num_leagues", len(leagueList)),
+			zap.Int("response_size_bytes", len(responseBytes)))
 	}
 
-	// Return JSON response with HTTP cache headers (cache for 10 minutes)
-	c.Header("Content-Type", "application/json")
-	c.Header("Cache-Control", "public, max-age=600")
-	c.Header("X-Cache", "MISS")
-	if _, writeErr := c.Writer.Write(responseBytes); writeErr != nil {
-		m.Logger.Error("Failed to write response", zap.Error(writeErr))
-	}
+	return string(responseBytes), nil
 }
 
 func (m *Middleware) TeamOptionsHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "TeamOptionsHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
+	logger := m.requestLogger(c.Request.Context())
 	// Extract game ID from URL path or param
 	path := strings.TrimPrefix(c.Param("param"), "/")
 	if path == "" {
@@ -163,47 +152,50 @@ func (m *Middleware) TeamOptionsHandler(c *gin.Context) {
 		return
 	}
 
-	// Check cache first
+	setSpanAttributes(c, attrGameID.Int64(gameID))
+
+	// Serve from cache; misses and stale entries are rendered once across concurrent requests
 	cacheKey := fmt.Sprintf("team-options:%d", gameID)
-	if m.RedisCache != nil {
-		if jsonBytes, ok := m.RedisCache.GetBytes(cacheKey); ok {
-			m.Logger.Info("Cache HIT",
-				zap.String("handler", "TeamOptionsHandler"),
-				zap.String("cache_key", cacheKey),
-				zap.Int64("game_id", gameID),
-				zap.Int("response_size_bytes", len(jsonBytes)))
-			c.Header("Content-Type", "application/json")
-			c.Header("Cache-Control", "public, max-age=600")
-			c.Header("X-Cache", "HIT")
-			if _, writeErr := c.Writer.Write(jsonBytes); writeErr != nil {
-				m.Logger.Error("Failed to write cached response", zap.Error(writeErr))
-			}
-			return
-		}
+	render := func(ctx context.Context) (string, error) {
+		return m.renderTeamOptions(ctx, cacheKey, int32(gameID))
 	}
-
-	m.Logger.Info("Cache MISS",
-		zap.String("handler", "TeamOptionsHandler"),
-		zap.String("cache_key", cacheKey),
-		zap.Int64("game_id", gameID))
-
-	// Fetch teams from database
-	teams, err := m.DBConn.GetTeamsByGameID(m.Context, int32(gameID))
+	responseJSON, cacheStatus, err := m.Coalescer.load(c.Request.Context(), cacheKey,
+		m.optionsLookup(cacheKey), m.optionsProbe(cacheKey), render)
 	if err != nil {
-		var message string
-		// Check if it's a connection error or other database issue
-		if strings.Contains(err.Error(), "connection") || strings.Contains(err.Error(), "connect") {
-			message = "Database connection error. Please try again later."
-		} else {
-			message = "Unable to load teams. Please refresh the page: " + err.Error()
-		}
 		c.JSON(http.StatusInternalServerError, map[string]any{
 			"error":   true,
-			"message": message,
+			"message": optionsErrorMessage(err, "teams"),
 			"teams":   []any{},
 		})
 		return
 	}
+	m.recordCacheLookup(c, cacheFamilyTeamOptions, cacheStatus)
+	logger.Info("Cache "+cacheStatus,
+		zap.String("handler", "TeamOptionsHandler"),
+		zap.String("cache_key", cacheKey),
+		zap.Int64("game_id", gameID),
+		zap.Int("response_size_bytes", len(responseJSON)))
+
+	// Return JSON response with HTTP cache headers (cache for 10 minutes)
+	c.Header("Content-Type", "application/json")
+	c.Header("Cache-Control", "public, max-age=600")
+	c.Header("X-Cache", cacheStatus)
+	if _, writeErr := c.Writer.WriteString(responseJSON); writeErr != nil {
+		logger.Error("Failed to write response", zap.Error(writeErr))
+	}
+}
+
+// renderTeamOptions loads the teams of a game and caches the JSON response.
+func (m *Middleware) renderTeamOptions(ctx context.Context, cacheKey string, gameID int32) (string, error) {
+	m.Logger.Info("Loading teams from database",
+		zap.String("cache_key", cacheKey),
+		zap.Int32("game_id", gameID))
+
+	// Fetch teams from database
+	teams, err := m.DBConn.GetTeamsByGameID(ctx, gameID)
+	if err != nil {
+		return "", err
+	}
 
 	// Convert to response format
 	type TeamResponse struct {
@@ -243,29 +235,49 @@ func (m *Middleware) TeamOptionsHandler(c *gin.Context) {
 	// Marshal to JSON bytes
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		m.Logger.Error("Failed to marshal response", zap.Error(err))
-		c.String(http.StatusInternalServerError, "Internal server error")
-		return
+		return "", fmt.Errorf("failed to marshal response: %w", err)
 	}
 
 	// Cache the response
-	if m.RedisCache != nil {
-		if cacheErr := m.RedisCache.SetBytes(cacheKey, responseBytes); cacheErr != nil {
-			m.Logger.Warn("Failed to cache response", zap.Error(cacheErr))
-		} else {
-			m.Logger.Info("Data cached",
-				zap.String("handler", "TeamOptionsHandler"),
-				zap.String("cache_key", cacheKey),
-				zap.Int("num_teams", len(teamList)),
-				zap.Int("response_size_bytes", len(responseBytes)))
+	if cacheErr := m.Cache.SetBytes(ctx, cacheKey, responseBytes); cacheErr != nil {
+		m.Logger.Warn("Failed to cache response", zap.Error(cacheErr))
+	} else {
+		m.Logger.Info("Data cached",
+			zap.String("handler", "TeamOptionsHandler"),
+			zap.String("cache_key", cacheKey),
+			zap.Int("num_teams", len(teamList)),
+			zap.Int("response_size_bytes", len(responseBytes)))
+	}
+
+	return string(responseBytes), nil
+}
+
+// optionsLookup reads a cached options response. Entries are stale during their data stale window.
+func (m *Middleware) optionsLookup(cacheKey string) cacheLookup {
+	return func(ctx context.Context) (string, bool, bool) {
+		jsonBytes, expiresAt, ok := m.Cache.GetBytesWithExpiry(ctx, cacheKey)
+		if !ok {
+			return "", false, false
 		}
+		return string(jsonBytes), time.Until(expiresAt) <= m.Config.Cache.TTL.DataStale, true
 	}
+}
 
-	// Return JSON response with HTTP cache headers (cache for 10 minutes)
-	c.Header("Content-Type", "application/json")
-	c.Header("Cache-Control", "public, max-age=600")
-	c.Header("X-Cache", "MISS")
-	if _, writeErr := c.Writer.Write(responseBytes); writeErr != nil {
-		m.Logger.Error("Failed to write response", zap.Error(writeErr))
+// optionsProbe reports whether a fresh options response is cached. Options lookups are not counted,
+// so it simply reads the entry.
+func (m *Middleware) optionsProbe(cacheKey string) cacheProbe {
+	lookup := m.optionsLookup(cacheKey)
+	return func(ctx context.Context) bool {
+		_, stale, ok := lookup(ctx)
+		return ok && !stale
+	}
+}
+
+// optionsErrorMessage turns a failed options render into a message for the user.
+func optionsErrorMessage(err error, what string) string {
+	// Check if it's a connection error or other database issue
+	if strings.Contains(err.Error(), "connection") || strings.Contains(err.Error(), "connect") {
+		return "Database connection error. Please try again later."
 	}
+	return "Unable to load " + what + ". Please refresh the page: " + err.Error()
 }
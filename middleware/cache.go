@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Values of CacheConfig.Backend.
+	cacheBackendRedis  = "redis"
+	cacheBackendMemory = "memory"
+	cacheBackendNone   = "none"
+
+	defaultMemoryCacheSize = 1024
+)
+
+// Cache stores rendered calendars (ics:) and API data (data:) shared between requests.
+// Calls end when ctx is done; backends that do no I/O ignore it.
+// RedisCache shares entries between instances, MemoryCache keeps them in this process
+// and NoopCache stores nothing.
+type Cache interface {
+	// GetICS retrieves a rendered calendar.
+	GetICS(ctx context.Context, hash string) (string, bool)
+	// GetICSWithExpiry retrieves a rendered calendar and the time its entry, stale window included, expires.
+	GetICSWithExpiry(ctx context.Context, hash string) (string, time.Time, bool)
+	// ICSExpiry returns when a rendered calendar expires, without reading it.
+	ICSExpiry(ctx context.Context, hash string) (time.Time, bool)
+	// SetICS stores a rendered calendar, fresh for the ICS TTL and stale for the ICS stale window after that.
+	SetICS(ctx context.Context, hash string, content string) error
+	// DeleteICS removes a rendered calendar.
+	DeleteICS(ctx context.Context, hash string) error
+	// GetData retrieves general data.
+	GetData(ctx context.Context, key string) (string, bool)
+	// SetData stores general data for the data TTL.
+	SetData(ctx context.Context, key string, value string) error
+	// GetBytes retrieves binary data.
+	GetBytes(ctx context.Context, key string) ([]byte, bool)
+	// GetBytesWithExpiry retrieves binary data and the time its entry, stale window included, expires.
+	GetBytesWithExpiry(ctx context.Context, key string) ([]byte, time.Time, bool)
+	// SetBytes stores binary data, fresh for the data TTL and stale for the data stale window after that.
+	SetBytes(ctx context.Context, key string, value []byte) error
+	// DeleteData removes general or binary data.
+	DeleteData(ctx context.Context, key string) error
+	// Clear removes all entries of the application.
+	Clear(ctx context.Context) error
+	// Close releases the backend.
+	Close() error
+	// GetCacheStats counts the entries per prefix.
+	GetCacheStats(ctx context.Context) (map[string]int64, error)
+}
+
+// NewCache creates the cache selected by cfg.Cache.Backend: redis, memory or none.
+// The Redis client is returned as well, for the pub/sub and locks that only Redis provides;
+// it is nil for the other backends and when Redis is unreachable, in which case the memory cache is used.
+func NewCache(ctx context.Context, cfg Config, logger *zap.Logger) (Cache, *RedisCache) {
+	switch cfg.Cache.Backend {
+	case cacheBackendNone:
+		logger.Info("Caching disabled by configuration")
+		return NoopCache{}, nil
+	case cacheBackendMemory:
+		return newMemoryCache(cfg.Cache, logger), nil
+	}
+
+	redisCache, err := connectRedis(ctx, cfg, logger)
+	if err != nil {
+		// Continue with a process-local cache - instances no longer share cached entries
+		logger.Error("Failed to initialize Redis cache, falling back to memory cache", zap.Error(err))
+		return newMemoryCache(cfg.Cache, logger), nil
+	}
+	return redisCache, redisCache
+}
+
+// newMemoryCache creates the memory cache described by cfg.
+func newMemoryCache(cfg CacheConfig, logger *zap.Logger) *MemoryCache {
+	logger.Info("Memory cache initialized", zap.Int("max_size", cfg.MemorySize))
+	return NewMemoryCache(cfg.MemorySize, cfg.TTL, logger)
+}
+
+// NoopCache stores nothing; every lookup misses.
+type NoopCache struct{}
+
+// GetICS always misses.
+func (NoopCache) GetICS(context.Context, string) (string, bool) { return "", false }
+
+// GetICSWithExpiry always misses.
+func (NoopCache) GetICSWithExpiry(context.Context, string) (string, time.Time, bool) {
+	return "", time.Time{}, false
+}
+
+// ICSExpiry always misses.
+func (NoopCache) ICSExpiry(context.Context, string) (time.Time, bool) { return time.Time{}, false }
+
+// SetICS discards the calendar.
+func (NoopCache) SetICS(context.Context, string, string) error { return nil }
+
+// DeleteICS has nothing to remove.
+func (NoopCache) DeleteICS(context.Context, string) error { return nil }
+
+// GetData always misses.
+func (NoopCache) GetData(context.Context, string) (string, bool) { return "", false }
+
+// SetData discards the data.
+func (NoopCache) SetData(context.Context, string, string) error { return nil }
+
+// GetBytes always misses.
+func (NoopCache) GetBytes(context.Context, string) ([]byte, bool) { return nil, false }
+
+// GetBytesWithExpiry always misses.
+func (NoopCache) GetBytesWithExpiry(context.Context, string) ([]byte, time.Time, bool) {
+	return nil, time.Time{}, false
+}
+
+// SetBytes discards the data.
+func (NoopCache) SetBytes(context.Context, string, []byte) error { return nil }
+
+// DeleteData has nothing to remove.
+func (NoopCache) DeleteData(context.Context, string) error { return nil }
+
+// Clear has nothing to remove.
+func (NoopCache) Clear(context.Context) error { return nil }
+
+// Close has nothing to release.
+func (NoopCache) Close() error { return nil }
+
+// GetCacheStats reports no entries.
+func (NoopCache) GetCacheStats(context.Context) (map[string]int64, error) {
+	return map[string]int64{"ics_entries": 0, "data_entries": 0}, nil
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"go.uber.org/zap"
+)
+
+const (
+	// invalidationChannel carries cacheInvalidation messages between instances. Other services
+	// that change cached data, such as the match importer, may publish on it as well.
+	invalidationChannel     = "cache:invalidate"
+	invalidationOriginBytes = 8
+	// invalidationScanLimit caps how many recently polled calendars a batch of match changes is checked against.
+	invalidationScanLimit = 5000
+)
+
+// cacheFamily names a kind of cached value that can be invalidated on every instance.
+type cacheFamily string
+
+const (
+	// cacheFamilyICS keys are calendar hashes and cover every rendered format of the calendar.
+	cacheFamilyICS cacheFamily = "ics"
+	// cacheFamilyLeagueOptions and cacheFamilyTeamOptions keys are game IDs. The importer publishes them
+	// after changing the leagues, tournaments or teams of a game.
+	cacheFamilyLeagueOptions cacheFamily = "league-options"
+	cacheFamilyTeamOptions   cacheFamily = "team-options"
+)
+
+// known reports whether instances understand invalidations of the family.
+func (f cacheFamily) known() bool {
+	switch f {
+	case cacheFamilyICS, cacheFamilyLeagueOptions, cacheFamilyTeamOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// cacheInvalidation announces that the shared Redis entry of a key is gone or stale,
+// so every instance drops its process-local copies.
+type cacheInvalidation struct {
+	Family cacheFamily `json:"family"`
+	Key    string      `json:"key"`
+	// Origin identifies the sending instance, which has already applied the message.
+	Origin string `json:"origin,omitempty"`
+}
+
+// String returns the invalidated cache key, e.g. ics:<hash> or team-options:<game>.
+func (i cacheInvalidation) String() string {
+	return string(i.Family) + ":" + i.Key
+}
+
+// InvalidationBus delivers cache invalidations to the local caches of every instance over Redis pub/sub.
+// Without Redis it only reaches the caches of this instance.
+type InvalidationBus struct {
+	mu       sync.RWMutex
+	handlers map[cacheFamily][]func(key string)
+	origin   string
+	redis    *RedisCache
+	logger   *zap.Logger
+}
+
+// NewInvalidationBus creates a bus publishing through redisCache, which may be nil.
+func NewInvalidationBus(redisCache *RedisCache, logger *zap.Logger) (*InvalidationBus, error) {
+	origin := make([]byte, invalidationOriginBytes)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, fmt.Errorf("failed to generate instance ID: %w", err)
+	}
+	return &InvalidationBus{
+		mu:       sync.RWMutex{},
+		handlers: make(map[cacheFamily][]func(key string)),
+		origin:   hex.EncodeToString(origin),
+		redis:    redisCache,
+		logger:   logger,
+	}, nil
+}
+
+// OnInvalidate registers a process-local cache to drop its entries of a family.
+func (b *InvalidationBus) OnInvalidate(family cacheFamily, handler func(key string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[family] = append(b.handlers[family], handler)
+}
+
+// Publish applies an invalidation locally and announces it to the other instances.
+// Callers remove the shared Redis entry first, so no instance refills its local cache from it.
+func (b *InvalidationBus) Publish(ctx context.Context, family cacheFamily, key string) error {
+	invalidation := cacheInvalidation{Family: family, Key: key, Origin: b.origin}
+	b.dispatch(invalidation)
+	if b.redis == nil {
+		return nil
+	}
+	message, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache invalidation: %w", err)
+	}
+	return b.redis.Publish(ctx, invalidationChannel, message)
+}
+
+// dispatch runs the local handlers of an invalidation.
+func (b *InvalidationBus) dispatch(invalidation cacheInvalidation) {
+	b.mu.RLock()
+	handlers := b.handlers[invalidation.Family]
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(invalidation.Key)
+	}
+	b.logger.Debug("Cache invalidated",
+		zap.String("key", invalidation.String()),
+		zap.Bool("remote", invalidation.Origin != b.origin))
+}
+
+// Run applies the invalidations published by other instances until ctx is cancelled.
+func (b *InvalidationBus) Run(ctx context.Context) {
+	if b.redis == nil {
+		b.logger.Info("Cache invalidation listener disabled, Redis is not configured")
+		return
+	}
+	pubsub := b.redis.Subscribe(ctx, invalidationChannel)
+	defer func() {
+		_ = pubsub.Close() // The connection is dropped on shutdown either way
+	}()
+	b.logger.Info("Cache invalidation listener started")
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("Cache invalidation listener stopped")
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var invalidation cacheInvalidation
+			if err := json.Unmarshal([]byte(message.Payload), &invalidation); err != nil {
+				b.logger.Warn("Skipping undecodable cache invalidation", zap.Error(err))
+				continue
+			}
+			if !invalidation.Family.known() || invalidation.Key == "" {
+				b.logger.Warn("Skipping unknown cache invalidation", zap.String("key", invalidation.String()))
+				continue
+			}
+			if invalidation.Origin == b.origin {
+				continue // Applied when it was published
+			}
+			b.dispatch(invalidation)
+		}
+	}
+}
+
+// dropOptionsOnInvalidate removes the cached options lists of a game when they are invalidated.
+// Every instance deletes the entry, so publishers need not know how options are cached.
+func dropOptionsOnInvalidate(bus *InvalidationBus, cache Cache, logger *zap.Logger) {
+	for _, family := range []cacheFamily{cacheFamilyLeagueOptions, cacheFamilyTeamOptions} {
+		bus.OnInvalidate(family, func(gameID string) {
+			cacheKey := string(family) + ":" + gameID
+			if err := cache.DeleteData(context.Background(), cacheKey); err != nil {
+				logger.Warn("Failed to delete cache entry", zap.Error(err), zap.String("cache_key", cacheKey))
+			}
+		})
+	}
+}
+
+// RunCacheInvalidationListener applies cache invalidations of other instances until ctx is cancelled.
+func (m *Middleware) RunCacheInvalidationListener(ctx context.Context) {
+	m.Invalidation.Run(ctx)
+}
+
+// invalidateCalendar removes every cached format of a calendar from the shared cache and from the local caches
+// of all instances. It is not cancelled with ctx, so a disconnecting client cannot leave it half done.
+func (m *Middleware) invalidateCalendar(ctx context.Context, hash string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, format := range calendarFormats() {
+		if format.timezoneAware {
+			continue
+		}
+		if err := m.Cache.DeleteICS(ctx, calendarCacheKey(hash, format)); err != nil {
+			m.Logger.Warn("Failed to delete cache entry", zap.Error(err), zap.String("hash", hash))
+		}
+	}
+	if err := m.Invalidation.Publish(ctx, cacheFamilyICS, hash); err != nil {
+		m.Logger.Warn("Failed to announce cache invalidation", zap.Error(err), zap.String("hash", hash))
+	}
+}
+
+// invalidateChangedCalendars invalidates every calendar showing one of the changed matches. Only calendars
+// polled within the calendar cache lifetime can have a cached feed, so the others are not checked.
+func (m *Middleware) invalidateChangedCalendars(ctx context.Context, matches []dbtypes.GetMatchForWebhookRow) {
+	if len(matches) == 0 {
+		return
+	}
+	mappings, err := m.DBConn.ListHotURLMappings(ctx, dbtypes.ListHotURLMappingsParams{
+		ActiveMinutes: int32(math.Ceil(m.Config.Cache.TTL.icsLifetime().Minutes())),
+		LimitCount:    invalidationScanLimit,
+	})
+	if err != nil {
+		m.Logger.Warn("Failed to list cached calendars", zap.Error(err))
+		return
+	}
+
+	invalidated := 0
+	for _, mapping := range mappings {
+		sub, decodeErr := m.decodeSubscription(mapping.HashedKey, mapping.ValueList)
+		if decodeErr != nil {
+			continue // Never rendered either
+		}
+		if sub.showsAny(matches) {
+			m.invalidateCalendar(ctx, mapping.HashedKey)
+			invalidated++
+		}
+	}
+	m.Logger.Info("Calendars invalidated by match changes",
+		zap.Int("matches", len(matches)),
+		zap.Int("calendars", invalidated))
+}
+
+// showsAny reports whether the calendar includes any of the matches.
+func (s calendarSubscription) showsAny(matches []dbtypes.GetMatchForWebhookRow) bool {
+	return slices.ContainsFunc(matches, func(match dbtypes.GetMatchForWebhookRow) bool {
+		return s.includes(match.GameID, match.LeagueID, match.Team1ID, match.Team2ID, match.TournamentTier)
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// TestOptionsInvalidation checks that publishing an options invalidation drops the cached list of that game only.
+func TestOptionsInvalidation(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(100, DefaultConfig().Cache.TTL, zap.NewNop())
+	bus, err := NewInvalidationBus(nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropOptionsOnInvalidate(bus, cache, zap.NewNop())
+
+	for _, key := range []string{"league-options:1", "team-options:1", "team-options:2"} {
+		if setErr := cache.SetBytes(ctx, key, []byte("[]")); setErr != nil {
+			t.Fatal(setErr)
+		}
+	}
+	if publishErr := bus.Publish(ctx, cacheFamilyTeamOptions, "1"); publishErr != nil {
+		t.Fatal(publishErr)
+	}
+
+	for key, want := range map[string]bool{"league-options:1": true, "team-options:1": false, "team-options:2": true} {
+		if _, ok := cache.GetBytes(ctx, key); ok != want {
+			t.Errorf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestShowsAny(t *testing.T) {
+	sub := calendarSubscription{
+		Hash:       "abc",
+		HideScores: false,
+		GameIDs:    []int32{1},
+		LeagueIDs:  []int32{10},
+		TeamIDs:    []int32{100},
+		MaxTier:    0,
+	}
+	match := func(gameID, leagueID, team1ID int32) dbtypes.GetMatchForWebhookRow {
+		//nolint:exhaustruct // Only the fields includes reads
+		return dbtypes.GetMatchForWebhookRow{
+			GameID:         gameID,
+			LeagueID:       leagueID,
+			Team1ID:        team1ID,
+			Team2ID:        0,
+			TournamentTier: pgtype.Int4{Int32: 1, Valid: true},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		matches []dbtypes.GetMatchForWebhookRow
+		want    bool
+	}{
+		{"none", nil, false},
+		{"other game", []dbtypes.GetMatchForWebhookRow{match(2, 10, 100)}, false},
+		{"followed team", []dbtypes.GetMatchForWebhookRow{match(1, 11, 100)}, true},
+		{"one of several", []dbtypes.GetMatchForWebhookRow{match(2, 10, 100), match(1, 11, 100)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sub.showsAny(tt.matches); got != tt.want {
+				t.Errorf("showsAny() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
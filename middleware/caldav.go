@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// XML namespaces used by WebDAV (RFC 4918), CalDAV (RFC 4791) and the CalendarServer extensions.
+const (
+	nsDAV            = "DAV:"
+	nsCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// davRequest is the subset of a PROPFIND or REPORT body this read-only server understands.
+type davRequest struct {
+	// Root is the document element, e.g. DAV:propfind or caldav:calendar-multiget.
+	Root      xml.Name
+	AllProp   bool
+	PropName  bool
+	Props     []xml.Name
+	Hrefs     []string
+	SyncToken string
+	// RangeStart and RangeEnd hold an optional calendar-query time-range filter.
+	RangeStart time.Time
+	RangeEnd   time.Time
+}
+
+// parseDAVRequest walks a PROPFIND or REPORT body. An empty body is treated as allprop (RFC 4918 section 9.1).
+//
+//nolint:gocognit // Flat token walk over the request document
+func parseDAVRequest(body io.Reader) (davRequest, error) {
+	var req davRequest
+	decoder := xml.NewDecoder(body)
+	var stack []xml.Name
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return davRequest{}, fmt.Errorf("invalid XML body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				req.Root = t.Name
+			}
+			if len(stack) > 0 && stack[len(stack)-1] == davName(nsDAV, "prop") {
+				req.Props = append(req.Props, t.Name)
+			}
+			switch t.Name {
+			case davName(nsDAV, "allprop"):
+				req.AllProp = true
+			case davName(nsDAV, "propname"):
+				req.PropName = true
+			case davName(nsCalDAV, "time-range"):
+				for _, attr := range t.Attr {
+					parsed, parseErr := time.Parse(icsDateTimeLayout, attr.Value)
+					if parseErr != nil {
+						return davRequest{}, fmt.Errorf("invalid time-range %s: %w", attr.Name.Local, parseErr)
+					}
+					switch attr.Name.Local {
+					case "start":
+						req.RangeStart = parsed
+					case "end":
+						req.RangeEnd = parsed
+					}
+				}
+			}
+			text.Reset()
+			stack = append(stack, t.Name)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch t.Name {
+			case davName(nsDAV, "href"):
+				req.Hrefs = append(req.Hrefs, strings.TrimSpace(text.String()))
+			case davName(nsDAV, "sync-token"):
+				req.SyncToken = strings.TrimSpace(text.String())
+			}
+			text.Reset()
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if req.Root == (xml.Name{}) {
+		req.AllProp = true
+	}
+	return req, nil
+}
+
+func davName(space, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+// davResource is a resource in a multistatus response with its known properties,
+// each stored as the already-encoded inner XML of the property element.
+type davResource struct {
+	Href  string
+	Props map[xml.Name]string
+	// Order fixes the property order for allprop and propname responses.
+	Order []xml.Name
+}
+
+// newDAVResource creates an empty resource for href.
+func newDAVResource(href string) *davResource {
+	return &davResource{Href: href, Props: make(map[xml.Name]string), Order: nil}
+}
+
+// set stores a property's inner XML.
+func (r *davResource) set(name xml.Name, innerXML string) {
+	if _, exists := r.Props[name]; !exists {
+		r.Order = append(r.Order, name)
+	}
+	r.Props[name] = innerXML
+}
+
+// multistatus builds a DAV:multistatus document (RFC 4918 section 13).
+type multistatus struct {
+	body strings.Builder
+}
+
+func newMultistatus() *multistatus {
+	ms := &multistatus{body: strings.Builder{}}
+	ms.body.WriteString(xml.Header)
+	ms.body.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="` + nsCalDAV + `" xmlns:CS="` + nsCalendarServer + `">`)
+	return ms
+}
+
+// addResource writes a response for a resource, answering the properties asked for in req.
+// Unknown requested properties are reported with a 404 propstat.
+func (ms *multistatus) addResource(resource *davResource, req davRequest, skipOnAllProp ...xml.Name) {
+	ms.body.WriteString("<D:response><D:href>")
+	ms.body.WriteString(escapeXML(resource.Href))
+	ms.body.WriteString("</D:href>")
+
+	var found, missing []xml.Name
+	switch {
+	case req.PropName:
+		ms.writePropstat(resource.Order, nil, http.StatusOK)
+		ms.body.WriteString("</D:response>")
+		return
+	case req.AllProp:
+		for _, name := range resource.Order {
+			if !slices.Contains(skipOnAllProp, name) {
+				found = append(found, name)
+			}
+		}
+	default:
+		for _, name := range req.Props {
+			if _, ok := resource.Props[name]; ok {
+				found = append(found, name)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+	}
+
+	if len(found) > 0 {
+		ms.writePropstat(found, resource.Props, http.StatusOK)
+	}
+	if len(missing) > 0 {
+		ms.writePropstat(missing, nil, http.StatusNotFound)
+	}
+	ms.body.WriteString("</D:response>")
+}
+
+// addStatus writes a response that carries only a status, e.g. 404 for a removed member.
+func (ms *multistatus) addStatus(href string, status int) {
+	ms.body.WriteString("<D:response><D:href>")
+	ms.body.WriteString(escapeXML(href))
+	ms.body.WriteString("</D:href><D:status>")
+	ms.body.WriteString(statusLine(status))
+	ms.body.WriteString("</D:status></D:response>")
+}
+
+// addSyncToken appends the top-level sync-token of a sync-collection report.
+func (ms *multistatus) addSyncToken(token string) {
+	ms.body.WriteString("<D:sync-token>")
+	ms.body.WriteString(escapeXML(token))
+	ms.body.WriteString("</D:sync-token>")
+}
+
+// String closes the document and returns it.
+func (ms *multistatus) String() string {
+	return ms.body.String() + "</D:multistatus>"
+}
+
+func (ms *multistatus) writePropstat(names []xml.Name, values map[xml.Name]string, status int) {
+	ms.body.WriteString("<D:propstat><D:prop>")
+	for _, name := range names {
+		open, closing := davElement(name)
+		ms.body.WriteString(open)
+		ms.body.WriteString(values[name])
+		ms.body.WriteString(closing)
+	}
+	ms.body.WriteString("</D:prop><D:status>")
+	ms.body.WriteString(statusLine(status))
+	ms.body.WriteString("</D:status></D:propstat>")
+}
+
+// davElement returns the opening and closing tags for a property name,
+// using the document prefixes for known namespaces and a local declaration otherwise.
+func davElement(name xml.Name) (string, string) {
+	prefix := map[string]string{nsDAV: "D", nsCalDAV: "C", nsCalendarServer: "CS"}[name.Space]
+	if name.Space == "" {
+		return "<" + name.Local + ">", "</" + name.Local + ">"
+	}
+	if prefix == "" {
+		return fmt.Sprintf(`<X:%s xmlns:X="%s">`, name.Local, escapeXML(name.Space)), "</X:" + name.Local + ">"
+	}
+	return "<" + prefix + ":" + name.Local + ">", "</" + prefix + ":" + name.Local + ">"
+}
+
+func statusLine(status int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", status, http.StatusText(status))
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// davHref wraps a path in a DAV:href element.
+func davHref(href string) string {
+	return "<D:href>" + escapeXML(href) + "</D:href>"
+}
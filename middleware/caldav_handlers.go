@@ -0,0 +1,517 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// The read-only CalDAV tree for a subscription:
+//
+//	/caldav/<hash>/                    principal and calendar home
+//	/caldav/<hash>/calendar/           calendar collection
+//	/caldav/<hash>/calendar/<id>.ics   one event resource per match
+const (
+	caldavPrefix          = "/caldav/"
+	caldavCalendarSegment = "calendar"
+
+	methodPropfind   = "PROPFIND"
+	methodReport     = "REPORT"
+	methodProppatch  = "PROPPATCH"
+	methodMkcalendar = "MKCALENDAR"
+
+	davContentType   = "application/xml; charset=utf-8"
+	eventContentType = "text/calendar; charset=utf-8; component=vevent"
+	etagLength       = 16
+
+	// maxDAVBodyBytes bounds PROPFIND and REPORT bodies; multiget reports list one href per event.
+	maxDAVBodyBytes = 256 << 10
+	// syncTokenMaxAge is how long a sync token stays valid. Older match revisions are pruned,
+	// so clients holding older tokens resync in full.
+	syncTokenMaxAge = 30 * 24 * time.Hour
+)
+
+type caldavResourceKind int
+
+const (
+	caldavHome caldavResourceKind = iota
+	caldavCollection
+	caldavEvent
+)
+
+// caldavTarget is a parsed CalDAV request path.
+type caldavTarget struct {
+	hash    string
+	kind    caldavResourceKind
+	matchID int32
+}
+
+func (t caldavTarget) homeHref() string {
+	return caldavPrefix + t.hash + "/"
+}
+
+func (t caldavTarget) collectionHref() string {
+	return t.homeHref() + caldavCalendarSegment + "/"
+}
+
+func (t caldavTarget) eventHref(matchID int32) string {
+	return fmt.Sprintf("%s%d.ics", t.collectionHref(), matchID)
+}
+
+// caldavCollectionState is everything needed to answer requests on a calendar collection.
+type caldavCollectionState struct {
+	sub       calendarSubscription
+	feed      calendarFeed
+	events    map[int32]calendarEvent
+	revision  int64
+	issuedAt  time.Time
+	syncToken string
+}
+
+// CalDAVMethods lists the HTTP methods routed to CalDAVHandler.
+// Write methods are routed too so they can be rejected as read-only rather than 404.
+func CalDAVMethods() []string {
+	return []string{
+		http.MethodOptions,
+		http.MethodGet,
+		http.MethodHead,
+		methodPropfind,
+		methodReport,
+		http.MethodPut,
+		http.MethodDelete,
+		methodProppatch,
+		methodMkcalendar,
+	}
+}
+
+// CalDAVHandler exposes each subscription as a read-only CalDAV calendar (RFC 4791)
+// with sync-collection support (RFC 6578), backed by the same match queries as the .ics feed.
+func (m *Middleware) CalDAVHandler(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	target, ok := parseCalDAVPath(c.Request.URL.Path)
+	if !ok {
+		c.String(http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodOptions:
+		c.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+		c.Status(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		m.caldavGet(c, target)
+	case methodPropfind:
+		m.caldavPropfind(c, target)
+	case methodReport:
+		m.caldavReport(c, target)
+	default:
+		m.writeDAVError(c, http.StatusForbidden, "<D:need-privileges/>")
+	}
+}
+
+// parseCalDAVPath splits a request path into its subscription hash and resource.
+func parseCalDAVPath(requestPath string) (caldavTarget, bool) {
+	rest := strings.Trim(strings.TrimPrefix(requestPath, caldavPrefix), "/")
+	parts := strings.Split(rest, "/")
+	target := caldavTarget{hash: parts[0], kind: caldavHome, matchID: 0}
+	if target.hash == "" {
+		return caldavTarget{}, false
+	}
+
+	switch {
+	case len(parts) == 1:
+		return target, true
+	case len(parts) == 2 && parts[1] == caldavCalendarSegment:
+		target.kind = caldavCollection
+		return target, true
+	case len(parts) == 3 && parts[1] == caldavCalendarSegment && strings.HasSuffix(parts[2], ".ics"):
+		matchID, err := strconv.ParseInt(strings.TrimSuffix(parts[2], ".ics"), 10, 32)
+		if err != nil {
+			return caldavTarget{}, false
+		}
+		target.kind = caldavEvent
+		target.matchID = int32(matchID)
+		return target, true
+	default:
+		return caldavTarget{}, false
+	}
+}
+
+// loadCollection loads the subscription, its events and the current sync token.
+// The revision is read before the matches so changes racing with this request are reported again next sync.
+func (m *Middleware) loadCollection(c *gin.Context, target caldavTarget) (caldavCollectionState, bool) {
+	logger := m.requestLogger(c.Request.Context())
+	issuedAt := time.Now()
+	revision, err := m.DBConn.GetLatestMatchRevision(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to read latest match revision", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to load calendar")
+		return caldavCollectionState{}, false
+	}
+
+	sub, feed, err := m.loadCalendarFeed(c.Request.Context(), target.hash)
+	if errors.Is(err, errCalendarNotFound) {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return caldavCollectionState{}, false
+	}
+	if err != nil {
+		logger.Error("Failed to load calendar", zap.Error(err), zap.String("hash", target.hash))
+		c.String(http.StatusInternalServerError, "Failed to load calendar")
+		return caldavCollectionState{}, false
+	}
+
+	if target.kind != caldavEvent {
+		if updateErr := m.DBConn.UpdateURLMappingAccessCount(c.Request.Context(), target.hash); updateErr != nil {
+			logger.Warn("Failed to update access count", zap.Error(updateErr), zap.String("hash", target.hash))
+		}
+	}
+
+	events := make(map[int32]calendarEvent, len(feed.Events))
+	for _, event := range feed.Events {
+		events[event.MatchID] = event
+	}
+	return caldavCollectionState{
+		sub:       sub,
+		feed:      feed,
+		events:    events,
+		revision:  revision,
+		issuedAt:  issuedAt,
+		syncToken: m.syncToken(revision, issuedAt),
+	}, true
+}
+
+func (m *Middleware) caldavGet(c *gin.Context, target caldavTarget) {
+	logger := m.requestLogger(c.Request.Context())
+	if target.kind == caldavHome {
+		c.Header("Allow", "OPTIONS, PROPFIND")
+		c.String(http.StatusMethodNotAllowed, "Not a calendar resource")
+		return
+	}
+	state, ok := m.loadCollection(c, target)
+	if !ok {
+		return
+	}
+
+	feed := state.feed
+	if target.kind == caldavEvent {
+		event, exists := state.events[target.matchID]
+		if !exists {
+			c.String(http.StatusNotFound, "Event not found")
+			return
+		}
+		feed.Events = []calendarEvent{event}
+	}
+
+	content, err := generateICS(feed)
+	if err != nil {
+		logger.Error("Failed to serialize calendar", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to generate calendar")
+		return
+	}
+	etag := contentETag(content)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(content))
+}
+
+func (m *Middleware) caldavPropfind(c *gin.Context, target caldavTarget) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+	// Depth: infinity is answered like Depth: 1; the tree is only three levels deep
+	depthZero := c.GetHeader("Depth") == "0"
+	ms := newMultistatus()
+
+	if target.kind == caldavHome {
+		if _, subErr := m.loadSubscription(c.Request.Context(), target.hash); subErr != nil {
+			c.String(http.StatusNotFound, "Calendar not found")
+			return
+		}
+		ms.addResource(homeResource(target), req)
+		if !depthZero {
+			state, ok := m.loadCollection(c, target)
+			if !ok {
+				return
+			}
+			ms.addResource(m.collectionResource(target, state), req)
+		}
+		c.Data(http.StatusMultiStatus, davContentType, []byte(ms.String()))
+		return
+	}
+
+	state, ok := m.loadCollection(c, target)
+	if !ok {
+		return
+	}
+	calendarData := davName(nsCalDAV, "calendar-data")
+
+	if target.kind == caldavEvent {
+		event, exists := state.events[target.matchID]
+		if !exists {
+			c.String(http.StatusNotFound, "Event not found")
+			return
+		}
+		ms.addResource(eventResource(target, state.feed, event), req, calendarData)
+		c.Data(http.StatusMultiStatus, davContentType, []byte(ms.String()))
+		return
+	}
+
+	ms.addResource(m.collectionResource(target, state), req)
+	if !depthZero {
+		for _, event := range state.feed.Events {
+			ms.addResource(eventResource(target, state.feed, event), req, calendarData)
+		}
+	}
+	c.Data(http.StatusMultiStatus, davContentType, []byte(ms.String()))
+}
+
+func (m *Middleware) caldavReport(c *gin.Context, target caldavTarget) {
+	if target.kind != caldavCollection {
+		m.writeDAVError(c, http.StatusForbidden, "<D:supported-report/>")
+		return
+	}
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+	state, ok := m.loadCollection(c, target)
+	if !ok {
+		return
+	}
+	ms := newMultistatus()
+
+	switch req.Root {
+	case davName(nsCalDAV, "calendar-query"):
+		for _, event := range state.feed.Events {
+			// Time-range overlap test (RFC 4791 section 9.9); zero bounds are open
+			if !req.RangeStart.IsZero() && !event.End.After(req.RangeStart) {
+				continue
+			}
+			if !req.RangeEnd.IsZero() && !event.Start.Before(req.RangeEnd) {
+				continue
+			}
+			ms.addResource(eventResource(target, state.feed, event), req)
+		}
+	case davName(nsCalDAV, "calendar-multiget"):
+		for _, href := range req.Hrefs {
+			hrefTarget, valid := parseCalDAVHref(href)
+			event, exists := state.events[hrefTarget.matchID]
+			if !valid || hrefTarget.hash != target.hash || hrefTarget.kind != caldavEvent || !exists {
+				ms.addStatus(href, http.StatusNotFound)
+				continue
+			}
+			ms.addResource(eventResource(target, state.feed, event), req)
+		}
+	case davName(nsDAV, "sync-collection"):
+		if !m.syncCollection(c, target, state, req, ms) {
+			return
+		}
+	default:
+		m.writeDAVError(c, http.StatusForbidden, "<D:supported-report/>")
+		return
+	}
+
+	c.Data(http.StatusMultiStatus, davContentType, []byte(ms.String()))
+}
+
+// syncCollection answers a sync-collection report (RFC 6578). Without a token every member is returned;
+// with one, only matches whose revision is newer, and matches that left the calendar are reported as 404.
+// Matches also leave when they fall out of the calendar window without a new revision, so those are
+// looked up from the time the token was issued.
+func (m *Middleware) syncCollection(
+	c *gin.Context,
+	target caldavTarget,
+	state caldavCollectionState,
+	req davRequest,
+	ms *multistatus,
+) bool {
+	logger := m.requestLogger(c.Request.Context())
+	if req.SyncToken == "" {
+		for _, event := range state.feed.Events {
+			ms.addResource(eventResource(target, state.feed, event), req)
+		}
+		ms.addSyncToken(state.syncToken)
+		return true
+	}
+
+	since, issuedAt, err := parseSyncToken(req.SyncToken)
+	if err != nil || since > state.revision || issuedAt.After(state.issuedAt) ||
+		state.issuedAt.Sub(issuedAt) > syncTokenMaxAge {
+		m.writeDAVError(c, http.StatusForbidden, "<D:valid-sync-token/>")
+		return false
+	}
+
+	changes, err := m.DBConn.GetMatchRevisionsSince(c.Request.Context(), dbtypes.GetMatchRevisionsSinceParams{
+		Since:     since,
+		GameIds:   state.sub.GameIDs,
+		TeamIds:   state.sub.TeamIDs,
+		LeagueIds: state.sub.LeagueIDs,
+		MaxTier:   state.sub.MaxTier,
+	})
+	if err != nil {
+		logger.Error("Failed to fetch match revisions", zap.Error(err), zap.String("hash", target.hash))
+		c.String(http.StatusInternalServerError, "Failed to load changes")
+		return false
+	}
+
+	expired, err := m.DBConn.GetMatchesLeftCalendarWindow(c.Request.Context(),
+		dbtypes.GetMatchesLeftCalendarWindowParams{
+			IssuedAt:  issuedAt.Unix(),
+			GameIds:   state.sub.GameIDs,
+			TeamIds:   state.sub.TeamIDs,
+			LeagueIds: state.sub.LeagueIDs,
+			MaxTier:   state.sub.MaxTier,
+		})
+	if err != nil {
+		logger.Error("Failed to fetch matches that left the window", zap.Error(err), zap.String("hash", target.hash))
+		c.String(http.StatusInternalServerError, "Failed to load changes")
+		return false
+	}
+
+	reported := make(map[int32]bool, len(changes)+len(expired))
+	for _, change := range changes {
+		reported[change.MatchID] = true
+		event, exists := state.events[change.MatchID]
+		if !exists {
+			ms.addStatus(target.eventHref(change.MatchID), http.StatusNotFound)
+			continue
+		}
+		ms.addResource(eventResource(target, state.feed, event), req)
+	}
+	for _, matchID := range expired {
+		if _, exists := state.events[matchID]; exists || reported[matchID] {
+			continue
+		}
+		ms.addStatus(target.eventHref(matchID), http.StatusNotFound)
+	}
+	ms.addSyncToken(state.syncToken)
+	return true
+}
+
+func homeResource(target caldavTarget) *davResource {
+	home := target.homeHref()
+	resource := newDAVResource(home)
+	resource.set(davName(nsDAV, "resourcetype"), "<D:collection/><D:principal/>")
+	resource.set(davName(nsDAV, "displayname"), "Esports Calendar")
+	resource.set(davName(nsDAV, "current-user-principal"), davHref(home))
+	resource.set(davName(nsDAV, "principal-URL"), davHref(home))
+	resource.set(davName(nsCalDAV, "calendar-home-set"), davHref(home))
+	resource.set(davName(nsDAV, "current-user-privilege-set"), "<D:privilege><D:read/></D:privilege>")
+	return resource
+}
+
+func (m *Middleware) collectionResource(target caldavTarget, state caldavCollectionState) *davResource {
+	resource := newDAVResource(target.collectionHref())
+	resource.set(davName(nsDAV, "resourcetype"), "<D:collection/><C:calendar/>")
+	resource.set(davName(nsDAV, "displayname"), escapeXML(state.feed.Name))
+	resource.set(davName(nsCalDAV, "calendar-description"), "Esports matches for your selections")
+	resource.set(davName(nsCalDAV, "supported-calendar-component-set"), `<C:comp name="VEVENT"/>`)
+	resource.set(davName(nsDAV, "supported-report-set"),
+		"<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>"+
+			"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>"+
+			"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>")
+	resource.set(davName(nsDAV, "current-user-privilege-set"),
+		"<D:privilege><D:read/></D:privilege><D:privilege><C:read-free-busy/></D:privilege>")
+	resource.set(davName(nsDAV, "current-user-principal"), davHref(target.homeHref()))
+	resource.set(davName(nsDAV, "owner"), davHref(target.homeHref()))
+	resource.set(davName(nsDAV, "sync-token"), escapeXML(state.syncToken))
+
+	// The ctag changes whenever any event in the collection changes
+	if content, err := generateICS(state.feed); err == nil {
+		resource.set(davName(nsCalendarServer, "getctag"), escapeXML(contentETag(content)))
+	} else {
+		m.Logger.Warn("Failed to compute collection ctag", zap.Error(err))
+	}
+	return resource
+}
+
+func eventResource(target caldavTarget, feed calendarFeed, event calendarEvent) *davResource {
+	single := feed
+	single.Events = []calendarEvent{event}
+	// generateICS never fails; it only returns an error to satisfy the serializer signature
+	content, _ := generateICS(single)
+
+	resource := newDAVResource(target.eventHref(event.MatchID))
+	resource.set(davName(nsDAV, "getetag"), escapeXML(contentETag(content)))
+	resource.set(davName(nsDAV, "getcontenttype"), eventContentType)
+	resource.set(davName(nsDAV, "resourcetype"), "")
+	resource.set(davName(nsCalDAV, "calendar-data"), escapeXML(content))
+	return resource
+}
+
+// writeDAVError writes a DAV:error body naming the failed precondition.
+func (m *Middleware) writeDAVError(c *gin.Context, status int, condition string) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<D:error xmlns:D="DAV:">` + condition + `</D:error>`
+	c.Data(status, davContentType, []byte(body))
+}
+
+// readDAVRequest parses a bounded PROPFIND or REPORT body, writing the error response on failure.
+func readDAVRequest(c *gin.Context) (davRequest, bool) {
+	req, err := parseDAVRequest(http.MaxBytesReader(c.Writer, c.Request.Body, maxDAVBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.String(http.StatusRequestEntityTooLarge, "Request body exceeds %d KiB", maxDAVBodyBytes>>10)
+		return davRequest{}, false
+	}
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return davRequest{}, false
+	}
+	return req, true
+}
+
+// syncToken formats a match revision and the time it was read as a sync-token URI.
+func (m *Middleware) syncToken(revision int64, issuedAt time.Time) string {
+	return fmt.Sprintf("%s/ns/sync/%d-%d", m.BaseURL, revision, issuedAt.Unix())
+}
+
+// parseSyncToken extracts the match revision and issue time from a sync-token URI.
+// Tokens without an issue time predate window tracking and are rejected, forcing a full resync.
+func parseSyncToken(token string) (int64, time.Time, error) {
+	revisionPart, issuedPart, ok := strings.Cut(token[strings.LastIndex(token, "/")+1:], "-")
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("sync token %q has no issue time", token)
+	}
+	revision, err := strconv.ParseInt(revisionPart, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	issued, err := strconv.ParseInt(issuedPart, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if revision < 0 || issued < 0 {
+		return 0, time.Time{}, fmt.Errorf("negative sync token value in %q", token)
+	}
+	return revision, time.Unix(issued, 0), nil
+}
+
+// parseCalDAVHref parses a multiget href, which may be an absolute URL or a path.
+func parseCalDAVHref(href string) (caldavTarget, bool) {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return caldavTarget{}, false
+	}
+	return parseCalDAVPath(parsed.Path)
+}
+
+// contentETag returns a strong entity tag for rendered content.
+func contentETag(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return `"` + hex.EncodeToString(sum[:])[:etagLength] + `"`
+}
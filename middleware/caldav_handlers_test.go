@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	m := &Middleware{BaseURL: "https://esportscalendar.app"} //nolint:exhaustruct // syncToken only uses the base URL
+	issuedAt := time.Unix(1790000000, 0)
+
+	revision, issued, err := parseSyncToken(m.syncToken(42, issuedAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision != 42 || !issued.Equal(issuedAt) {
+		t.Fatalf("got revision %d issued %v, want 42 issued %v", revision, issued, issuedAt)
+	}
+
+	// Tokens from before the issue time was recorded must force a full resync
+	for _, token := range []string{"https://esportscalendar.app/ns/sync/42", "42-x", "-1-5"} {
+		if _, _, err = parseSyncToken(token); err == nil {
+			t.Errorf("parseSyncToken(%q) succeeded", token)
+		}
+	}
+}
+
+func TestReadDAVRequestLimitsBody(t *testing.T) {
+	body := `<D:propfind xmlns:D="DAV:"><D:prop>` +
+		strings.Repeat("<D:displayname/>", maxDAVBodyBytes/len("<D:displayname/>")+1) +
+		`</D:prop></D:propfind>`
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(methodPropfind, "/caldav/abc/calendar/", strings.NewReader(body))
+
+	if _, ok := readDAVRequest(c); ok {
+		t.Fatal("oversized body was accepted")
+	}
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
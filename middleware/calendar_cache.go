@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// calendarCacheJanitorInterval is how often expired files are removed and hit ratios logged.
+	calendarCacheJanitorInterval = 10 * time.Minute
+
+	// Cache tiers reported by CalendarCache.Get.
+	cacheTierFile  = "file"
+	cacheTierRedis = "redis"
+)
+
+// CalendarCache is the two-tier cache of rendered calendar feeds: the file-backed ICSCache in front
+// of the shared Cache. The file tier may be nil; while Redis is down the file tier keeps serving.
+type CalendarCache struct {
+	local  *ICSCache
+	shared Cache
+	ttl    CacheTTLConfig
+	logger *zap.Logger
+
+	localHits  atomic.Int64
+	sharedHits atomic.Int64
+	misses     atomic.Int64
+}
+
+// calendarCacheStats counts lookups per tier. Ratios are relative to the lookups that reached the tier.
+type calendarCacheStats struct {
+	FileHits        int64   `json:"file_hits"`
+	RedisHits       int64   `json:"redis_hits"`
+	Misses          int64   `json:"misses"`
+	FileHitRatio    float64 `json:"file_hit_ratio"`
+	RedisHitRatio   float64 `json:"redis_hit_ratio"`
+	OverallHitRatio float64 `json:"overall_hit_ratio"`
+}
+
+// NewCalendarCache combines the tiers and drops local entries when any instance invalidates a calendar.
+func NewCalendarCache(
+	local *ICSCache,
+	shared Cache,
+	ttl CacheTTLConfig,
+	invalidation *InvalidationBus,
+	logger *zap.Logger,
+) *CalendarCache {
+	cache := &CalendarCache{
+		local:      local,
+		shared:     shared,
+		ttl:        ttl,
+		logger:     logger,
+		localHits:  atomic.Int64{},
+		sharedHits: atomic.Int64{},
+		misses:     atomic.Int64{},
+	}
+	if local != nil {
+		invalidation.OnInvalidate(cacheFamilyICS, func(hash string) {
+			for _, format := range calendarFormats() {
+				local.Delete(calendarCacheKey(hash, format))
+			}
+		})
+	}
+	return cache
+}
+
+// cachedFeed is a calendar found in one of the cache tiers.
+type cachedFeed struct {
+	Content string
+	Tier    string
+	// Stale feeds are past their fresh TTL and only served while a refresh runs.
+	Stale bool
+}
+
+// Get looks a feed up in the file tier, then in Redis, and reports the tier that had it.
+// Redis hits are copied to the file tier with the remaining Redis TTL, so both tiers expire together.
+// A stale file entry is only used when Redis has nothing fresher.
+func (c *CalendarCache) Get(ctx context.Context, key string) (cachedFeed, bool) {
+	var staleLocal *cachedFeed
+	if c.local != nil {
+		if content, expiresAt, ok := c.local.Get(key); ok {
+			feed := cachedFeed{Content: content, Tier: cacheTierFile, Stale: c.stale(expiresAt)}
+			if !feed.Stale {
+				c.localHits.Add(1)
+				return feed, true
+			}
+			staleLocal = &feed
+		}
+	}
+	if content, expiresAt, ok := c.shared.GetICSWithExpiry(ctx, key); ok {
+		c.sharedHits.Add(1)
+		if c.local != nil {
+			_ = c.local.Set(key, content, expiresAt) // Logged by the file cache; Redis still serves it
+		}
+		return cachedFeed{Content: content, Tier: cacheTierRedis, Stale: c.stale(expiresAt)}, true
+	}
+	if staleLocal != nil {
+		c.localHits.Add(1)
+		return *staleLocal, true
+	}
+	c.misses.Add(1)
+	return cachedFeed{Content: "", Tier: "", Stale: false}, false
+}
+
+// Expiry returns when the cached copy of a feed expires, preferring the shared tier.
+// It does not count as a lookup.
+func (c *CalendarCache) Expiry(ctx context.Context, key string) (time.Time, bool) {
+	if expiresAt, ok := c.shared.ICSExpiry(ctx, key); ok {
+		return expiresAt, true
+	}
+	if c.local != nil {
+		return c.local.Expiry(key)
+	}
+	return time.Time{}, false
+}
+
+// stale reports whether a feed expiring at expiresAt is inside its stale window.
+func (c *CalendarCache) stale(expiresAt time.Time) bool {
+	return time.Until(expiresAt) <= c.ttl.ICSStale
+}
+
+// Set stores a freshly rendered feed in both tiers.
+func (c *CalendarCache) Set(ctx context.Context, key, content string) error {
+	sharedErr := c.shared.SetICS(ctx, key, content)
+	if c.local != nil {
+		if err := c.local.Set(key, content, time.Now().Add(c.ttl.icsLifetime())); err != nil {
+			return err
+		}
+	}
+	return sharedErr
+}
+
+// Stats returns the lookup counters and hit ratios of both tiers.
+func (c *CalendarCache) Stats() calendarCacheStats {
+	localHits := c.localHits.Load()
+	sharedHits := c.sharedHits.Load()
+	misses := c.misses.Load()
+	return calendarCacheStats{
+		FileHits:        localHits,
+		RedisHits:       sharedHits,
+		Misses:          misses,
+		FileHitRatio:    hitRatio(localHits, sharedHits+misses),
+		RedisHitRatio:   hitRatio(sharedHits, misses),
+		OverallHitRatio: hitRatio(localHits+sharedHits, misses),
+	}
+}
+
+// hitRatio returns hits / (hits + misses), or 0 before the first lookup.
+func hitRatio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// RunCalendarCacheJanitor removes expired feeds from the file tier and logs the hit ratios
+// until ctx is cancelled.
+func (m *Middleware) RunCalendarCacheJanitor(ctx context.Context) {
+	ticker := time.NewTicker(calendarCacheJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		removed := 0
+		if m.CalendarCache.local != nil {
+			removed = m.CalendarCache.local.RemoveExpired()
+		}
+		stats := m.CalendarCache.Stats()
+		m.Logger.Info("Calendar cache stats",
+			zap.Int("expired_removed", removed),
+			zap.Int64("file_hits", stats.FileHits),
+			zap.Int64("redis_hits", stats.RedisHits),
+			zap.Int64("misses", stats.Misses),
+			zap.Float64("file_hit_ratio", stats.FileHitRatio),
+			zap.Float64("redis_hit_ratio", stats.RedisHitRatio),
+			zap.Float64("overall_hit_ratio", stats.OverallHitRatio))
+	}
+}
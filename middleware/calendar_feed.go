@@ -0,0 +1,324 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+)
+
+// iCalendar value types used by the calendar properties (RFC 5545 section 3.3).
+const (
+	valueTypeText     = "text"
+	valueTypeDateTime = "date-time"
+	valueTypeURI      = "uri"
+	valueTypeUnknown  = "unknown"
+)
+
+// structuredDateTimeLayout is the UTC date-time form shared by jCal and xCal (RFC 7265, RFC 6321).
+const structuredDateTimeLayout = "2006-01-02T15:04:05Z"
+
+// calendarProperty is a single format-agnostic calendar property.
+// Name is the lower-case iCalendar property name; Time is used for date-time values, Value otherwise.
+type calendarProperty struct {
+	Name      string
+	ValueType string
+	Value     string
+	Time      time.Time
+}
+
+// calendarEvent is the intermediate representation of one match in a calendar feed.
+type calendarEvent struct {
+	UID      string
+	MatchID  int32
+	Finished bool
+	Stamp    time.Time
+	Start    time.Time
+	End      time.Time
+	// URL is the match on the calendar's schedule page, e.g. https://esportscalendar.app/embed/<hash>#match-42.
+	URL         string
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+
+	// Structured match details for tabular formats.
+	MatchName      string
+	GameName       string
+	LeagueName     string
+	SeriesName     string
+	TournamentName string
+	Tier           string
+	Team1Name      string
+	Team2Name      string
+	BestOf         int32
+	// Score is "2-1" for finished matches, "Finished" when scores are hidden and empty otherwise.
+	Score string
+}
+
+// calendarFeed is the intermediate representation of a whole calendar, shared by all serializers.
+type calendarFeed struct {
+	// Link is the subscription URL without a format extension, e.g. https://esportscalendar.app/<hash>.
+	Link      string
+	ProdID    string
+	Name      string
+	Timezone  string
+	Generated time.Time
+	// DisplayLocation is the timezone used by formats that render local wall-clock times.
+	DisplayLocation *time.Location
+	Events          []calendarEvent
+}
+
+// calendarFormat describes a serializer for a calendar feed served at /:hash.<extension>.
+type calendarFormat struct {
+	extension   string
+	contentType string
+	serialize   func(feed calendarFeed) (string, error)
+	// timezoneAware formats honour the ?tz= query parameter and are not cached,
+	// since every timezone would need its own entry.
+	timezoneAware bool
+}
+
+// calendarFormats lists every supported calendar feed format, iCalendar first.
+func calendarFormats() []calendarFormat {
+	return []calendarFormat{
+		{
+			extension:     ".ics",
+			contentType:   "text/calendar; charset=utf-8",
+			serialize:     generateICS,
+			timezoneAware: false,
+		},
+		{
+			extension:     ".json",
+			contentType:   "application/calendar+json; charset=utf-8",
+			serialize:     generateJCal,
+			timezoneAware: false,
+		},
+		{
+			extension:     ".xml",
+			contentType:   "application/calendar+xml; charset=utf-8",
+			serialize:     generateXCal,
+			timezoneAware: false,
+		},
+		{
+			extension:     ".atom",
+			contentType:   "application/atom+xml; charset=utf-8",
+			serialize:     generateAtom,
+			timezoneAware: false,
+		},
+		{
+			extension:     ".rss",
+			contentType:   "application/rss+xml; charset=utf-8",
+			serialize:     generateRSS,
+			timezoneAware: false,
+		},
+		{
+			extension:     ".csv",
+			contentType:   "text/csv; charset=utf-8; header=present",
+			serialize:     generateCSV,
+			timezoneAware: true,
+		},
+	}
+}
+
+// calendarFormatByExtension returns the calendar format registered for the given extension.
+func calendarFormatByExtension(extension string) (calendarFormat, bool) {
+	for _, format := range calendarFormats() {
+		if format.extension == extension {
+			return format, true
+		}
+	}
+	return calendarFormat{}, false
+}
+
+// calendarCacheKey returns the cache key for a rendered feed.
+// iCalendar feeds keep the bare hash so existing ics:<hash> entries stay valid.
+func calendarCacheKey(hash string, format calendarFormat) string {
+	if format.extension == ".ics" {
+		return hash
+	}
+	return hash + format.extension
+}
+
+// matchURL links a match on the schedule page of a calendar. Its element ID is match-<id>.
+func matchURL(baseURL, hash string, matchID int32) string {
+	return fmt.Sprintf("%s/embed/%s#match-%d", baseURL, hash, matchID)
+}
+
+// buildCalendarFeed converts matches into the intermediate calendar model.
+func buildCalendarFeed(
+	hash string,
+	matches []dbtypes.GetCalendarMatchesBySelectionsRow,
+	hideScores bool,
+	baseURL string,
+) calendarFeed {
+	feed := calendarFeed{
+		Link:      fmt.Sprintf("%s/%s", baseURL, hash),
+		ProdID:    "-//EsportsCalendar//EN",
+		Name:      "Esports Calendar",
+		Timezone:  "UTC",
+		Generated: time.Now(),
+		// Callers may override the display timezone for timezone-aware formats
+		DisplayLocation: time.UTC,
+		Events:          make([]calendarEvent, 0, len(matches)),
+	}
+
+	for _, match := range matches {
+		// Get team names with fallback to "TBD"
+		team1Name := "TBD"
+		if match.Team1Name.Valid {
+			team1Name = match.Team1Name.String
+		}
+		team2Name := "TBD"
+		if match.Team2Name.Valid {
+			team2Name = match.Team2Name.String
+		}
+		if !match.ExpectedStartTime.Valid {
+			continue
+		}
+
+		startTime := match.ExpectedStartTime.Time
+		// Calculate duration: 1 hour per game
+		duration := time.Duration(match.AmountOfGames) * time.Hour
+		endTime := startTime.Add(duration)
+
+		// Build summary: [Game] Tournament - Match Name (omit tournament if empty)
+		// Add scores to title if match is finished and hideScores is false
+		summary := fmt.Sprintf("[%s] %s", match.GameName, match.Name)
+		if match.TournamentName != "" {
+			summary = fmt.Sprintf("[%s] %s - %s", match.GameName, match.TournamentName, match.Name)
+		}
+		if match.Finished && !hideScores {
+			// Add score to the title
+			summary = fmt.Sprintf("%s [%d-%d]", summary, match.Team1Score, match.Team2Score)
+		}
+
+		// Build description with teams, league, tournament, and score for finished matches
+		description := fmt.Sprintf("%s vs %s - %s - %s (%s)",
+			team1Name,
+			team2Name,
+			match.TournamentName,
+			match.LeagueName,
+			match.GameName,
+		)
+		if match.Finished {
+			if hideScores {
+				// Show "Finished" instead of score
+				description = fmt.Sprintf("%s vs %s [Finished] - %s - %s (%s)",
+					team1Name,
+					team2Name,
+					match.TournamentName,
+					match.LeagueName,
+					match.GameName,
+				)
+			} else {
+				description = fmt.Sprintf("%s vs %s [%d-%d] - %s - %s (%s)",
+					team1Name,
+					team2Name,
+					match.Team1Score,
+					match.Team2Score,
+					match.TournamentName,
+					match.LeagueName,
+					match.GameName,
+				)
+			}
+		}
+
+		// Build location: League - Series (only include dash if both are non-empty)
+		location := ""
+		switch {
+		case match.LeagueName != "" && match.SeriesName != "":
+			location = fmt.Sprintf("%s - %s", match.LeagueName, match.SeriesName)
+		case match.LeagueName != "":
+			location = match.LeagueName
+		case match.SeriesName != "":
+			location = match.SeriesName
+		}
+
+		// Score column mirrors the preview badges: result, "Finished" when hidden, blank when upcoming
+		score := ""
+		if match.Finished {
+			score = "Finished"
+			if !hideScores {
+				score = fmt.Sprintf("%d-%d", match.Team1Score, match.Team2Score)
+			}
+		}
+		tier := ""
+		if match.TournamentTier.Valid {
+			tier = tierLabel(match.TournamentTier.Int32)
+		}
+
+		feed.Events = append(feed.Events, calendarEvent{
+			UID:         fmt.Sprintf("%d@%s", match.ID, baseURL),
+			MatchID:     match.ID,
+			Finished:    match.Finished,
+			Stamp:       startTime,
+			Start:       startTime,
+			End:         endTime,
+			URL:         matchURL(baseURL, hash, match.ID),
+			Summary:     summary,
+			Description: description,
+			Location:    location,
+			// All matches are confirmed
+			Status:         "CONFIRMED",
+			MatchName:      match.Name,
+			GameName:       match.GameName,
+			LeagueName:     match.LeagueName,
+			SeriesName:     match.SeriesName,
+			TournamentName: match.TournamentName,
+			Tier:           tier,
+			Team1Name:      team1Name,
+			Team2Name:      team2Name,
+			BestOf:         match.AmountOfGames,
+			Score:          score,
+		})
+	}
+
+	return feed
+}
+
+// updated returns when the event last changed in a way visible to subscribers:
+// the expected end for finished matches (when the result comes in), otherwise the start,
+// never later than the time the feed was generated.
+func (e calendarEvent) updated(generated time.Time) time.Time {
+	updated := e.Start
+	if e.Finished {
+		updated = e.End
+	}
+	if updated.After(generated) {
+		return generated
+	}
+	return updated
+}
+
+// properties returns the calendar-level properties in serialization order.
+func (f calendarFeed) properties() []calendarProperty {
+	return []calendarProperty{
+		{Name: "version", ValueType: valueTypeText, Value: "2.0", Time: time.Time{}},
+		{Name: "prodid", ValueType: valueTypeText, Value: f.ProdID, Time: time.Time{}},
+		{Name: "calscale", ValueType: valueTypeText, Value: "GREGORIAN", Time: time.Time{}},
+		{Name: "x-wr-calname", ValueType: valueTypeUnknown, Value: f.Name, Time: time.Time{}},
+		{Name: "x-wr-timezone", ValueType: valueTypeUnknown, Value: f.Timezone, Time: time.Time{}},
+	}
+}
+
+// properties returns the event properties in serialization order, omitting empty optional ones.
+func (e calendarEvent) properties() []calendarProperty {
+	props := []calendarProperty{
+		{Name: "uid", ValueType: valueTypeText, Value: e.UID, Time: time.Time{}},
+		{Name: "dtstamp", ValueType: valueTypeDateTime, Value: "", Time: e.Stamp},
+		{Name: "dtstart", ValueType: valueTypeDateTime, Value: "", Time: e.Start},
+		{Name: "dtend", ValueType: valueTypeDateTime, Value: "", Time: e.End},
+		{Name: "url", ValueType: valueTypeURI, Value: e.URL, Time: time.Time{}},
+		{Name: "summary", ValueType: valueTypeText, Value: e.Summary, Time: time.Time{}},
+		{Name: "description", ValueType: valueTypeText, Value: e.Description, Time: time.Time{}},
+	}
+	if e.Location != "" {
+		props = append(props, calendarProperty{
+			Name: "location", ValueType: valueTypeText, Value: e.Location, Time: time.Time{},
+		})
+	}
+	return append(props, calendarProperty{
+		Name: "status", ValueType: valueTypeText, Value: e.Status, Time: time.Time{},
+	})
+}
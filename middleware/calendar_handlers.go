@@ -1,10 +1,13 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/feimaomiao/esportscalendar/dbtypes"
 	"github.com/gin-gonic/gin"
@@ -12,199 +15,264 @@ import (
 )
 
 func (m *Middleware) ExportHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "ExportHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
-
-	// Parse JSON body with selections and hideScores
-	var requestBody map[string]any
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		m.Logger.Error("Failed to parse JSON body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
-	}
-	m.Logger.Debug("Received request body for export", zap.Any("request_body", requestBody))
-
-	// Generate canonical JSON (sorted keys for consistent hashing)
+	logger := m.requestLogger(c.Request.Context())
+	// Validated selections are re-encoded with sorted keys and IDs for consistent hashing
 	// This preserves both selections and hideScores in the stored data
-	jsonBytes, err := json.Marshal(requestBody)
-	if err != nil {
-		m.Logger.Error("Failed to marshal selections", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to process selections"})
+	jsonBytes, ok := m.bindSelectionPayload(c)
+	if !ok {
 		return
 	}
 
 	// Generate hash
 	hash := generateHash(jsonBytes)
+	setSpanAttributes(c, attrCalendarHash.String(hash))
 	previewLen := 100
 	if len(jsonBytes) < previewLen {
 		previewLen = len(jsonBytes)
 	}
-	m.Logger.Info("Exporting calendar",
+	logger.Info("Exporting calendar",
 		zap.String("hash", hash),
 		zap.Int("payload_size", len(jsonBytes)),
 		zap.String("payload_preview", string(jsonBytes[:previewLen])))
 
 	// Store in database
-	err = m.DBConn.InsertURLMapping(m.Context, dbtypes.InsertURLMappingParams{
+	err := m.DBConn.InsertURLMapping(c.Request.Context(), dbtypes.InsertURLMappingParams{
 		HashedKey: hash,
 		ValueList: jsonBytes,
 	})
 	if err != nil {
-		m.Logger.Error("Failed to store URL mapping", zap.Error(err), zap.String("hash", hash))
+		logger.Error("Failed to store URL mapping", zap.Error(err), zap.String("hash", hash))
 		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create calendar link"})
 		return
 	}
 
 	// Return the hash as JSON
 	response := map[string]string{
-		"hash": hash,
-		"url":  fmt.Sprintf("%s/%s.ics", m.BaseURL, hash),
+		"hash":         hash,
+		"url":          fmt.Sprintf("%s/%s.ics", m.BaseURL, hash),
+		"settings_url": fmt.Sprintf("%s/settings/%s", m.BaseURL, hash),
 	}
 	c.JSON(http.StatusOK, response)
 }
 
+// CalendarHandler serves a stored calendar at /:hash.<ext> in any registered calendar format.
 func (m *Middleware) CalendarHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "CalendarHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
-
-	// Extract hash from URL path (format: /:hash.ics)
+	logger := m.requestLogger(c.Request.Context())
+	// Extract hash and format from URL path (format: /:hash.ics, /:hash.json, ...)
 	path := strings.TrimPrefix(c.Request.URL.Path, "/")
-	hash := strings.TrimSuffix(path, ".ics")
+	format, ok := calendarFormatByExtension(filepath.Ext(path))
+	if !ok {
+		c.String(http.StatusNotFound, "Unknown calendar format")
+		return
+	}
+	hash := strings.TrimSuffix(path, format.extension)
+	c.Set(metricsRouteKey, "/:hash"+format.extension)
+	m.Metrics.calendarPolled()
+	setSpanAttributes(c, attrCalendarHash.String(hash), attrCalendarFormat.String(format.extension))
 
 	if hash == "" || hash == path {
 		c.String(http.StatusBadRequest, "Invalid calendar URL")
 		return
 	}
 
-	m.Logger.Info("Looking up calendar",
+	// Downloads are limited per calendar; forced refreshes, which drop its cached feeds, more strictly
+	refresh := c.Query("refresh") == "1"
+	if !m.allowRequest(c, rateLimitCalendar, hash, m.Config.RateLimit.Calendar) ||
+		(refresh && !m.allowRequest(c, rateLimitRefresh, hash, m.Config.RateLimit.Refresh)) {
+		return
+	}
+
+	// Timezone-aware formats render wall-clock times in the requested timezone (default UTC)
+	location := time.UTC
+	if tz := c.Query("tz"); format.timezoneAware && tz != "" {
+		loaded, tzErr := time.LoadLocation(tz)
+		if tzErr != nil {
+			c.String(http.StatusBadRequest, "Invalid timezone")
+			return
+		}
+		location = loaded
+	}
+	useCache := !format.timezoneAware
+
+	logger.Info("Looking up calendar",
 		zap.String("hash", hash),
+		zap.String("format", format.extension),
 		zap.String("full_path", c.Request.URL.Path))
 
+	// While the database is down, calendar apps keep receiving the cached feed
+	if !m.Readiness.Database() {
+		if !m.serveCachedCalendar(c, hash, format) {
+			c.Header("Retry-After", maintenanceRetryAfter)
+			c.String(http.StatusServiceUnavailable, "Calendar temporarily unavailable")
+		}
+		return
+	}
+
 	// Retrieve selections from database
-	mapping, err := m.DBConn.GetURLMapping(m.Context, hash)
-	if err != nil {
-		m.Logger.Error("Calendar not found in database",
+	sub, err := m.loadSubscription(c.Request.Context(), hash)
+	if errors.Is(err, errCalendarNotFound) {
+		logger.Error("Calendar not found in database",
 			zap.String("hash", hash),
 			zap.String("url", c.Request.URL.Path),
 			zap.Error(err))
 		c.String(http.StatusNotFound, "Calendar not found")
 		return
 	}
+	if err != nil {
+		logger.Error("Failed to load calendar", zap.Error(err), zap.String("hash", hash))
+		if !m.serveCachedCalendar(c, hash, format) {
+			c.String(http.StatusInternalServerError, "Invalid calendar data")
+		}
+		return
+	}
 
-	m.Logger.Info("Calendar found in database", zap.String("hash", hash))
+	logger.Info("Calendar found in database", zap.String("hash", hash))
 
 	// Update access count
-	err = m.DBConn.UpdateURLMappingAccessCount(m.Context, hash)
+	err = m.DBConn.UpdateURLMappingAccessCount(c.Request.Context(), hash)
 	if err != nil {
-		m.Logger.Warn("Failed to update access count", zap.Error(err), zap.String("hash", hash))
+		logger.Warn("Failed to update access count", zap.Error(err), zap.String("hash", hash))
 	}
 
 	// Check if refresh is requested via query parameter
-	refresh := c.Query("refresh") == "1"
-	if refresh && m.RedisCache != nil {
-		m.Logger.Info("Cache refresh requested", zap.String("hash", hash))
-		if delErr := m.RedisCache.DeleteICS(hash); delErr != nil {
-			m.Logger.Warn("Failed to delete cache entry", zap.Error(delErr), zap.String("hash", hash))
-		}
+	if refresh {
+		logger.Info("Cache refresh requested", zap.String("hash", hash))
+		// Drop every format on every instance so all variants are regenerated from the same data
+		m.invalidateCalendar(c.Request.Context(), hash)
 	}
 
-	// Try to get from cache first (unless refresh was requested)
-	var icsContent string
-	var cacheHit bool
-	if m.RedisCache != nil && !refresh {
-		icsContent, cacheHit = m.RedisCache.GetICS(hash)
-		if cacheHit {
-			m.Logger.Info("Cache HIT", zap.String("hash", hash))
-			// Set headers for iCalendar file download
-			c.Status(http.StatusOK)
-			c.Header("Content-Type", "text/calendar; charset=utf-8")
-			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"esports-calendar-%s.ics\"", hash))
-			c.Header("Cache-Control", "public, max-age=3600") // Cache for 1 hour
-			c.Header("X-Cache", "HIT")
-			if _, writeErr := c.Writer.Write([]byte(icsContent)); writeErr != nil {
-				m.Logger.Error("Failed to write calendar content", zap.Error(writeErr))
-				return
-			}
-			m.Logger.Debug("Served calendar from cache", zap.String("hash", hash))
+	if !useCache {
+		content, renderErr := m.renderCalendar(c.Request.Context(), sub, format, location)
+		if renderErr != nil {
+			logger.Error("Failed to generate calendar",
+				zap.Error(renderErr),
+				zap.String("hash", hash),
+				zap.String("format", format.extension))
+			c.String(http.StatusInternalServerError, "Failed to generate calendar")
 			return
 		}
-		m.Logger.Info("Cache MISS", zap.String("hash", hash))
+		m.writeCalendar(c, hash, format, content, cacheStatusMiss)
+		return
 	}
 
-	// Cache miss or expired - generate new content
-	// Parse stored data from JSON
-	var storedData map[string]any
-	if unmarshalErr := json.Unmarshal(mapping.ValueList, &storedData); unmarshalErr != nil {
-		m.Logger.Error("Failed to parse stored data", zap.Error(unmarshalErr))
-		c.String(http.StatusInternalServerError, "Invalid calendar data")
+	// Serve from cache; misses and stale entries are rendered once across concurrent pollers
+	cacheKey := calendarCacheKey(hash, format)
+	var tier string
+	content, cacheStatus, err := m.Coalescer.load(c.Request.Context(), cacheKey,
+		m.calendarLookup(cacheKey, &tier),
+		m.calendarProbe(cacheKey),
+		m.calendarRender(sub, format, cacheKey))
+	if err != nil {
+		logger.Error("Failed to generate calendar",
+			zap.Error(err),
+			zap.String("hash", hash),
+			zap.String("format", format.extension))
+		c.String(http.StatusInternalServerError, "Failed to generate calendar")
 		return
 	}
 
-	// Extract hideScores flag (default to false)
-	hideScores := false
-	if hideScoresVal, ok := storedData["hideScores"].(bool); ok {
-		hideScores = hideScoresVal
-	}
-
-	// Extract selections (handle both old and new format)
-	var selections map[string]any
-	if selectionsVal, ok := storedData["selections"].(map[string]any); ok {
-		// New format with selections wrapper
-		selections = selectionsVal
-	} else {
-		// Old format without wrapper
-		selections = storedData
-	}
-
-	// Extract game IDs, league IDs, team IDs, and max tier from selections
-	gameIDs, leagueIDs, teamIDs, maxTier := parseSelections(selections, m.Logger)
-	m.Logger.Debug("Parsed IDs from selections",
-		zap.Any("game_ids", gameIDs),
-		zap.Any("league_ids", leagueIDs),
-		zap.Any("team_ids", teamIDs),
-		zap.Int32("max_tier", maxTier),
-		zap.Bool("hide_scores", hideScores))
-
-	// Fetch matches from database (14 days old and future, filtered by tier)
-	var matches []dbtypes.GetCalendarMatchesBySelectionsRow
-	if len(gameIDs) > 0 {
-		matches, err = m.DBConn.GetCalendarMatchesBySelections(m.Context, dbtypes.GetCalendarMatchesBySelectionsParams{
-			GameIds:   gameIDs,
-			LeagueIds: leagueIDs,
-			TeamIds:   teamIDs,
-			MaxTier:   maxTier,
-		})
+	m.recordCacheLookup(c, cacheFamilyICS, cacheStatus)
+	setSpanAttributes(c, attrCacheTier.String(tier))
+	logger.Info("Cache "+cacheStatus,
+		zap.String("hash", hash),
+		zap.String("format", format.extension),
+		zap.String("tier", tier))
+	m.writeCalendar(c, hash, format, content, cacheStatus)
+}
+
+// calendarLookup reads a feed from the calendar cache and records the tier that had it.
+func (m *Middleware) calendarLookup(cacheKey string, tier *string) cacheLookup {
+	return func(ctx context.Context) (string, bool, bool) {
+		feed, ok := m.CalendarCache.Get(ctx, cacheKey)
+		*tier = feed.Tier
+		return feed.Content, feed.Stale, ok
+	}
+}
+
+// calendarProbe reports whether a fresh feed is cached, going by its expiry only.
+func (m *Middleware) calendarProbe(cacheKey string) cacheProbe {
+	return func(ctx context.Context) bool {
+		expiresAt, ok := m.CalendarCache.Expiry(ctx, cacheKey)
+		return ok && !m.CalendarCache.stale(expiresAt)
+	}
+}
+
+// calendarRender renders a cacheable feed in UTC and stores it in the calendar cache.
+func (m *Middleware) calendarRender(sub calendarSubscription, format calendarFormat, cacheKey string) cacheRender {
+	return func(ctx context.Context) (string, error) {
+		rendered, err := m.renderCalendar(ctx, sub, format, time.UTC)
 		if err != nil {
-			m.Logger.Error("Failed to fetch matches", zap.Error(err))
-			c.String(http.StatusInternalServerError, "Failed to generate calendar")
-			return
+			return "", err
+		}
+		if cacheErr := m.CalendarCache.Set(ctx, cacheKey, rendered); cacheErr != nil {
+			m.Logger.Warn("Failed to cache calendar", zap.Error(cacheErr), zap.String("hash", sub.Hash))
 		}
+		return rendered, nil
 	}
+}
 
-	// Generate iCalendar format with hideScores flag
-	icsContent = generateICS(matches, hideScores, m.BaseURL)
+// serveCachedCalendar writes a feed from the calendar cache without touching the database,
+// stale feeds included, and reports whether one was cached.
+func (m *Middleware) serveCachedCalendar(c *gin.Context, hash string, format calendarFormat) bool {
+	logger := m.requestLogger(c.Request.Context())
+	if format.timezoneAware {
+		return false
+	}
+	feed, ok := m.CalendarCache.Get(c.Request.Context(), calendarCacheKey(hash, format))
+	if !ok {
+		m.recordCacheLookup(c, cacheFamilyICS, cacheStatusMiss)
+		return false
+	}
+	cacheStatus := cacheStatusHit
+	if feed.Stale {
+		cacheStatus = cacheStatusStale
+	}
+	m.recordCacheLookup(c, cacheFamilyICS, cacheStatus)
+	setSpanAttributes(c, attrCacheTier.String(feed.Tier))
+	logger.Info("Serving cached calendar without database",
+		zap.String("hash", hash),
+		zap.String("format", format.extension),
+		zap.String("tier", feed.Tier))
+	m.writeCalendar(c, hash, format, feed.Content, cacheStatus)
+	return true
+}
 
-	// Store in cache
-	if m.RedisCache != nil {
-		if cacheErr := m.RedisCache.SetICS(hash, icsContent); cacheErr != nil {
-			m.Logger.Warn("Failed to cache ICS file", zap.Error(cacheErr), zap.String("hash", hash))
-		}
+// renderCalendar fetches the matches of a subscription and serializes them in a calendar format.
+func (m *Middleware) renderCalendar(
+	ctx context.Context,
+	sub calendarSubscription,
+	format calendarFormat,
+	location *time.Location,
+) (string, error) {
+	matches, err := m.fetchCalendarMatches(ctx, sub)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch matches: %w", err)
 	}
 
-	// Set headers for iCalendar file download
+	// Build the intermediate model with hideScores flag and serialize it in the requested format
+	feed := buildCalendarFeed(sub.Hash, matches, sub.HideScores, m.BaseURL)
+	feed.DisplayLocation = location
+	content, err := format.serialize(feed)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize calendar: %w", err)
+	}
+	m.Logger.Debug("Rendered calendar",
+		zap.Int("match_count", len(matches)),
+		zap.String("hash", sub.Hash),
+		zap.String("format", format.extension))
+	return content, nil
+}
+
+// writeCalendar writes a rendered calendar as a file download with the format's content type.
+func (m *Middleware) writeCalendar(c *gin.Context, hash string, format calendarFormat, content, cacheStatus string) {
+	logger := m.requestLogger(c.Request.Context())
 	c.Status(http.StatusOK)
-	c.Header("Content-Type", "text/calendar; charset=utf-8")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"esports-calendar-%s.ics\"", hash))
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"esports-calendar-%s%s\"", hash, format.extension))
 	c.Header("Cache-Control", "public, max-age=3600") // Cache for 1 hour
-	c.Header("X-Cache", "MISS")
-	if _, writeErr := c.Writer.Write([]byte(icsContent)); writeErr != nil {
-		m.Logger.Error("Failed to write calendar content", zap.Error(writeErr))
-		return
+	c.Header("X-Cache", cacheStatus)
+	if _, writeErr := c.Writer.Write([]byte(content)); writeErr != nil {
+		logger.Error("Failed to write calendar content", zap.Error(writeErr))
 	}
-
-	m.Logger.Debug("Served calendar", zap.Int("match_count", len(matches)), zap.String("hash", hash))
 }
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// renderLockTTL bounds how long a render may take, and so how long a crashed instance can block
+	// others from rendering a key.
+	renderLockTTL = 30 * time.Second
+	// renderLockWait is how long a miss waits for another instance's render before rendering itself.
+	renderLockWait  = 5 * time.Second
+	renderLockPoll  = 100 * time.Millisecond
+	renderLockBytes = 16
+	lockPrefix      = "lock:"
+
+	// X-Cache values of coalesced lookups.
+	cacheStatusHit   = "HIT"
+	cacheStatusStale = "STALE"
+	cacheStatusMiss  = "MISS"
+)
+
+// errRenderInProgress is returned by background refreshes when another instance already renders the key.
+var errRenderInProgress = errors.New("render in progress on another instance")
+
+// cacheLookup returns a cached value and whether it is past its fresh TTL.
+type cacheLookup func(ctx context.Context) (value string, stale bool, ok bool)
+
+// cacheProbe reports whether a fresh value is cached. Unlike cacheLookup, it is not counted as a cache access,
+// so it can be polled.
+type cacheProbe func(ctx context.Context) bool
+
+// cacheRender renders a value and stores it in the cache.
+type cacheRender func(ctx context.Context) (string, error)
+
+// RenderCoalescer makes sure a cache key is rendered once at a time: within the process through
+// singleflight, and across instances through a Redis lock when Redis is available.
+type RenderCoalescer struct {
+	group  singleflight.Group
+	redis  *RedisCache
+	owner  string
+	logger *zap.Logger
+}
+
+// NewRenderCoalescer creates a coalescer locking through redisCache, which may be nil.
+func NewRenderCoalescer(redisCache *RedisCache, logger *zap.Logger) (*RenderCoalescer, error) {
+	owner := make([]byte, renderLockBytes)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	return &RenderCoalescer{
+		group:  singleflight.Group{},
+		redis:  redisCache,
+		owner:  hex.EncodeToString(owner),
+		logger: logger,
+	}, nil
+}
+
+// load returns the cached value of key with its X-Cache status. Stale values are served right away
+// while one background render refreshes them; misses share a single render. The shared render is not
+// cancelled with ctx, since other callers may be waiting for it, but the caller stops waiting when
+// ctx is done.
+func (rc *RenderCoalescer) load(
+	ctx context.Context,
+	key string,
+	lookup cacheLookup,
+	probe cacheProbe,
+	render cacheRender,
+) (string, string, error) {
+	if value, stale, ok := lookup(ctx); ok {
+		if !stale {
+			return value, cacheStatusHit, nil
+		}
+		go rc.refresh(context.WithoutCancel(ctx), key, lookup, probe, render)
+		return value, cacheStatusStale, nil
+	}
+
+	renderCtx := context.WithoutCancel(ctx)
+	result := rc.group.DoChan(key, func() (any, error) {
+		return rc.renderLocked(renderCtx, key, lookup, probe, render, true)
+	})
+	var value any
+	var err error
+	select {
+	case <-ctx.Done():
+		return "", cacheStatusMiss, ctx.Err()
+	case res := <-result:
+		value, err = res.Val, res.Err
+		if res.Shared {
+			rc.logger.Debug("Coalesced cache miss", zap.String("key", key))
+		}
+	}
+	if errors.Is(err, errRenderInProgress) {
+		// Joined a background refresh that gave way to another instance; wait for that one instead
+		value, err = rc.renderLocked(ctx, key, lookup, probe, render, true)
+	}
+	if err != nil {
+		return "", cacheStatusMiss, err
+	}
+	content, _ := value.(string) // renderLocked always returns a string
+	return content, cacheStatusMiss, nil
+}
+
+// refresh re-renders a stale key unless another instance is already doing so. It reports whether the key
+// was rendered, which includes joining a render of this process.
+func (rc *RenderCoalescer) refresh(
+	ctx context.Context,
+	key string,
+	lookup cacheLookup,
+	probe cacheProbe,
+	render cacheRender,
+) bool {
+	_, err, _ := rc.group.Do(key, func() (any, error) {
+		return rc.renderLocked(ctx, key, lookup, probe, render, false)
+	})
+	if err != nil && !errors.Is(err, errRenderInProgress) {
+		rc.logger.Warn("Failed to refresh stale cache entry", zap.Error(err), zap.String("key", key))
+	}
+	return err == nil
+}
+
+// renderLocked renders key while holding its Redis lock, within renderLockTTL. When another instance
+// holds the lock, a waiting caller probes the cache for that render, reading it once it is there, and
+// renders itself only after renderLockWait.
+func (rc *RenderCoalescer) renderLocked(
+	ctx context.Context,
+	key string,
+	lookup cacheLookup,
+	probe cacheProbe,
+	render cacheRender,
+	wait bool,
+) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, renderLockTTL)
+	defer cancel()
+	if rc.redis == nil {
+		return render(ctx)
+	}
+	lockKey := lockPrefix + key
+	held, err := rc.redis.HoldLock(ctx, lockKey, rc.owner, renderLockTTL)
+	if err != nil {
+		// Without a working lock, rendering locally is still correct, only less shared
+		rc.logger.Warn("Failed to take render lock", zap.Error(err), zap.String("key", key))
+		return render(ctx)
+	}
+	if held {
+		defer rc.redis.ReleaseLock(context.WithoutCancel(ctx), lockKey, rc.owner)
+		return render(ctx)
+	}
+	if !wait {
+		return "", errRenderInProgress
+	}
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, renderLockWait)
+	defer cancelWait()
+	ticker := time.NewTicker(renderLockPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			rc.logger.Warn("Timed out waiting for render on another instance", zap.String("key", key))
+			return render(ctx)
+		case <-ticker.C:
+			if !probe(ctx) {
+				continue
+			}
+			if value, stale, ok := lookup(ctx); ok && !stale {
+				return value, nil
+			}
+		}
+	}
+}
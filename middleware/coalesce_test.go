@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newLockedRedis starts a minimal RESP server on which every lock is held by another owner,
+// and returns a cache connected to it.
+func newLockedRedis(t *testing.T) *RedisCache {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					args, readErr := readRESPCommand(reader)
+					if readErr != nil {
+						return
+					}
+					reply := "+OK\r\n"
+					switch strings.ToUpper(args[0]) {
+					case "HELLO":
+						reply = "-ERR unknown command 'HELLO'\r\n"
+					case "PING":
+						reply = "+PONG\r\n"
+					case "EVAL":
+						reply = ":0\r\n" // HoldLock: another owner holds the lock
+					}
+					if _, writeErr := conn.Write([]byte(reply)); writeErr != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	cfg := DefaultConfig()
+	cfg.Redis.Addr = listener.Addr().String()
+	cache, err := NewRedisCache(context.Background(), cfg.Redis, cfg.Cache.TTL, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+// TestRenderLockedWaitsWithoutLookups checks that waiting for another instance's render probes the cache
+// and reads it once, so each poll is not counted as a cache miss.
+func TestRenderLockedWaitsWithoutLookups(t *testing.T) {
+	rc, err := NewRenderCoalescer(newLockedRedis(t), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var probes, lookups atomic.Int32
+	probe := func(context.Context) bool {
+		return probes.Add(1) >= 3 // The other instance finishes during the third poll
+	}
+	lookup := func(context.Context) (string, bool, bool) {
+		lookups.Add(1)
+		return "rendered elsewhere", false, true
+	}
+	render := func(context.Context) (string, error) {
+		t.Error("rendered although another instance held the lock")
+		return "", nil
+	}
+
+	value, err := rc.renderLocked(context.Background(), "key", lookup, probe, render, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "rendered elsewhere" {
+		t.Fatalf("renderLocked() = %q, want the other instance's render", value)
+	}
+	if probes.Load() != 3 || lookups.Load() != 1 {
+		t.Fatalf("got %d probes and %d lookups, want 3 and 1", probes.Load(), lookups.Load())
+	}
+}
+
+func TestRefreshReportsRender(t *testing.T) {
+	lookup := func(context.Context) (string, bool, bool) { return "", false, false }
+	probe := func(context.Context) bool { return false }
+	rendered := func(context.Context) (string, error) { return "feed", nil }
+	failed := func(context.Context) (string, error) { return "", errCalendarNotFound }
+
+	local, err := NewRenderCoalescer(nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, err := NewRenderCoalescer(newLockedRedis(t), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		rc     *RenderCoalescer
+		render cacheRender
+		want   bool
+	}{
+		{"rendered", local, rendered, true},
+		{"render failed", local, failed, false},
+		{"rendering elsewhere", locked, rendered, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rc.refresh(context.Background(), "key", lookup, probe, tt.render); got != tt.want {
+				t.Errorf("refresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
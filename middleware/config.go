@@ -0,0 +1,603 @@
+package middleware
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	redactedSecret = "REDACTED"
+
+	defaultHTTPTimeout      = time.Minute
+	defaultShutdownTimeout  = 10 * time.Second
+	defaultHSTSMaxAge       = 365 * 24 * time.Hour
+	defaultPostgresPort     = 5432
+	defaultDBMaxConns       = 10
+	defaultDBConnLifetime   = time.Hour
+	defaultDBConnIdleTime   = 30 * time.Minute
+	defaultDBQueryTimeout   = 10 * time.Second
+	defaultRedisDialTime    = 5 * time.Second
+	defaultRedisCommandTime = 3 * time.Second
+	defaultExportPerMinute  = 10
+	defaultExportBurst      = 20
+	defaultPreviewPerMinute = 30
+	defaultPreviewBurst     = 30
+	defaultCalendarPerMin   = 300
+	defaultCalendarBurst    = 300
+	defaultRefreshPerMinute = 2
+	defaultRefreshBurst     = 3
+	defaultNotifyPerMinute  = 5
+	defaultNotifyBurst      = 10
+	// Three confirmation mails per address and hour.
+	defaultEmailPerMinute = 3.0 / 60
+	defaultEmailBurst     = 3
+	defaultSMTPPort       = 587
+	defaultMailFrom       = "EsportsCalendar <noreply@esportscalendar.app>"
+	maxPort               = 65535
+)
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+// Config is the effective configuration of the server. It is built from defaults, then an optional
+// YAML file, then environment variables, then command-line flags, each overriding the previous.
+type Config struct {
+	HTTP      HTTPConfig      `yaml:"http"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	SMTP      SMTPConfig      `yaml:"smtp"`
+	VAPID     VAPIDConfig     `yaml:"vapid"`
+	BaseURL   string          `yaml:"base_url"`
+	// DebugToken is the bearer token of /debug/status and /metrics, which are disabled while it is empty.
+	DebugToken string `yaml:"debug_token"`
+	// AllowPrivateReceivers lets webhooks and push subscriptions target loopback and private addresses.
+	// It is meant for testing receivers locally and must stay off in production.
+	AllowPrivateReceivers bool `yaml:"allow_private_receivers"`
+}
+
+// HTTPConfig configures the HTTP server. TrustedProxies lists the comma-separated addresses or CIDR ranges
+// whose X-Forwarded-For header names the client IP. HSTSMaxAge is announced in Strict-Transport-Security,
+// which is left out when it is 0.
+type HTTPConfig struct {
+	Addr              string        `yaml:"addr"`
+	TrustedProxies    string        `yaml:"trusted_proxies"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	HSTSMaxAge        time.Duration `yaml:"hsts_max_age"`
+}
+
+// DatabaseConfig configures the PostgreSQL pool. URL, when set, replaces the individual connection fields.
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	// QueryTimeout bounds each query, including reading its rows.
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+// RedisConfig configures the Redis client used when the cache backend is redis.
+type RedisConfig struct {
+	Addr                  string        `yaml:"addr"`
+	Username              string        `yaml:"username"`
+	Password              string        `yaml:"password"`
+	DB                    int           `yaml:"db"`
+	PoolSize              int           `yaml:"pool_size"`
+	DialTimeout           time.Duration `yaml:"dial_timeout"`
+	CommandTimeout        time.Duration `yaml:"command_timeout"`
+	TLS                   bool          `yaml:"tls"`
+	TLSServerName         string        `yaml:"tls_server_name"`
+	TLSInsecureSkipVerify bool          `yaml:"tls_insecure_skip_verify"`
+}
+
+// CacheConfig selects the cache backend and its sizes and TTLs.
+type CacheConfig struct {
+	Backend    string         `yaml:"backend"`
+	MemorySize int            `yaml:"memory_size"`
+	ICSDir     string         `yaml:"ics_dir"`
+	ICSSize    int            `yaml:"ics_size"`
+	TTL        CacheTTLConfig `yaml:"ttl"`
+}
+
+// CacheTTLConfig holds how long cached values are fresh, and how long after that they may still be
+// served while one request refreshes them.
+type CacheTTLConfig struct {
+	ICS       time.Duration `yaml:"ics"`
+	ICSStale  time.Duration `yaml:"ics_stale"`
+	Data      time.Duration `yaml:"data"`
+	DataStale time.Duration `yaml:"data_stale"`
+}
+
+// icsLifetime is how long a calendar stays cached in total, including its stale window.
+func (t CacheTTLConfig) icsLifetime() time.Duration {
+	return t.ICS + t.ICSStale
+}
+
+// dataLifetime is how long option lists stay cached in total, including their stale window.
+func (t CacheTTLConfig) dataLifetime() time.Duration {
+	return t.Data + t.DataStale
+}
+
+// TracingConfig configures OpenTelemetry tracing. Spans are exported over OTLP/HTTP while Endpoint is set;
+// without it, incoming trace context is still passed on to Postgres and Redis spans that are not recorded.
+type TracingConfig struct {
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// RateLimitConfig holds the rate limits of the routes that write to the database or force renders.
+type RateLimitConfig struct {
+	// Export limits calendar link creation per client IP.
+	Export RateLimitPolicy `yaml:"export"`
+	// Preview limits, per client IP, the previews of selections, which are stored until they expire.
+	Preview RateLimitPolicy `yaml:"preview"`
+	// Calendar limits the downloads of each calendar. Calendar services poll from shared IPs, so it is per hash.
+	Calendar RateLimitPolicy `yaml:"calendar"`
+	// Refresh limits the ?refresh=1 downloads of each calendar, which drop its cached feeds.
+	Refresh RateLimitPolicy `yaml:"refresh"`
+	// Notifications limits, per client IP, the registrations of webhooks, push devices and email
+	// addresses and the test messages sent to them, which all reach other servers.
+	Notifications RateLimitPolicy `yaml:"notifications"`
+	// Email limits the confirmation mails sent to each address over all calendars.
+	Email RateLimitPolicy `yaml:"email"`
+}
+
+// SMTPConfig configures the mail server of email digests, which are disabled while Host is empty.
+// Without Username the server must relay unauthenticated, like MailHog on port 1025 for local development.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// VAPIDConfig identifies the server to Web Push services. PrivateKey is a base64url P-256 key as generated
+// by web-push tooling; while it is empty, a key pair is generated once and shared through the database.
+// Subject is the mailto: or https: contact sent to push services and defaults to the base URL.
+type VAPIDConfig struct {
+	PrivateKey string `yaml:"private_key"`
+	Subject    string `yaml:"subject"`
+}
+
+// TrustedProxyList splits TrustedProxies into its entries.
+func (c HTTPConfig) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// DefaultConfig returns the configuration used when nothing overrides it, matching the Docker setup.
+func DefaultConfig() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr: ":8080",
+			// Loopback and private networks, where the reverse proxy of a Docker setup runs
+			TrustedProxies:    "127.0.0.0/8,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7",
+			ReadTimeout:       defaultHTTPTimeout,
+			ReadHeaderTimeout: defaultHTTPTimeout,
+			WriteTimeout:      defaultHTTPTimeout,
+			IdleTimeout:       defaultHTTPTimeout,
+			ShutdownTimeout:   defaultShutdownTimeout,
+			HSTSMaxAge:        defaultHSTSMaxAge,
+		},
+		Database: DatabaseConfig{
+			URL:             "",
+			Host:            "postgres",
+			Port:            defaultPostgresPort,
+			User:            "",
+			Password:        "",
+			Name:            "esports",
+			SSLMode:         "disable",
+			MaxConns:        defaultDBMaxConns,
+			MinConns:        0,
+			MaxConnLifetime: defaultDBConnLifetime,
+			MaxConnIdleTime: defaultDBConnIdleTime,
+			QueryTimeout:    defaultDBQueryTimeout,
+		},
+		Redis: RedisConfig{
+			Addr:                  "redis:6379",
+			Username:              "",
+			Password:              "",
+			DB:                    0,
+			PoolSize:              0,
+			DialTimeout:           defaultRedisDialTime,
+			CommandTimeout:        defaultRedisCommandTime,
+			TLS:                   false,
+			TLSServerName:         "",
+			TLSInsecureSkipVerify: false,
+		},
+		Cache: CacheConfig{
+			Backend:    cacheBackendRedis,
+			MemorySize: defaultMemoryCacheSize,
+			ICSDir:     defaultICSCacheDir,
+			ICSSize:    defaultICSCacheSize,
+			TTL: CacheTTLConfig{
+				ICS:       icsCacheTTL,
+				ICSStale:  icsStaleTTL,
+				Data:      dataCacheTTL,
+				DataStale: dataStaleTTL,
+			},
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "",
+			ServiceName: "esportscalendar",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Export:        RateLimitPolicy{PerMinute: defaultExportPerMinute, Burst: defaultExportBurst},
+			Preview:       RateLimitPolicy{PerMinute: defaultPreviewPerMinute, Burst: defaultPreviewBurst},
+			Calendar:      RateLimitPolicy{PerMinute: defaultCalendarPerMin, Burst: defaultCalendarBurst},
+			Refresh:       RateLimitPolicy{PerMinute: defaultRefreshPerMinute, Burst: defaultRefreshBurst},
+			Notifications: RateLimitPolicy{PerMinute: defaultNotifyPerMinute, Burst: defaultNotifyBurst},
+			Email:         RateLimitPolicy{PerMinute: defaultEmailPerMinute, Burst: defaultEmailBurst},
+		},
+		SMTP: SMTPConfig{
+			Host:     "",
+			Port:     defaultSMTPPort,
+			Username: "",
+			Password: "",
+			From:     defaultMailFrom,
+		},
+		VAPID: VAPIDConfig{
+			PrivateKey: "",
+			Subject:    "",
+		},
+		BaseURL:               "https://esportscalendar.app",
+		DebugToken:            "",
+		AllowPrivateReceivers: false,
+	}
+}
+
+// configField binds a configuration value to its environment variable and command-line flag.
+type configField struct {
+	env   string
+	flag  string
+	usage string
+	value any // Pointer to a string, bool, int, int32, float64 or time.Duration field of Config
+}
+
+// fields lists every setting that can be overridden from the environment or the command line.
+// The lowercase postgres_* variables are kept for existing deployments.
+func (c *Config) fields() []configField {
+	return []configField{
+		{"LISTEN_ADDR", "listen", "HTTP listen address", &c.HTTP.Addr},
+		{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated proxy addresses or CIDRs trusted for X-Forwarded-For",
+			&c.HTTP.TrustedProxies},
+		{"HTTP_READ_TIMEOUT", "http-read-timeout", "HTTP read timeout", &c.HTTP.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", "http-read-header-timeout", "HTTP header read timeout", &c.HTTP.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", "http-write-timeout", "HTTP write timeout", &c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", "http-idle-timeout", "HTTP keep-alive idle timeout", &c.HTTP.IdleTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", "http-shutdown-timeout", "graceful shutdown timeout", &c.HTTP.ShutdownTimeout},
+		{"HSTS_MAX_AGE", "hsts-max-age", "Strict-Transport-Security max-age, 0 to disable", &c.HTTP.HSTSMaxAge},
+		{"DATABASE_URL", "database-url", "PostgreSQL DSN, replaces the other database settings", &c.Database.URL},
+		{"postgres_host", "database-host", "PostgreSQL host", &c.Database.Host},
+		{"postgres_port", "database-port", "PostgreSQL port", &c.Database.Port},
+		{"postgres_user", "database-user", "PostgreSQL user", &c.Database.User},
+		{"postgres_password", "database-password", "PostgreSQL password", &c.Database.Password},
+		{"postgres_db", "database-name", "PostgreSQL database name", &c.Database.Name},
+		{"postgres_sslmode", "database-sslmode", "PostgreSQL sslmode", &c.Database.SSLMode},
+		{"DB_MAX_CONNS", "database-max-conns", "maximum pool connections", &c.Database.MaxConns},
+		{"DB_MIN_CONNS", "database-min-conns", "minimum idle pool connections", &c.Database.MinConns},
+		{"DB_MAX_CONN_LIFETIME", "database-max-conn-lifetime", "pool connection lifetime", &c.Database.MaxConnLifetime},
+		{"DB_MAX_CONN_IDLE_TIME", "database-max-conn-idle-time", "pool idle time", &c.Database.MaxConnIdleTime},
+		{"DB_QUERY_TIMEOUT", "database-query-timeout", "deadline of each query", &c.Database.QueryTimeout},
+		{"REDIS_ADDR", "redis-addr", "Redis address", &c.Redis.Addr},
+		{"REDIS_USERNAME", "redis-username", "Redis ACL user", &c.Redis.Username},
+		{"REDIS_PASSWORD", "redis-password", "Redis password", &c.Redis.Password},
+		{"REDIS_DB", "redis-db", "Redis database number", &c.Redis.DB},
+		{"REDIS_POOL_SIZE", "redis-pool-size", "Redis pool size, 0 for the client default", &c.Redis.PoolSize},
+		{"REDIS_DIAL_TIMEOUT", "redis-dial-timeout", "Redis dial timeout", &c.Redis.DialTimeout},
+		{"REDIS_COMMAND_TIMEOUT", "redis-command-timeout", "deadline of each Redis command",
+			&c.Redis.CommandTimeout},
+		{"REDIS_TLS", "redis-tls", "connect to Redis over TLS", &c.Redis.TLS},
+		{"REDIS_TLS_SERVER_NAME", "redis-tls-server-name", "Redis TLS server name", &c.Redis.TLSServerName},
+		{"REDIS_TLS_INSECURE_SKIP_VERIFY", "redis-tls-insecure-skip-verify",
+			"skip Redis certificate verification", &c.Redis.TLSInsecureSkipVerify},
+		{"CACHE_BACKEND", "cache-backend", "cache backend: redis, memory or none", &c.Cache.Backend},
+		{"CACHE_MEMORY_SIZE", "cache-memory-size", "entries of the memory cache", &c.Cache.MemorySize},
+		{"ICS_CACHE_DIR", "ics-cache-dir", "directory of the calendar file cache", &c.Cache.ICSDir},
+		{"ICS_CACHE_SIZE", "ics-cache-size", "calendars kept in the file cache", &c.Cache.ICSSize},
+		{"CACHE_ICS_TTL", "cache-ics-ttl", "how long rendered calendars are fresh", &c.Cache.TTL.ICS},
+		{"CACHE_ICS_STALE_TTL", "cache-ics-stale-ttl", "how long stale calendars are served", &c.Cache.TTL.ICSStale},
+		{"CACHE_DATA_TTL", "cache-data-ttl", "how long cached data is fresh", &c.Cache.TTL.Data},
+		{"CACHE_DATA_STALE_TTL", "cache-data-stale-ttl", "stale data window", &c.Cache.TTL.DataStale},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "OTLP/HTTP collector URL, empty to disable export",
+			&c.Tracing.Endpoint},
+		{"OTEL_SERVICE_NAME", "otel-service-name", "service name of exported spans", &c.Tracing.ServiceName},
+		{"OTEL_TRACES_SAMPLER_ARG", "otel-sample-ratio", "fraction of new traces to sample", &c.Tracing.SampleRatio},
+		{"RATE_LIMIT_EXPORT_PER_MINUTE", "rate-limit-export-per-minute",
+			"calendar links per IP and minute, 0 to disable", &c.RateLimit.Export.PerMinute},
+		{"RATE_LIMIT_EXPORT_BURST", "rate-limit-export-burst", "calendar links per IP at once",
+			&c.RateLimit.Export.Burst},
+		{"RATE_LIMIT_PREVIEW_PER_MINUTE", "rate-limit-preview-per-minute",
+			"previews per IP and minute, 0 to disable", &c.RateLimit.Preview.PerMinute},
+		{"RATE_LIMIT_PREVIEW_BURST", "rate-limit-preview-burst", "previews per IP at once",
+			&c.RateLimit.Preview.Burst},
+		{"RATE_LIMIT_CALENDAR_PER_MINUTE", "rate-limit-calendar-per-minute",
+			"downloads per calendar and minute, 0 to disable", &c.RateLimit.Calendar.PerMinute},
+		{"RATE_LIMIT_CALENDAR_BURST", "rate-limit-calendar-burst", "downloads per calendar at once",
+			&c.RateLimit.Calendar.Burst},
+		{"RATE_LIMIT_REFRESH_PER_MINUTE", "rate-limit-refresh-per-minute",
+			"forced refreshes per calendar and minute, 0 to disable", &c.RateLimit.Refresh.PerMinute},
+		{"RATE_LIMIT_REFRESH_BURST", "rate-limit-refresh-burst", "forced refreshes per calendar at once",
+			&c.RateLimit.Refresh.Burst},
+		{"RATE_LIMIT_NOTIFICATIONS_PER_MINUTE", "rate-limit-notifications-per-minute",
+			"notification sign-ups and test messages per IP and minute, 0 to disable",
+			&c.RateLimit.Notifications.PerMinute},
+		{"RATE_LIMIT_NOTIFICATIONS_BURST", "rate-limit-notifications-burst",
+			"notification sign-ups and test messages per IP at once", &c.RateLimit.Notifications.Burst},
+		{"RATE_LIMIT_EMAIL_PER_MINUTE", "rate-limit-email-per-minute",
+			"confirmation emails per address and minute, 0 to disable", &c.RateLimit.Email.PerMinute},
+		{"RATE_LIMIT_EMAIL_BURST", "rate-limit-email-burst", "confirmation emails per address at once",
+			&c.RateLimit.Email.Burst},
+		{"SMTP_HOST", "smtp-host", "SMTP server of email digests, empty to disable them", &c.SMTP.Host},
+		{"SMTP_PORT", "smtp-port", "SMTP port", &c.SMTP.Port},
+		{"SMTP_USERNAME", "smtp-username", "SMTP user, empty to send unauthenticated", &c.SMTP.Username},
+		{"SMTP_PASSWORD", "smtp-password", "SMTP password", &c.SMTP.Password},
+		{"SMTP_FROM", "smtp-from", "sender address of emails", &c.SMTP.From},
+		{"VAPID_PRIVATE_KEY", "vapid-private-key", "base64url VAPID private key, empty to generate one",
+			&c.VAPID.PrivateKey},
+		{"VAPID_SUBJECT", "vapid-subject", "mailto: or https: contact sent to push services, empty for the base URL",
+			&c.VAPID.Subject},
+		{"BASE_URL", "base-url", "public URL of the site", &c.BaseURL},
+		{"DEBUG_TOKEN", "debug-token", "bearer token of /debug/status and /metrics, empty to disable them",
+			&c.DebugToken},
+		{"ALLOW_PRIVATE_RECEIVERS", "allow-private-receivers",
+			"let webhooks and push subscriptions target private addresses, for development only",
+			&c.AllowPrivateReceivers},
+	}
+}
+
+// set parses raw into the field.
+func (f configField) set(raw string) error {
+	var err error
+	switch value := f.value.(type) {
+	case *string:
+		*value = raw
+	case *bool:
+		*value, err = strconv.ParseBool(raw)
+	case *int:
+		*value, err = strconv.Atoi(raw)
+	case *int32:
+		var parsed int64
+		parsed, err = strconv.ParseInt(raw, 10, 32)
+		*value = int32(parsed)
+	case *float64:
+		*value, err = strconv.ParseFloat(raw, 64)
+	case *time.Duration:
+		*value, err = time.ParseDuration(raw)
+	default:
+		err = fmt.Errorf("unsupported type %T", f.value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.env, err)
+	}
+	return nil
+}
+
+// LoadConfig builds the configuration from CONFIG_FILE or -config, the environment and args.
+// It reports whether -print-config asked to print the configuration instead of serving.
+func LoadConfig(args []string) (Config, bool, error) {
+	cfg := DefaultConfig()
+	fields := cfg.fields()
+
+	flags := flag.NewFlagSet("esportscalendar", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration and exit")
+	flagValues := make(map[string]string)
+	for _, field := range fields {
+		flags.Func(field.flag, field.usage+" (env "+field.env+")", func(raw string) error {
+			flagValues[field.flag] = raw
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, false, err
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return cfg, false, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err = yaml.UnmarshalWithOptions(data, &cfg, yaml.DisallowUnknownField()); err != nil {
+			return cfg, false, fmt.Errorf("failed to parse config file %s: %w", *configFile, err)
+		}
+	}
+	for _, field := range fields {
+		if raw := os.Getenv(field.env); raw != "" {
+			if err := field.set(raw); err != nil {
+				return cfg, false, fmt.Errorf("%w: %w", errInvalidConfig, err)
+			}
+		}
+	}
+	for _, field := range fields {
+		if raw, ok := flagValues[field.flag]; ok {
+			if err := field.set(raw); err != nil {
+				return cfg, false, fmt.Errorf("%w: -%s: %w", errInvalidConfig, field.flag, err)
+			}
+		}
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return cfg, *printConfig, cfg.Validate()
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		problems = append(problems, "http.addr: "+err.Error())
+	}
+	for _, proxy := range c.HTTP.TrustedProxyList() {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "http.trusted_proxies: "+proxy+" is not an address or CIDR")
+	}
+	check(c.HTTP.ReadTimeout > 0 && c.HTTP.ReadHeaderTimeout > 0 && c.HTTP.WriteTimeout > 0 &&
+		c.HTTP.IdleTimeout > 0 && c.HTTP.ShutdownTimeout > 0, "http: timeouts must be positive")
+	check(c.HTTP.HSTSMaxAge >= 0, "http.hsts_max_age must not be negative")
+
+	if c.Database.URL == "" {
+		check(c.Database.Host != "", "database.host must be set")
+		check(c.Database.Port > 0 && c.Database.Port <= maxPort, "database.port must be a TCP port")
+		check(c.Database.Name != "", "database.name must be set")
+		check(slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"},
+			c.Database.SSLMode), "database.sslmode is not a libpq sslmode")
+	}
+	check(c.Database.MaxConns > 0, "database.max_conns must be positive")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns must be between 0 and max_conns")
+	check(c.Database.MaxConnLifetime > 0 && c.Database.MaxConnIdleTime > 0 && c.Database.QueryTimeout > 0,
+		"database: connection lifetime, idle time and query timeout must be positive")
+
+	check(slices.Contains([]string{cacheBackendRedis, cacheBackendMemory, cacheBackendNone}, c.Cache.Backend),
+		"cache.backend must be redis, memory or none")
+	if c.Cache.Backend == cacheBackendRedis {
+		check(c.Redis.Addr != "", "redis.addr must be set")
+		check(c.Redis.DB >= 0, "redis.db must not be negative")
+		check(c.Redis.PoolSize >= 0, "redis.pool_size must not be negative")
+		check(c.Redis.DialTimeout > 0 && c.Redis.CommandTimeout > 0,
+			"redis: dial and command timeouts must be positive")
+	}
+	check(c.Cache.MemorySize > 0, "cache.memory_size must be positive")
+	check(c.Cache.ICSSize > 0, "cache.ics_size must be positive")
+	check(c.Cache.TTL.ICS > 0 && c.Cache.TTL.Data > 0, "cache.ttl: ics and data must be positive")
+	check(c.Cache.TTL.ICSStale >= 0 && c.Cache.TTL.DataStale >= 0, "cache.ttl: stale windows must not be negative")
+
+	if c.Tracing.Endpoint != "" {
+		endpoint, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"tracing.endpoint must be an absolute http(s) URL")
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name must be set")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	for _, limit := range []struct {
+		name   string
+		policy RateLimitPolicy
+	}{
+		{rateLimitExport, c.RateLimit.Export},
+		{rateLimitPreview, c.RateLimit.Preview},
+		{rateLimitCalendar, c.RateLimit.Calendar},
+		{rateLimitRefresh, c.RateLimit.Refresh},
+		{rateLimitNotifications, c.RateLimit.Notifications},
+		{rateLimitEmail, c.RateLimit.Email},
+	} {
+		check(limit.policy.PerMinute >= 0, "rate_limit."+limit.name+".per_minute must not be negative")
+		check(!limit.policy.enabled() || limit.policy.Burst > 0, "rate_limit."+limit.name+".burst must be positive")
+	}
+
+	if c.SMTP.Host != "" {
+		check(c.SMTP.Port > 0 && c.SMTP.Port <= maxPort, "smtp.port must be a TCP port")
+		_, fromErr := mail.ParseAddress(c.SMTP.From)
+		check(fromErr == nil, "smtp.from must be an email address")
+	}
+
+	if c.VAPID.PrivateKey != "" {
+		_, keyErr := parseVAPIDPrivateKey(c.VAPID.PrivateKey)
+		check(keyErr == nil, "vapid.private_key must be 32 base64url-encoded bytes")
+	}
+	check(c.VAPID.Subject == "" || strings.HasPrefix(c.VAPID.Subject, "mailto:") ||
+		strings.HasPrefix(c.VAPID.Subject, "https:"), "vapid.subject must be a mailto: or https: URL")
+
+	if baseURL, err := url.Parse(c.BaseURL); err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") ||
+		baseURL.Host == "" {
+		problems = append(problems, "base_url must be an absolute http(s) URL")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", errInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DSN returns the PostgreSQL connection string.
+func (c DatabaseConfig) DSN() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// Redacted returns a copy of the configuration with passwords, tokens and credentials in URLs hidden.
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = redactedSecret
+	}
+	if c.Database.URL != "" {
+		dsn, err := url.Parse(c.Database.URL)
+		switch {
+		case err != nil || dsn.Scheme == "":
+			c.Database.URL = redactedSecret // A key=value DSN may hold a password anywhere
+		case dsn.User != nil:
+			if _, hasPassword := dsn.User.Password(); hasPassword {
+				dsn.User = url.UserPassword(dsn.User.Username(), redactedSecret)
+				c.Database.URL = dsn.String()
+			}
+		}
+	}
+	if c.Redis.Password != "" {
+		c.Redis.Password = redactedSecret
+	}
+	if c.SMTP.Password != "" {
+		c.SMTP.Password = redactedSecret
+	}
+	if c.VAPID.PrivateKey != "" {
+		c.VAPID.PrivateKey = redactedSecret
+	}
+	if c.DebugToken != "" {
+		c.DebugToken = redactedSecret
+	}
+	return c
+}
+
+// String renders the configuration as YAML with secrets redacted, in the format of the config file.
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return string(out)
+}
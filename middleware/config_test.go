@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigRedactedHidesSecrets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Database.Password = "db-secret"
+	cfg.Redis.Password = "redis-secret"
+	cfg.SMTP.Password = "smtp-secret"
+	cfg.DebugToken = "debug-secret"
+	cfg.VAPID.PrivateKey = "vapid-secret"
+
+	redacted := cfg.Redacted()
+	for name, value := range map[string]string{
+		"database.password": redacted.Database.Password,
+		"redis.password":    redacted.Redis.Password,
+		"smtp.password":     redacted.SMTP.Password,
+		"debug_token":       redacted.DebugToken,
+		"vapid.private_key": redacted.VAPID.PrivateKey,
+	} {
+		if value != redactedSecret {
+			t.Errorf("%s = %q, want %q", name, value, redactedSecret)
+		}
+	}
+	if cfg.SMTP.Password != "smtp-secret" {
+		t.Error("Redacted changed the original configuration")
+	}
+}
+
+func TestConfigValidateSMTP(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default configuration is invalid: %v", err)
+	}
+	cfg.SMTP.Host = "mail.example.com"
+	cfg.SMTP.Port = 0
+	cfg.SMTP.From = "not an address"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid SMTP settings were accepted")
+	}
+	for _, problem := range []string{"smtp.port", "smtp.from"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("%v does not mention %s", err, problem)
+		}
+	}
+}
+
+func TestLoadConfigReadsSMTPEnvironment(t *testing.T) {
+	t.Setenv("SMTP_HOST", "mailhog")
+	t.Setenv("SMTP_PORT", "1025")
+	t.Setenv("SMTP_PASSWORD", "secret")
+	cfg, _, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SMTP.Host != "mailhog" || cfg.SMTP.Port != 1025 || cfg.SMTP.Password != "secret" {
+		t.Fatalf("SMTP = %+v", cfg.SMTP)
+	}
+}
+
+func TestConfigValidateVAPID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.VAPID.PrivateKey = "not a key"
+	cfg.VAPID.Subject = "admin@example.com"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid VAPID settings were accepted")
+	}
+	for _, problem := range []string{"vapid.private_key", "vapid.subject"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("%v does not mention %s", err, problem)
+		}
+	}
+
+	t.Setenv("VAPID_PRIVATE_KEY", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE")
+	t.Setenv("VAPID_SUBJECT", "mailto:admin@example.com")
+	cfg, _, err = LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VAPID.Subject != "mailto:admin@example.com" {
+		t.Fatalf("VAPID = %+v", cfg.VAPID)
+	}
+}
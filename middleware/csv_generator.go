@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvDateTimeLayout is a spreadsheet-friendly local date-time.
+const csvDateTimeLayout = "2006-01-02 15:04"
+
+// generateCSV serializes a calendar feed as RFC 4180 CSV, one row per match.
+// Start and end times are rendered in the feed's display timezone.
+func generateCSV(feed calendarFeed) (string, error) {
+	location := feed.DisplayLocation
+	if location == nil {
+		location = time.UTC
+	}
+
+	var out strings.Builder
+	w := csv.NewWriter(&out)
+	// RFC 4180 requires CRLF line breaks
+	w.UseCRLF = true
+
+	header := []string{
+		"Game",
+		"League",
+		"Series",
+		"Tournament",
+		"Tier",
+		"Match",
+		"Team 1",
+		"Team 2",
+		"Start (" + location.String() + ")",
+		"End (" + location.String() + ")",
+		"Best Of",
+		"Score",
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, event := range feed.Events {
+		record := []string{
+			event.GameName,
+			event.LeagueName,
+			event.SeriesName,
+			event.TournamentName,
+			event.Tier,
+			event.MatchName,
+			event.Team1Name,
+			event.Team2Name,
+			event.Start.In(location).Format(csvDateTimeLayout),
+			event.End.In(location).Format(csvDateTimeLayout),
+			strconv.Itoa(int(event.BestOf)),
+			event.Score,
+		}
+		for i, cell := range record {
+			record[i] = escapeCSVFormula(cell)
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// escapeCSVFormula prefixes cells that spreadsheets would evaluate as formulas with a quote, so
+// names coming from the match data cannot run formulas when the export is opened.
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
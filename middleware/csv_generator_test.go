@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"Team Liquid":                "Team Liquid",
+		"2-1":                        "2-1",
+		`=HYPERLINK("http://x","a")`: `'=HYPERLINK("http://x","a")`,
+		"+1":                         "'+1",
+		"-cmd":                       "'-cmd",
+		"@SUM(A1)":                   "'@SUM(A1)",
+		"\tTeam":                     "'\tTeam",
+		"\rTeam":                     "'\rTeam",
+	}
+	for cell, want := range tests {
+		if got := escapeCSVFormula(cell); got != want {
+			t.Errorf("escapeCSVFormula(%q) = %q, want %q", cell, got, want)
+		}
+	}
+}
+
+func TestGenerateCSVEscapesFormulas(t *testing.T) {
+	start := time.Date(2026, 11, 2, 12, 0, 0, 0, time.UTC)
+	feed := calendarFeed{ //nolint:exhaustruct // The CSV only uses the events and the timezone
+		DisplayLocation: time.UTC,
+		Events: []calendarEvent{{ //nolint:exhaustruct // Only the tabular fields are exported
+			Start:      start,
+			End:        start.Add(time.Hour),
+			MatchName:  "Final",
+			GameName:   "Dota 2",
+			LeagueName: "=1+1",
+			Team1Name:  "@evil",
+			Team2Name:  "OG",
+			BestOf:     3,
+			Score:      "2-1",
+		}},
+	}
+	content, err := generateCSV(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	row := records[1]
+	if row[1] != "'=1+1" || row[6] != "'@evil" || row[7] != "OG" || row[11] != "2-1" {
+		t.Fatalf("row = %q", row)
+	}
+}
@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/feimaomiao/esportscalendar/components"
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	emailDigestPollInterval = 5 * time.Minute
+	// emailDigestSendHour is the local hour on Monday from which a subscriber's digest goes out.
+	emailDigestSendHour  = 8
+	emailDigestBatchSize = 50
+	// emailDigestMaxMatches caps the matches listed in one digest; the rest are counted.
+	emailDigestMaxMatches = 40
+	emailDigestWindow     = 7 * 24 * time.Hour
+	emailTokenBytes       = 32
+	// maxEmailLength is the longest address SMTP can carry (RFC 5321).
+	maxEmailLength = 254
+	// emailConfirmationCooldown is how long signing up again does not resend the confirmation mail.
+	emailConfirmationCooldown = time.Hour
+)
+
+var (
+	errInvalidEmail     = errors.New("invalid email address")
+	errEmailDisabled    = errors.New("email is not configured on this server")
+	errEmailRateLimited = errors.New("too many confirmation emails were sent to this address, please try again later")
+	errEmailTaken       = errors.New("this address was signed up from another browser, use the links in its emails")
+)
+
+// subscribeEmail stores a digest subscription for a calendar, managed with ownerKey in its stored form,
+// and mails the confirmation link unless the address is already confirmed. The mail is not sent again within
+// emailConfirmationCooldown, and each address gets at most the email rate limit of confirmation
+// mails over all calendars. Unknown timezones fall back to UTC.
+func (m *Middleware) subscribeEmail(
+	ctx context.Context,
+	hash, ownerKey, address, timezone string,
+) (dbtypes.EmailSubscription, error) {
+	if m.Mailer == nil {
+		return dbtypes.EmailSubscription{}, errEmailDisabled
+	}
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil || len(parsed.Address) > maxEmailLength {
+		return dbtypes.EmailSubscription{}, errInvalidEmail
+	}
+	if _, err = m.loadSubscription(ctx, hash); err != nil {
+		return dbtypes.EmailSubscription{}, err
+	}
+
+	token := make([]byte, emailTokenBytes)
+	if _, err = rand.Read(token); err != nil {
+		return dbtypes.EmailSubscription{}, fmt.Errorf("failed to generate email token: %w", err)
+	}
+	sub, err := m.DBConn.UpsertEmailSubscription(ctx, dbtypes.UpsertEmailSubscriptionParams{
+		HashedKey: hash,
+		Email:     strings.ToLower(parsed.Address),
+		Timezone:  digestLocation(timezone).String(),
+		Token:     hex.EncodeToString(token),
+		OwnerKey:  ownerKey,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dbtypes.EmailSubscription{}, errEmailTaken
+	}
+	if err != nil {
+		return dbtypes.EmailSubscription{}, fmt.Errorf("failed to store email subscription: %w", err)
+	}
+	if sub.ConfirmedAt.Valid {
+		return sub, nil
+	}
+	claimed, err := m.DBConn.ClaimEmailConfirmation(ctx, dbtypes.ClaimEmailConfirmationParams{
+		ID:              sub.ID,
+		CooldownSeconds: int32(emailConfirmationCooldown.Seconds()),
+	})
+	if err != nil {
+		return dbtypes.EmailSubscription{}, fmt.Errorf("failed to claim confirmation email: %w", err)
+	}
+	if claimed == 0 {
+		// The link mailed within the cooldown is still valid
+		return sub, nil
+	}
+	if !m.allowEmail(ctx, sub.Email) {
+		m.releaseEmailConfirmation(ctx, sub.ID)
+		return dbtypes.EmailSubscription{}, errEmailRateLimited
+	}
+
+	data := components.ConfirmEmailData{
+		Email:       sub.Email,
+		ConfirmURL:  fmt.Sprintf("%s/email/confirm/%s", m.BaseURL, sub.Token),
+		SettingsURL: fmt.Sprintf("%s/settings/%s", m.BaseURL, hash),
+	}
+	htmlBody, textBody, err := renderEmail(ctx, components.ConfirmEmailHTML(data), components.ConfirmEmailText(data))
+	if err != nil {
+		m.releaseEmailConfirmation(ctx, sub.ID)
+		return dbtypes.EmailSubscription{}, err
+	}
+	if err = m.Mailer.Send(ctx, emailMessage{
+		To:          sub.Email,
+		Subject:     "Confirm your weekly esports digest",
+		Text:        textBody,
+		HTML:        htmlBody,
+		Unsubscribe: "",
+	}); err != nil {
+		m.releaseEmailConfirmation(ctx, sub.ID)
+		return dbtypes.EmailSubscription{}, fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+
+	m.Logger.Info("Email subscription pending confirmation",
+		zap.String("hash", hash),
+		zap.Int32("subscription_id", sub.ID))
+	return sub, nil
+}
+
+// allowEmail takes a token from the email rate limit of an address. Mail is let through while the
+// limiter is unavailable, like requests are.
+func (m *Middleware) allowEmail(ctx context.Context, address string) bool {
+	policy := m.Config.RateLimit.Email
+	if !policy.enabled() {
+		return true
+	}
+	wait, err := m.RateLimiter.TakeToken(ctx, rateLimitPrefix+rateLimitEmail+":"+address, policy)
+	if err != nil {
+		m.requestLogger(ctx).Warn("Rate limiter unavailable, allowing email", zap.Error(err))
+		return true
+	}
+	if wait > 0 {
+		m.Metrics.rateLimited(rateLimitEmail)
+		m.requestLogger(ctx).Info("Confirmation email rate limited", zap.Duration("retry_after", wait))
+		return false
+	}
+	return true
+}
+
+// releaseEmailConfirmation lets a confirmation mail that was not sent be sent on the next sign-up.
+func (m *Middleware) releaseEmailConfirmation(ctx context.Context, id int32) {
+	if err := m.DBConn.ReleaseEmailConfirmation(context.WithoutCancel(ctx), id); err != nil {
+		m.requestLogger(ctx).Warn("Failed to release confirmation email",
+			zap.Error(err),
+			zap.Int32("subscription_id", id))
+	}
+}
+
+// digestLocation resolves a subscriber's IANA timezone. UTC is used for empty or unknown names,
+// and for "Local", which PostgreSQL does not understand.
+func digestLocation(name string) *time.Location {
+	if name == "" || name == "Local" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// RunEmailDigestScheduler mails weekly digests until ctx is cancelled. Each subscription is sent
+// once per week, on Monday from emailDigestSendHour in its own timezone.
+func (m *Middleware) RunEmailDigestScheduler(ctx context.Context) {
+	if m.Mailer == nil {
+		m.Logger.Info("Email digest scheduler disabled, SMTP is not configured")
+		return
+	}
+	m.Logger.Info("Email digest scheduler started")
+	ticker := time.NewTicker(emailDigestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.sendDueDigests(ctx); err != nil && ctx.Err() == nil {
+			m.Logger.Error("Failed to send email digests", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.Logger.Info("Email digest scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueDigests claims due subscriptions in batches and mails them. Claiming marks a digest as
+// sent, so several instances never mail the same address twice; failed sends are released for the next tick.
+func (m *Middleware) sendDueDigests(ctx context.Context) error {
+	for {
+		digests, err := m.DBConn.ClaimDueEmailDigests(ctx, dbtypes.ClaimDueEmailDigestsParams{
+			SendHour:   emailDigestSendHour,
+			LimitCount: emailDigestBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim email digests: %w", err)
+		}
+
+		failed := 0
+		for _, digest := range digests {
+			if sendErr := m.sendDigest(ctx, digest); sendErr != nil {
+				failed++
+				m.Logger.Warn("Failed to send email digest",
+					zap.Int32("subscription_id", digest.ID),
+					zap.Error(sendErr))
+				// Released even during shutdown so the digest is not held until its claim expires
+				releaseErr := m.DBConn.ReleaseEmailDigest(context.WithoutCancel(ctx), digest.ID)
+				if releaseErr != nil {
+					m.Logger.Error("Failed to release email digest",
+						zap.Int32("subscription_id", digest.ID),
+						zap.Error(releaseErr))
+				}
+			}
+		}
+		if len(digests) > 0 {
+			m.Logger.Info("Email digests sent",
+				zap.Int("sent", len(digests)-failed),
+				zap.Int("failed", failed))
+		}
+		// Released digests would be claimed again right away; retry them on the next tick
+		if len(digests) < emailDigestBatchSize || failed > 0 || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// sendDigest renders and mails the digest of one subscription.
+func (m *Middleware) sendDigest(ctx context.Context, digest dbtypes.ClaimDueEmailDigestsRow) error {
+	sub, err := m.decodeSubscription(digest.HashedKey, digest.ValueList)
+	if err != nil {
+		return err
+	}
+	matches, err := m.fetchCalendarMatches(ctx, sub)
+	if err != nil {
+		return fmt.Errorf("failed to fetch matches: %w", err)
+	}
+
+	unsubscribeURL := fmt.Sprintf("%s/email/unsubscribe/%s", m.BaseURL, digest.Token)
+	data := buildDigest(matches, digestLocation(digest.Timezone), time.Now())
+	data.SettingsURL = fmt.Sprintf("%s/settings/%s", m.BaseURL, digest.HashedKey)
+	data.UnsubscribeURL = unsubscribeURL
+
+	htmlBody, textBody, err := renderEmail(ctx, components.DigestEmailHTML(data), components.DigestEmailText(data))
+	if err != nil {
+		return err
+	}
+	return m.Mailer.Send(ctx, emailMessage{
+		To:          digest.Email,
+		Subject:     "This week in your esports calendar: " + data.Period,
+		Text:        textBody,
+		HTML:        htmlBody,
+		Unsubscribe: unsubscribeURL,
+	})
+}
+
+// buildDigest lists the unfinished matches starting within a week of now, in the subscriber's timezone.
+func buildDigest(
+	matches []dbtypes.GetCalendarMatchesBySelectionsRow,
+	location *time.Location,
+	now time.Time,
+) components.DigestEmailData {
+	end := now.Add(emailDigestWindow)
+	data := components.DigestEmailData{
+		Period:         now.In(location).Format("Jan 2") + " – " + end.In(location).Format("Jan 2"),
+		Timezone:       location.String(),
+		Days:           nil,
+		More:           0,
+		SettingsURL:    "",
+		UnsubscribeURL: "",
+	}
+	listed := 0
+	for _, match := range matches {
+		start := match.ExpectedStartTime.Time
+		if match.Finished || !match.ExpectedStartTime.Valid || start.Before(now) || !start.Before(end) {
+			continue
+		}
+		if listed == emailDigestMaxMatches {
+			data.More++
+			continue
+		}
+		listed++
+		local := start.In(location)
+		day := local.Format("Monday, Jan 2")
+		if len(data.Days) == 0 || data.Days[len(data.Days)-1].Day != day {
+			data.Days = append(data.Days, components.DigestDay{Day: day, Matches: nil})
+		}
+		team1 := embedTeamName(match.Team1Acronym.String, match.Team1Name.String)
+		team2 := embedTeamName(match.Team2Acronym.String, match.Team2Name.String)
+		current := &data.Days[len(data.Days)-1]
+		current.Matches = append(current.Matches, components.DigestMatch{
+			Time:  local.Format("15:04"),
+			Teams: team1 + " vs " + team2,
+			Event: joinNonEmpty(" · ", match.GameName, match.LeagueName, match.TournamentName),
+		})
+	}
+	return data
+}
+
+// maskEmail keeps the first character of the local part and the domain of an address.
+func maskEmail(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 1 {
+		return "…"
+	}
+	first, size := utf8.DecodeRuneInString(address)
+	if size >= at {
+		return "…" + address[at:]
+	}
+	return string(first) + "…" + address[at:]
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAllowEmailLimitsEachAddress(t *testing.T) {
+	cfg := DefaultConfig()
+	m := &Middleware{ //nolint:exhaustruct // allowEmail only uses these
+		Config:      cfg,
+		RateLimiter: NewMemoryRateLimiter(),
+		Metrics:     NewMetrics(),
+		Logger:      zap.NewNop(),
+	}
+	ctx := context.Background()
+	for i := range cfg.RateLimit.Email.Burst {
+		if !m.allowEmail(ctx, "victim@example.com") {
+			t.Fatalf("email %d was limited", i+1)
+		}
+	}
+	if m.allowEmail(ctx, "victim@example.com") {
+		t.Fatal("email beyond the burst was allowed")
+	}
+	if !m.allowEmail(ctx, "other@example.com") {
+		t.Fatal("another address shares the limit")
+	}
+}
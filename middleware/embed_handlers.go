@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/components"
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultEmbedCount = 5
+	maxEmbedCount     = 10
+	embedCacheControl = "public, max-age=300" // Widget data is refreshed every 5 minutes
+)
+
+// embedScript renders a schedule widget on third-party pages without an iframe.
+// The %s placeholder receives the JSON-encoded embedScriptConfig.
+const embedScript = `(function (config) {
+  var script = document.currentScript;
+  var target = document.querySelector('[data-esportscalendar="' + config.hash + '"]');
+  if (!target) {
+    target = document.createElement('div');
+    script.parentNode.insertBefore(target, script);
+  }
+  var dark = config.theme === 'dark';
+  var palette = dark
+    ? { bg: '#1d232a', row: '#2a323c', text: '#a6adbb', accent: '#7582ff' }
+    : { bg: '#ffffff', row: '#f2f2f2', text: '#1f2937', accent: '#4f46e5' };
+  target.style.cssText = 'font-family:system-ui,sans-serif;font-size:14px;padding:8px;border-radius:8px;' +
+    'background:' + palette.bg + ';color:' + palette.text;
+
+  function text(tag, value, style) {
+    var el = document.createElement(tag);
+    el.textContent = value;
+    if (style) el.style.cssText = style;
+    return el;
+  }
+
+  function formatTime(iso) {
+    var date = new Date(iso);
+    var options = { month: 'short', day: '2-digit', hour: '2-digit', minute: '2-digit', hour12: false };
+    if (config.timezone) options.timeZone = config.timezone;
+    return date.toLocaleString('en-US', options);
+  }
+
+  fetch(config.api)
+    .then(function (response) {
+      if (!response.ok) throw new Error('HTTP ' + response.status);
+      return response.json();
+    })
+    .then(function (data) {
+      target.textContent = '';
+      if (data.matches.length === 0) {
+        target.appendChild(text('div', 'No upcoming matches.'));
+      }
+      data.matches.forEach(function (match) {
+        var row = document.createElement('div');
+        row.style.cssText = 'display:flex;justify-content:space-between;gap:8px;margin-bottom:4px;' +
+          'border-radius:4px;background:' + palette.row + ';padding:' + (config.compact ? '2px 6px' : '6px 8px');
+        var title = document.createElement('div');
+        title.appendChild(text('strong', match.game + ' ', 'color:' + palette.accent));
+        title.appendChild(text('span', match.team1 + ' vs ' + match.team2));
+        if (!config.compact) title.appendChild(text('div', match.league, 'font-size:12px;opacity:.7'));
+        row.appendChild(title);
+        var status = match.score || (match.start ? formatTime(match.start) : 'TBD');
+        row.appendChild(text('span', status, 'white-space:nowrap;font-family:monospace'));
+        target.appendChild(row);
+      });
+      var link = text('a', 'Subscribe on EsportsCalendar', 'display:block;text-align:right;font-size:12px;color:inherit');
+      link.href = config.calendar;
+      link.target = '_blank';
+      link.rel = 'noopener';
+      target.appendChild(link);
+    })
+    .catch(function () {
+      target.textContent = 'Could not load matches.';
+    });
+})(%s);
+`
+
+// embedScriptConfig is passed to embedScript.
+type embedScriptConfig struct {
+	Hash     string `json:"hash"`
+	API      string `json:"api"`
+	Calendar string `json:"calendar"`
+	Theme    string `json:"theme"`
+	Compact  bool   `json:"compact"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// embedMatch is a match as returned by the embed JSON API.
+type embedMatch struct {
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	Game     string `json:"game"`
+	League   string `json:"league"`
+	Team1    string `json:"team1"`
+	Team2    string `json:"team2"`
+	Start    string `json:"start,omitempty"`
+	Finished bool   `json:"finished"`
+	// Score is "2-1" for finished matches, "Finished" when scores are hidden and empty otherwise.
+	Score string `json:"score,omitempty"`
+}
+
+// embedParams are the widget query parameters shared by the page, the script and the JSON API.
+type embedParams struct {
+	count    int32
+	location *time.Location
+	options  components.EmbedOptions
+}
+
+// parseEmbedParams reads ?count, ?tz, ?theme and ?compact.
+func parseEmbedParams(c *gin.Context) (embedParams, error) {
+	params := embedParams{
+		count:    defaultEmbedCount,
+		location: time.UTC,
+		options: components.EmbedOptions{
+			Theme:       "light",
+			Compact:     c.Query("compact") == "1" || c.Query("compact") == "true",
+			Timezone:    c.Query("tz"),
+			CalendarURL: "",
+		},
+	}
+
+	if countStr := c.Query("count"); countStr != "" {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > maxEmbedCount {
+			return embedParams{}, fmt.Errorf("count must be between 1 and %d", maxEmbedCount)
+		}
+		params.count = int32(count) // #nosec G115 -- count is bounded by maxEmbedCount
+	}
+	if params.options.Timezone != "" {
+		location, err := time.LoadLocation(params.options.Timezone)
+		if err != nil {
+			return embedParams{}, errors.New("invalid timezone")
+		}
+		params.location = location
+	}
+	if c.Query("theme") == "dark" {
+		params.options.Theme = "dark"
+	}
+	return params, nil
+}
+
+// EmbedHandler serves the iframe schedule widget at /embed/:hash and its loader script at /embed/:hash.js.
+func (m *Middleware) EmbedHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	params, err := parseEmbedParams(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash := c.Param("hash")
+	if scriptHash, isScript := strings.CutSuffix(hash, ".js"); isScript {
+		m.serveEmbedScript(c, scriptHash, params)
+		return
+	}
+
+	sub, matches, ok := m.loadEmbedMatches(c, hash, params.count)
+	if !ok {
+		return
+	}
+	// With an explicit timezone times are rendered server-side; otherwise the page converts them in the browser
+	if params.options.Timezone != "" {
+		for i := range matches {
+			matches[i].ExpectedStartTime.Time = matches[i].ExpectedStartTime.Time.In(params.location)
+		}
+	}
+	params.options.CalendarURL = fmt.Sprintf("%s/%s.ics", m.BaseURL, hash)
+
+	// Allow the widget to be framed by any site
+	allowFraming(c)
+	c.Header("Cache-Control", pageCacheControl)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	component := components.EmbedPage(matches, sub.HideScores, params.options)
+	if renderErr := component.Render(c.Request.Context(), c.Writer); renderErr != nil {
+		logger.Error("Failed to render embed page", zap.Error(renderErr))
+		c.String(http.StatusInternalServerError, "Failed to render page")
+	}
+}
+
+// EmbedMatchesHandler is the JSON API behind the embed script: /api/matches/:hash?count=5.
+func (m *Middleware) EmbedMatchesHandler(c *gin.Context) {
+	params, err := parseEmbedParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	hash := c.Param("hash")
+	sub, matches, ok := m.loadEmbedMatches(c, hash, params.count)
+	if !ok {
+		return
+	}
+
+	result := make([]embedMatch, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, newEmbedMatch(match, sub.HideScores))
+	}
+
+	// The script runs on third-party origins
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Cache-Control", embedCacheControl)
+	c.JSON(http.StatusOK, map[string]any{
+		"hash":    hash,
+		"matches": result,
+	})
+}
+
+func (m *Middleware) serveEmbedScript(c *gin.Context, hash string, params embedParams) {
+	logger := m.requestLogger(c.Request.Context())
+	query := url.Values{}
+	query.Set("count", strconv.Itoa(int(params.count)))
+	config, err := json.Marshal(embedScriptConfig{
+		Hash:     hash,
+		API:      fmt.Sprintf("%s/api/matches/%s?%s", m.BaseURL, url.PathEscape(hash), query.Encode()),
+		Calendar: fmt.Sprintf("%s/%s.ics", m.BaseURL, hash),
+		Theme:    params.options.Theme,
+		Compact:  params.options.Compact,
+		Timezone: params.options.Timezone,
+	})
+	if err != nil {
+		logger.Error("Failed to encode embed config", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to generate script")
+		return
+	}
+
+	c.Header("Cache-Control", embedCacheControl)
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", fmt.Appendf(nil, embedScript, config))
+}
+
+// loadEmbedMatches loads a subscription and up to count of its matches, writing the error response on failure.
+func (m *Middleware) loadEmbedMatches(
+	c *gin.Context,
+	hash string,
+	count int32,
+) (calendarSubscription, []dbtypes.GetFutureMatchesBySelectionsRow, bool) {
+	logger := m.requestLogger(c.Request.Context())
+	sub, err := m.loadSubscription(c.Request.Context(), hash)
+	if errors.Is(err, errCalendarNotFound) {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return calendarSubscription{}, nil, false
+	}
+	if err != nil {
+		logger.Error("Failed to load subscription", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Invalid calendar data")
+		return calendarSubscription{}, nil, false
+	}
+
+	matches, _, err := m.fetchMatches(c.Request.Context(), sub.GameIDs, sub.LeagueIDs, sub.TeamIDs, sub.MaxTier, count)
+	if err != nil {
+		logger.Error("Failed to fetch matches", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to fetch matches")
+		return calendarSubscription{}, nil, false
+	}
+	return sub, matches, true
+}
+
+func newEmbedMatch(match dbtypes.GetFutureMatchesBySelectionsRow, hideScores bool) embedMatch {
+	result := embedMatch{
+		ID:       match.ID,
+		Name:     match.Name,
+		Game:     match.GameName,
+		League:   match.LeagueName,
+		Team1:    embedTeamName(match.Team1Acronym.String, match.Team1Name.String),
+		Team2:    embedTeamName(match.Team2Acronym.String, match.Team2Name.String),
+		Start:    "",
+		Finished: match.Finished,
+		Score:    "",
+	}
+	if match.ExpectedStartTime.Valid {
+		result.Start = match.ExpectedStartTime.Time.UTC().Format(time.RFC3339)
+	}
+	if match.Finished {
+		result.Score = "Finished"
+		if !hideScores {
+			result.Score = fmt.Sprintf("%d-%d", match.Team1Score, match.Team2Score)
+		}
+	}
+	return result
+}
+
+// embedTeamName prefers the acronym, like the preview cards.
+func embedTeamName(acronym, name string) string {
+	switch {
+	case acronym != "":
+		return acronym
+	case name != "":
+		return name
+	default:
+		return "TBD"
+	}
+}
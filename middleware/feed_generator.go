@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	atomNamespace = "http://www.w3.org/2005/Atom"
+	// tagURIDate is the fixed date component of the tag: URIs used as entry IDs (RFC 4151).
+	tagURIDate = "2025"
+)
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     atomText `xml:"title"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Content   atomText `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  string      `xml:"author>name"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXmlns string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+// generateAtom serializes a calendar feed as an Atom feed (RFC 4287) with one entry per match.
+func generateAtom(feed calendarFeed) (string, error) {
+	selfURL := feed.Link + ".atom"
+	entries := make([]atomEntry, 0, len(feed.Events))
+	for _, event := range feed.Events {
+		entries = append(entries, atomEntry{
+			ID:        entryTagURI(feed.Link, event.MatchID),
+			Title:     atomText{Type: "text", Value: event.Summary},
+			Link:      atomLink{Href: event.URL, Rel: "alternate", Type: ""},
+			Published: event.Start.UTC().Format(time.RFC3339),
+			Updated:   event.updated(feed.Generated).UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "text", Value: entryContent(event)},
+		})
+	}
+
+	doc := atomFeed{
+		XMLName: xml.Name{Space: "", Local: "feed"},
+		Xmlns:   atomNamespace,
+		ID:      selfURL,
+		Title:   feed.Name,
+		Updated: feedUpdated(feed).UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.Link + ".ics", Rel: "alternate", Type: "text/calendar"},
+		},
+		Author:  feed.Name,
+		Entries: entries,
+	}
+	return marshalFeed(doc)
+}
+
+// generateRSS serializes a calendar feed as an RSS 2.0 channel with one item per match.
+func generateRSS(feed calendarFeed) (string, error) {
+	items := make([]rssItem, 0, len(feed.Events))
+	for _, event := range feed.Events {
+		items = append(items, rssItem{
+			Title:       event.Summary,
+			Link:        event.URL,
+			Description: entryContent(event),
+			GUID:        rssGUID{IsPermaLink: false, Value: entryTagURI(feed.Link, event.MatchID)},
+			PubDate:     event.updated(feed.Generated).UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	doc := rssFeed{
+		XMLName:   xml.Name{Space: "", Local: "rss"},
+		Version:   "2.0",
+		AtomXmlns: atomNamespace,
+		Channel: rssChannel{
+			Title:         feed.Name,
+			Link:          feed.Link + ".ics",
+			Description:   "Upcoming and recent esports matches for your selections",
+			LastBuildDate: feedUpdated(feed).UTC().Format(time.RFC1123Z),
+			SelfLink:      atomLink{Href: feed.Link + ".rss", Rel: "self", Type: "application/rss+xml"},
+			Items:         items,
+		},
+	}
+	return marshalFeed(doc)
+}
+
+// entryTagURI builds a stable tag: URI for a match so readers keep one entry per match
+// across updates, e.g. tag:esportscalendar.app,2025:abcd1234/match/42.
+func entryTagURI(feedLink string, matchID int32) string {
+	authority := "esportscalendar.app"
+	path := feedLink
+	if parsed, err := url.Parse(feedLink); err == nil && parsed.Host != "" {
+		authority = parsed.Hostname()
+		path = strings.TrimPrefix(parsed.Path, "/")
+	}
+	return fmt.Sprintf("tag:%s,%s:%s/match/%d", authority, tagURIDate, path, matchID)
+}
+
+// entryContent returns the entry body: description plus the (already score-aware) schedule.
+func entryContent(event calendarEvent) string {
+	content := event.Description
+	if event.Location != "" {
+		content += "\n" + event.Location
+	}
+	return fmt.Sprintf("%s\nStarts: %s", content, event.Start.UTC().Format(time.RFC1123))
+}
+
+// feedUpdated returns the most recent entry update, or the generation time for an empty feed.
+func feedUpdated(feed calendarFeed) time.Time {
+	var latest time.Time
+	for _, event := range feed.Events {
+		if updated := event.updated(feed.Generated); updated.After(latest) {
+			latest = updated
+		}
+	}
+	if latest.IsZero() {
+		return feed.Generated
+	}
+	return latest
+}
+
+func marshalFeed(doc any) (string, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data), nil
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestFeedEntriesLinkTheirMatch checks that Atom entries and RSS items link their own match
+// rather than the site.
+func TestFeedEntriesLinkTheirMatch(t *testing.T) {
+	start := pgtype.Timestamp{Time: time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC), Valid: true}
+	matches := []dbtypes.GetCalendarMatchesBySelectionsRow{
+		//nolint:exhaustruct // The feed reads these
+		{ID: 41, Name: "A vs B", ExpectedStartTime: start, AmountOfGames: 3, GameName: "LoL"},
+		//nolint:exhaustruct // The feed reads these
+		{ID: 42, Name: "C vs D", ExpectedStartTime: start, AmountOfGames: 3, GameName: "LoL"},
+	}
+	feed := buildCalendarFeed("abcd1234", matches, false, "https://esportscalendar.app")
+
+	atom, err := generateAtom(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rss, err := generateRSS(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<link href="https://esportscalendar.app/embed/abcd1234#match-41" rel="alternate">`,
+		`<link href="https://esportscalendar.app/embed/abcd1234#match-42" rel="alternate">`,
+	} {
+		if !strings.Contains(atom, want) {
+			t.Errorf("Atom feed lacks %s", want)
+		}
+	}
+	for _, want := range []string{
+		"<link>https://esportscalendar.app/embed/abcd1234#match-41</link>",
+		"<link>https://esportscalendar.app/embed/abcd1234#match-42</link>",
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("RSS feed lacks %s", want)
+		}
+	}
+}
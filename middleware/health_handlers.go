@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	healthCheckTimeout = 3 * time.Second
+	checkOK            = "ok"
+	checkDisabled      = "disabled"
+	checkUnavailable   = "unavailable"
+)
+
+// requiredTables lists the tables of sqlc/schema.sql. The schema is applied outside this server,
+// so readiness checks that it is complete instead of reading a migration version.
+func requiredTables() []string {
+	return []string{
+		"games", "leagues", "series", "tournaments", "matches", "teams", "url_mappings",
+		"match_revisions", "match_changes", "webhooks", "webhook_deliveries", "webhook_dead_letters",
+		"email_subscriptions", "vapid_keys", "push_subscriptions", "push_reminders",
+	}
+}
+
+// readinessReport is the result of the readiness checks. Each check is "ok", "disabled" or the reason it failed.
+type readinessReport struct {
+	Ready    bool   `json:"ready"`
+	Postgres string `json:"postgres"`
+	Schema   string `json:"schema"`
+	Redis    string `json:"redis"`
+}
+
+// poolStats is the pgxpool state reported by /debug/status.
+type poolStats struct {
+	TotalConns        int32   `json:"total_conns"`
+	AcquiredConns     int32   `json:"acquired_conns"`
+	IdleConns         int32   `json:"idle_conns"`
+	MaxConns          int32   `json:"max_conns"`
+	AcquireCount      int64   `json:"acquire_count"`
+	EmptyAcquireCount int64   `json:"empty_acquire_count"`
+	CanceledAcquires  int64   `json:"canceled_acquire_count"`
+	AcquireSeconds    float64 `json:"acquire_duration_seconds"`
+}
+
+// buildStatus describes the running binary.
+type buildStatus struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Time      string `json:"time"`
+	Modified  bool   `json:"modified"`
+}
+
+// ingestionStatus reports how long ago the ingestion service last changed a match.
+type ingestionStatus struct {
+	LastMatchChange *time.Time `json:"last_match_change"`
+	LagSeconds      *float64   `json:"lag_seconds"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// debugStatus is the body of /debug/status.
+type debugStatus struct {
+	Readiness     readinessReport    `json:"readiness"`
+	Pool          poolStats          `json:"pool"`
+	Cache         map[string]int64   `json:"cache"`
+	CacheError    string             `json:"cache_error,omitempty"`
+	CalendarCache calendarCacheStats `json:"calendar_cache"`
+	Build         buildStatus        `json:"build"`
+	Ingestion     ingestionStatus    `json:"ingestion"`
+	Uptime        string             `json:"uptime"`
+	Goroutines    int                `json:"goroutines"`
+}
+
+// HealthzHandler reports that the process is alive. It checks nothing else, so a restart is
+// only triggered for a hung process and not for an unreachable database.
+func (m *Middleware) HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, map[string]any{"status": checkOK})
+}
+
+// ReadyzHandler reports whether this instance can serve traffic: Postgres answers and holds the
+// expected schema. Redis is reported but does not fail readiness, since the memory and file caches
+// serve without it.
+func (m *Middleware) ReadyzHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	report := m.checkReadiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+		c.Header("Retry-After", maintenanceRetryAfter)
+		logger.Warn("Readiness check failed",
+			zap.String("postgres", report.Postgres),
+			zap.String("schema", report.Schema),
+			zap.String("redis", report.Redis))
+	}
+	c.JSON(status, report.public())
+}
+
+// public replaces failure reasons with "unavailable", since they may name hosts and users.
+func (r readinessReport) public() readinessReport {
+	for _, check := range []*string{&r.Postgres, &r.Schema, &r.Redis} {
+		if *check != checkOK && *check != checkDisabled {
+			*check = checkUnavailable
+		}
+	}
+	return r
+}
+
+// checkReadiness pings Postgres and Redis and looks up the schema.
+func (m *Middleware) checkReadiness(ctx context.Context) readinessReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := readinessReport{Ready: false, Postgres: checkOK, Schema: checkOK, Redis: checkDisabled}
+	if err := m.DB.Ping(ctx); err != nil {
+		report.Postgres = err.Error()
+		report.Schema = checkUnavailable
+	} else if missing, err := m.DBConn.ListMissingTables(ctx, requiredTables()); err != nil {
+		report.Schema = err.Error()
+	} else if len(missing) > 0 {
+		report.Schema = "missing tables: " + strings.Join(missing, ", ")
+	}
+	if m.RedisCache != nil {
+		report.Redis = checkOK
+		if err := m.RedisCache.Ping(ctx); err != nil {
+			report.Redis = err.Error()
+		}
+	}
+	report.Ready = report.Postgres == checkOK && report.Schema == checkOK
+	return report
+}
+
+// RequireDebugToken guards operator endpoints such as /debug/status and /metrics. It requires the
+// configured debug token as a bearer token, and the endpoints are not found while no token is configured.
+func (m *Middleware) RequireDebugToken(c *gin.Context) {
+	if m.debugAuthorized(c.GetHeader("Authorization")) {
+		c.Next()
+		return
+	}
+	if m.Config.DebugToken == "" {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	m.requestLogger(c.Request.Context()).Warn("Rejected debug request",
+		zap.String("path", c.Request.URL.Path), zap.String("client_ip", c.ClientIP()))
+	c.Header("WWW-Authenticate", `Bearer realm="debug"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": true, "message": "Unauthorized"})
+}
+
+// DebugStatusHandler reports pool, cache, build and ingestion details for operators.
+// It is routed behind RequireDebugToken.
+func (m *Middleware) DebugStatusHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	cacheStats, cacheErr := m.Cache.GetCacheStats(ctx)
+	status := debugStatus{
+		Readiness:     m.checkReadiness(ctx),
+		Pool:          m.poolStats(),
+		Cache:         cacheStats,
+		CacheError:    "",
+		CalendarCache: m.CalendarCache.Stats(),
+		Build:         readBuildStatus(),
+		Ingestion:     m.ingestionStatus(ctx),
+		Uptime:        time.Since(m.StartedAt).Round(time.Second).String(),
+		Goroutines:    runtime.NumGoroutine(),
+	}
+	if cacheErr != nil {
+		status.CacheError = cacheErr.Error()
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// debugAuthorized reports whether header carries the debug token, comparing in constant time.
+func (m *Middleware) debugAuthorized(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return m.Config.DebugToken != "" && ok &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(m.Config.DebugToken)) == 1
+}
+
+// poolStats reads the current state of the database pool.
+func (m *Middleware) poolStats() poolStats {
+	stat := m.DB.Stat()
+	return poolStats{
+		TotalConns:        stat.TotalConns(),
+		AcquiredConns:     stat.AcquiredConns(),
+		IdleConns:         stat.IdleConns(),
+		MaxConns:          stat.MaxConns(),
+		AcquireCount:      stat.AcquireCount(),
+		EmptyAcquireCount: stat.EmptyAcquireCount(),
+		CanceledAcquires:  stat.CanceledAcquireCount(),
+		AcquireSeconds:    stat.AcquireDuration().Seconds(),
+	}
+}
+
+// ingestionStatus measures the time since the newest match revision. Revisions are only written
+// when an upsert changes a match, so a quiet schedule also shows up as lag.
+func (m *Middleware) ingestionStatus(ctx context.Context) ingestionStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	status := ingestionStatus{LastMatchChange: nil, LagSeconds: nil, Error: ""}
+	changedAt, err := m.DBConn.GetLatestMatchChange(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if changedAt.Valid {
+		lag := time.Since(changedAt.Time).Seconds()
+		status.LastMatchChange = &changedAt.Time
+		status.LagSeconds = &lag
+	}
+	return status
+}
+
+// readBuildStatus reads the module version and VCS stamp embedded by the Go toolchain.
+func readBuildStatus() buildStatus {
+	status := buildStatus{
+		GoVersion: runtime.Version(),
+		Module:    "",
+		Version:   "",
+		Revision:  "",
+		Time:      "",
+		Modified:  false,
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return status
+	}
+	status.Module = info.Main.Path
+	status.Version = info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			status.Revision = setting.Value
+		case "vcs.time":
+			status.Time = setting.Value
+		case "vcs.modified":
+			status.Modified = setting.Value == "true"
+		}
+	}
+	return status
+}
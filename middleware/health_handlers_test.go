@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRequireDebugToken(t *testing.T) {
+	tests := []struct {
+		token         string
+		authorization string
+		want          int
+	}{
+		{"", "", http.StatusNotFound},
+		{"", "Bearer ", http.StatusNotFound},
+		{"secret", "", http.StatusUnauthorized},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"secret", "secret", http.StatusUnauthorized},
+		{"secret", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.DebugToken = tt.token
+		m := &Middleware{Config: cfg, Logger: zap.NewNop()} //nolint:exhaustruct // The guard only uses these
+		router := gin.New()
+		router.GET("/metrics", m.RequireDebugToken, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.authorization != "" {
+			request.Header.Set("Authorization", tt.authorization)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != tt.want {
+			t.Errorf("token %q, Authorization %q: got status %d, want %d",
+				tt.token, tt.authorization, recorder.Code, tt.want)
+		}
+	}
+}
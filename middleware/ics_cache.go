@@ -4,6 +4,8 @@ import (
 	"container/list"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,98 +13,190 @@ import (
 )
 
 const (
-	maxCacheSize = 256
-	// Forces the cache to refresh each hour.
-	cacheRefreshTime = time.Hour
-	cacheDir         = "/tmp/esportscalendar-ics-cache"
+	defaultICSCacheSize = 256
+	defaultICSCacheDir  = "/tmp/esportscalendar-ics-cache"
+	icsCacheFileExt     = ".ics"
+	// icsCacheTempPrefix names the files feeds are written to before they replace the cached file.
+	icsCacheTempPrefix = ".write-"
 )
 
 type cacheEntry struct {
-	hash       string
-	filePath   string
-	lastUpdate time.Time
-	element    *list.Element
+	hash     string
+	filePath string
+	// expiresAt matches the Redis TTL of the same feed. The file's modification time
+	// is set to expiresAt - lifetime so the expiry survives a restart.
+	expiresAt time.Time
+	element   *list.Element
 }
 
+// ICSCache is a file-backed LRU cache of rendered calendar feeds, the local tier in front of Redis.
 type ICSCache struct {
 	mu      sync.RWMutex
 	entries map[string]*cacheEntry
 	lruList *list.List
-	logger  *zap.Logger
+	dir     string
+	maxSize int
+	// lifetime is how long a feed stays cached in total, including its stale window.
+	lifetime time.Duration
+	logger   *zap.Logger
 }
 
-func NewICSCache(logger *zap.Logger) (*ICSCache, error) {
+// NewICSCache creates the cache in cfg.ICSDir holding up to cfg.ICSSize feeds,
+// and rehydrates it from the unexpired files a previous run left there.
+func NewICSCache(cfg CacheConfig, logger *zap.Logger) (*ICSCache, error) {
 	// Create cache directory if it doesn't exist
-	if err := os.MkdirAll(cacheDir, 0750); err != nil {
+	if err := os.MkdirAll(cfg.ICSDir, 0750); err != nil {
 		return nil, err
 	}
 
-	return &ICSCache{
-		entries: make(map[string]*cacheEntry),
-		lruList: list.New(),
-		logger:  logger,
-		mu:      sync.RWMutex{},
-	}, nil
+	cache := &ICSCache{
+		entries:  make(map[string]*cacheEntry),
+		lruList:  list.New(),
+		dir:      cfg.ICSDir,
+		maxSize:  cfg.ICSSize,
+		lifetime: cfg.TTL.icsLifetime(),
+		logger:   logger,
+		mu:       sync.RWMutex{},
+	}
+	cache.rehydrate()
+	logger.Info("ICS file cache initialized",
+		zap.String("cache_dir", cfg.ICSDir),
+		zap.Int("max_size", cfg.ICSSize),
+		zap.Int("rehydrated", len(cache.entries)))
+	return cache, nil
+}
+
+// rehydrate loads the unexpired files of the cache directory, most recently written first,
+// and removes expired ones.
+func (c *ICSCache) rehydrate() {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		c.logger.Warn("Failed to read cache directory", zap.Error(err), zap.String("cache_dir", c.dir))
+		return
+	}
+
+	var loaded []*cacheEntry
+	now := time.Now()
+	for _, file := range files {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), icsCacheTempPrefix) {
+			// Left behind by a write that was interrupted
+			_ = os.Remove(filepath.Join(c.dir, file.Name()))
+			continue
+		}
+		if file.IsDir() || !strings.HasSuffix(file.Name(), icsCacheFileExt) {
+			continue
+		}
+		info, infoErr := file.Info()
+		if infoErr != nil {
+			continue
+		}
+		entry := &cacheEntry{
+			hash:      strings.TrimSuffix(file.Name(), icsCacheFileExt),
+			filePath:  filepath.Join(c.dir, file.Name()),
+			expiresAt: info.ModTime().Add(c.lifetime),
+			element:   nil,
+		}
+		if !entry.expiresAt.After(now) {
+			if removeErr := os.Remove(entry.filePath); removeErr != nil {
+				c.logger.Warn("Failed to remove expired cache file",
+					zap.Error(removeErr),
+					zap.String("hash", entry.hash))
+			}
+			continue
+		}
+		loaded = append(loaded, entry)
+	}
+
+	// Oldest first, so the newest files end up at the front of the LRU list
+	slices.SortFunc(loaded, func(a, b *cacheEntry) int {
+		return a.expiresAt.Compare(b.expiresAt)
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range loaded {
+		if c.lruList.Len() >= c.maxSize {
+			c.evictLRU()
+		}
+		entry.element = c.lruList.PushFront(entry.hash)
+		c.entries[entry.hash] = entry
+	}
 }
 
-// Get retrieves cached ICS content if valid (not expired).
-func (c *ICSCache) Get(hash string) (string, bool) {
+// Get retrieves cached ICS content and its expiry if valid (not expired).
+func (c *ICSCache) Get(hash string) (string, time.Time, bool) {
 	c.mu.RLock()
 	entry, exists := c.entries[hash]
+	var expiresAt time.Time
+	if exists {
+		expiresAt = entry.expiresAt
+	}
 	c.mu.RUnlock()
 
 	if !exists {
-		return "", false
+		return "", time.Time{}, false
 	}
 
 	// Check if cache is expired
-	if time.Since(entry.lastUpdate) > cacheRefreshTime {
+	if !time.Now().Before(expiresAt) {
 		c.logger.Debug("Cache expired", zap.String("hash", hash))
-		return "", false
+		return "", time.Time{}, false
 	}
 
 	// Read from file
 	content, err := os.ReadFile(entry.filePath)
 	if err != nil {
 		c.logger.Error("Failed to read cached file", zap.Error(err), zap.String("hash", hash))
-		return "", false
+		return "", time.Time{}, false
 	}
 
-	// Move to front of LRU list
+	// Move to front of LRU list, unless the entry was evicted meanwhile
 	c.mu.Lock()
-	c.lruList.MoveToFront(entry.element)
+	if c.entries[hash] == entry {
+		c.lruList.MoveToFront(entry.element)
+	}
 	c.mu.Unlock()
 
 	c.logger.Debug("Cache hit", zap.String("hash", hash))
-	return string(content), true
+	return string(content), expiresAt, true
+}
+
+// Expiry returns when a cached feed expires, without reading it.
+func (c *ICSCache) Expiry(hash string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, exists := c.entries[hash]
+	if !exists {
+		return time.Time{}, false
+	}
+	return entry.expiresAt, true
 }
 
-// Set stores ICS content in cache.
-func (c *ICSCache) Set(hash string, content string) error {
+// Set stores ICS content in cache until expiresAt.
+func (c *ICSCache) Set(hash string, content string, expiresAt time.Time) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// Check if entry already exists
 	if entry, exists := c.entries[hash]; exists {
 		// Update existing entry
-		if err := os.WriteFile(entry.filePath, []byte(content), 0600); err != nil {
+		if err := c.writeFile(entry.filePath, content, expiresAt); err != nil {
 			c.logger.Error("Failed to write cache file", zap.Error(err), zap.String("hash", hash))
 			return err
 		}
-		entry.lastUpdate = time.Now()
+		entry.expiresAt = expiresAt
 		c.lruList.MoveToFront(entry.element)
 		c.logger.Debug("Cache updated", zap.String("hash", hash))
 		return nil
 	}
 
 	// Evict LRU entry if cache is full
-	if c.lruList.Len() >= maxCacheSize {
+	if c.lruList.Len() >= c.maxSize {
 		c.evictLRU()
 	}
 
 	// Create new cache file
-	filePath := filepath.Join(cacheDir, hash+".ics")
-	if err := os.WriteFile(filePath, []byte(content), 0600); err != nil {
+	filePath := filepath.Join(c.dir, hash+icsCacheFileExt)
+	if err := c.writeFile(filePath, content, expiresAt); err != nil {
 		c.logger.Error("Failed to write cache file", zap.Error(err), zap.String("hash", hash))
 		return err
 	}
@@ -110,16 +204,78 @@ func (c *ICSCache) Set(hash string, content string) error {
 	// Add to cache
 	element := c.lruList.PushFront(hash)
 	c.entries[hash] = &cacheEntry{
-		hash:       hash,
-		filePath:   filePath,
-		lastUpdate: time.Now(),
-		element:    element,
+		hash:      hash,
+		filePath:  filePath,
+		expiresAt: expiresAt,
+		element:   element,
 	}
 
 	c.logger.Debug("Cache entry created", zap.String("hash", hash), zap.Int("cache_size", len(c.entries)))
 	return nil
 }
 
+// writeFile writes a cache file and records its expiry in the modification time. The content goes to
+// a temporary file renamed into place, so Get, which reads without holding the lock, never sees a
+// partly written feed.
+func (c *ICSCache) writeFile(filePath, content string, expiresAt time.Time) error {
+	file, err := os.CreateTemp(c.dir, icsCacheTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	written := expiresAt.Add(-c.lifetime)
+	if err == nil {
+		err = os.Chtimes(tempPath, written, written)
+	}
+	if err == nil {
+		err = os.Rename(tempPath, filePath)
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
+}
+
+// Delete removes an entry, if present.
+func (c *ICSCache) Delete(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[hash]
+	if !exists {
+		return
+	}
+	c.removeEntry(entry)
+}
+
+// RemoveExpired deletes the expired entries and returns how many there were.
+func (c *ICSCache) RemoveExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for _, entry := range c.entries {
+		if now.Before(entry.expiresAt) {
+			continue
+		}
+		c.removeEntry(entry)
+		removed++
+	}
+	return removed
+}
+
+// Len returns the number of cached feeds.
+func (c *ICSCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
 // evictLRU removes the least recently used entry.
 // Must be called with lock held.
 func (c *ICSCache) evictLRU() {
@@ -133,20 +289,21 @@ func (c *ICSCache) evictLRU() {
 		c.logger.Warn("Failed to get hash from LRU element")
 		return
 	}
-	entry := c.entries[hash]
-
-	// Remove file
-	if err := os.Remove(entry.filePath); err != nil {
-		c.logger.Warn("Failed to remove cache file", zap.Error(err), zap.String("hash", hash))
-	}
-
-	// Remove from map and list.
-	delete(c.entries, hash)
-	c.lruList.Remove(element)
+	c.removeEntry(c.entries[hash])
 
 	c.logger.Debug("Cache entry evicted", zap.String("hash", hash))
 }
 
+// removeEntry deletes an entry's file and forgets it.
+// Must be called with lock held.
+func (c *ICSCache) removeEntry(entry *cacheEntry) {
+	if err := os.Remove(entry.filePath); err != nil && !os.IsNotExist(err) {
+		c.logger.Warn("Failed to remove cache file", zap.Error(err), zap.String("hash", entry.hash))
+	}
+	delete(c.entries, entry.hash)
+	c.lruList.Remove(entry.element)
+}
+
 // Clear removes all cache entries.
 func (c *ICSCache) Clear() error {
 	c.mu.Lock()
@@ -181,11 +338,11 @@ func (c *ICSCache) Cleanup() error {
 	c.lruList = list.New()
 
 	// Remove the cache directory
-	if err := os.RemoveAll(cacheDir); err != nil {
-		c.logger.Error("Failed to remove cache directory", zap.Error(err), zap.String("cache_dir", cacheDir))
+	if err := os.RemoveAll(c.dir); err != nil {
+		c.logger.Error("Failed to remove cache directory", zap.Error(err), zap.String("cache_dir", c.dir))
 		return err
 	}
 
-	c.logger.Info("Cache cleaned up and directory removed", zap.String("cache_dir", cacheDir))
+	c.logger.Info("Cache cleaned up and directory removed", zap.String("cache_dir", c.dir))
 	return nil
 }
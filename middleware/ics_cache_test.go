@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestICSCache(t *testing.T, dir string) *ICSCache {
+	t.Helper()
+	cfg := DefaultConfig().Cache
+	cfg.ICSDir = dir
+	cache, err := NewICSCache(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+// TestICSCacheReadsWholeFeeds checks that a read racing with a rewrite of the same feed gets either
+// the old or the new content, never a truncated file.
+func TestICSCacheReadsWholeFeeds(t *testing.T) {
+	cache := newTestICSCache(t, t.TempDir())
+	feeds := []string{strings.Repeat("A", 1<<20), strings.Repeat("B", 1<<20)}
+	expiresAt := time.Now().Add(time.Hour)
+	if err := cache.Set("abc", feeds[0], expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := cache.Set("abc", feeds[i%2], expiresAt); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for range 200 {
+		content, _, ok := cache.Get("abc")
+		if !ok {
+			t.Fatal("cached feed was missing")
+		}
+		if content != feeds[0] && content != feeds[1] {
+			t.Fatalf("read a partly written feed of %d bytes", len(content))
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestICSCacheRehydrateSkipsTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	cache := newTestICSCache(t, dir)
+	if err := cache.Set("abc", "BEGIN:VCALENDAR", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	leftover := filepath.Join(dir, icsCacheTempPrefix+"123")
+	if err := os.WriteFile(leftover, []byte("BEGIN:VCAL"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rehydrated := newTestICSCache(t, dir)
+	if content, _, ok := rehydrated.Get("abc"); !ok || content != "BEGIN:VCALENDAR" {
+		t.Fatalf("got %q, %v after restart", content, ok)
+	}
+	if len(rehydrated.entries) != 1 {
+		t.Fatalf("rehydrated %d entries, want 1", len(rehydrated.entries))
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Fatalf("leftover temporary file was not removed: %v", err)
+	}
+}
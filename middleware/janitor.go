@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	databaseJanitorInterval = time.Hour
+	// revisionRetention outlasts syncTokenMaxAge by a day, covering clock skew between instances and the database.
+	revisionRetention = syncTokenMaxAge + 24*time.Hour
+)
+
+// RunDatabaseJanitor removes rows that are no longer needed until ctx is cancelled.
+// Every instance may run it; deleting rows twice is harmless.
+func (m *Middleware) RunDatabaseJanitor(ctx context.Context) {
+	ticker := time.NewTicker(databaseJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		m.pruneDatabase(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneDatabase removes expired preview selections, dispatched match changes and the match revisions
+// no valid sync token refers to.
+func (m *Middleware) pruneDatabase(ctx context.Context) {
+	var fields []zap.Field
+	for _, prune := range []struct {
+		table string
+		run   func(context.Context) (int64, error)
+	}{
+		{"preview_selections", m.DBConn.DeleteExpiredPreviewSelections},
+		{"match_changes", m.DBConn.DeleteDispatchedMatchChanges},
+		{"match_revisions", func(ctx context.Context) (int64, error) {
+			return m.DBConn.DeleteOldMatchRevisions(ctx, int32(revisionRetention/time.Hour))
+		}},
+	} {
+		removed, err := prune.run(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.Logger.Error("Failed to prune table", zap.Error(err), zap.String("table", prune.table))
+			continue
+		}
+		fields = append(fields, zap.Int64(prune.table, removed))
+	}
+	m.Logger.Info("Database pruned", fields...)
+}
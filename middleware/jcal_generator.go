@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"encoding/json"
+)
+
+// generateJCal serializes a calendar feed as jCal (RFC 7265).
+// A jCal component is a three element array: name, properties and sub-components.
+func generateJCal(feed calendarFeed) (string, error) {
+	events := make([]any, 0, len(feed.Events))
+	for _, event := range feed.Events {
+		events = append(events, []any{"vevent", jCalProperties(event.properties()), []any{}})
+	}
+
+	calendar := []any{"vcalendar", jCalProperties(feed.properties()), events}
+	data, err := json.Marshal(calendar)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// jCalProperties converts properties into jCal property arrays: [name, params, type, value].
+func jCalProperties(props []calendarProperty) []any {
+	result := make([]any, 0, len(props))
+	for _, prop := range props {
+		var value string
+		if prop.ValueType == valueTypeDateTime {
+			value = prop.Time.UTC().Format(structuredDateTimeLayout)
+		} else {
+			value = prop.Value
+		}
+		result = append(result, []any{prop.Name, map[string]any{}, prop.ValueType, value})
+	}
+	return result
+}
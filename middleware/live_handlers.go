@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/components"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LiveScoresHandler streams score and status updates of the started matches of the stored preview
+// selections named in its query as Server-Sent Events: GET /live?id=. Each event is named match-<id>
+// and carries the match's status badge, which the preview cards swap in through HTMX's SSE extension.
+func (m *Middleware) LiveScoresHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash, payload, ok := m.bindPreviewQuery(c)
+	if !ok {
+		return
+	}
+	sub, err := m.decodeSubscription(hash, payload)
+	if err != nil {
+		logger.Error("Failed to decode selections for live scores", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load selections")
+		return
+	}
+
+	updates, unsubscribe, ok := m.Live.subscribe(c.ClientIP())
+	if !ok {
+		c.String(http.StatusTooManyRequests, "Too many live score streams")
+		return
+	}
+	defer unsubscribe()
+
+	// Streams outlive the server's write timeout
+	if deadlineErr := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); deadlineErr != nil {
+		logger.Warn("Failed to lift write deadline of live score stream", zap.Error(deadlineErr))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	keepAlive := time.NewTicker(liveKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, writeErr := c.Writer.WriteString(": keep-alive\n\n"); writeErr != nil {
+				return
+			}
+			c.Writer.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !update.belongsTo(sub) {
+				continue
+			}
+			var badge strings.Builder
+			component := components.MatchStatusBadge(update.Finished, true, update.Team1Score, update.Team2Score,
+				sub.HideScores)
+			if renderErr := component.Render(ctx, &badge); renderErr != nil {
+				logger.Error("Failed to render live score", zap.Error(renderErr), zap.Int32("match_id", update.ID))
+				continue
+			}
+			c.SSEvent(fmt.Sprintf("match-%d", update.ID), badge.String())
+			c.Writer.Flush()
+		}
+	}
+}
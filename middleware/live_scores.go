@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const (
+	livePollInterval = 5 * time.Second
+	liveBatchSize    = 500
+	// liveLockTTL hands the publisher role to another instance once the holder stops renewing it.
+	liveLockTTL           = 3 * livePollInterval
+	liveKeepAliveInterval = 25 * time.Second
+	// liveClientBuffer is how many updates a slow stream may lag behind before updates are dropped for it.
+	liveClientBuffer = 32
+	liveOwnerBytes   = 16
+	// liveMaxStreamsPerIP caps the streams one client address may hold open on an instance.
+	liveMaxStreamsPerIP = 6
+
+	liveChannel     = "live:matches"
+	liveLockKey     = "live:publisher"
+	liveRevisionKey = "live:revision"
+)
+
+// liveUpdate is the score and status of a started match, published to every instance.
+type liveUpdate struct {
+	ID         int32 `json:"id"`
+	GameID     int32 `json:"game_id"`
+	LeagueID   int32 `json:"league_id"`
+	Team1ID    int32 `json:"team1_id"`
+	Team2ID    int32 `json:"team2_id"`
+	Tier       int32 `json:"tier"`
+	Finished   bool  `json:"finished"`
+	Team1Score int32 `json:"team1_score"`
+	Team2Score int32 `json:"team2_score"`
+}
+
+// belongsTo reports whether the updated match is part of a calendar subscription.
+func (u liveUpdate) belongsTo(sub calendarSubscription) bool {
+	return sub.includes(u.GameID, u.LeagueID, u.Team1ID, u.Team2ID, pgtype.Int4{Int32: u.Tier, Valid: true})
+}
+
+// LiveHub fans live score updates out to the SSE streams of this instance.
+type LiveHub struct {
+	mu      sync.Mutex
+	clients map[chan liveUpdate]struct{}
+	// streams counts the open streams per client IP.
+	streams map[string]int
+	closed  bool
+}
+
+// NewLiveHub creates a hub without streams.
+func NewLiveHub() *LiveHub {
+	return &LiveHub{
+		mu:      sync.Mutex{},
+		clients: make(map[chan liveUpdate]struct{}),
+		streams: make(map[string]int),
+		closed:  false,
+	}
+}
+
+// subscribe registers a stream of clientIP, unless the client already holds liveMaxStreamsPerIP streams.
+// The channel is closed when the hub shuts down; the returned function unregisters the stream.
+func (h *LiveHub) subscribe(clientIP string) (<-chan liveUpdate, func(), bool) {
+	updates := make(chan liveUpdate, liveClientBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[clientIP] >= liveMaxStreamsPerIP {
+		return nil, func() {}, false
+	}
+	h.streams[clientIP]++
+	release := func() {
+		if h.streams[clientIP]--; h.streams[clientIP] <= 0 {
+			delete(h.streams, clientIP)
+		}
+	}
+	if h.closed {
+		release()
+		close(updates)
+		return updates, func() {}, true
+	}
+	h.clients[updates] = struct{}{}
+	return updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[updates]; ok {
+			delete(h.clients, updates)
+			close(updates)
+		}
+		release()
+	}, true
+}
+
+// broadcast hands an update to every stream without blocking. Streams with a full
+// buffer miss it; their next update of the match carries the current score anyway.
+func (h *LiveHub) broadcast(update liveUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for updates := range h.clients {
+		select {
+		case updates <- update:
+		default:
+		}
+	}
+}
+
+// Close ends every stream, so open SSE responses finish and the server can shut down.
+func (h *LiveHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for updates := range h.clients {
+		delete(h.clients, updates)
+		close(updates)
+	}
+}
+
+// RunLiveScorePublisher publishes score and status changes of started matches until ctx is cancelled.
+// With Redis, one instance at a time holds the publisher lock and fans updates out over pub/sub;
+// without it, updates go straight to the streams of this instance.
+func (m *Middleware) RunLiveScorePublisher(ctx context.Context) {
+	owner := make([]byte, liveOwnerBytes)
+	if _, err := rand.Read(owner); err != nil {
+		m.Logger.Error("Live score publisher disabled, failed to generate owner token", zap.Error(err))
+		return
+	}
+	token := hex.EncodeToString(owner)
+	m.Logger.Info("Live score publisher started")
+	ticker := time.NewTicker(livePollInterval)
+	defer ticker.Stop()
+
+	// A negative cursor is loaded from Redis, or starts at the latest revision
+	since := int64(-1)
+	for {
+		next, err := m.publishLiveScores(ctx, token, since)
+		if err != nil && ctx.Err() == nil {
+			m.Logger.Error("Failed to publish live scores", zap.Error(err))
+		}
+		since = next
+
+		select {
+		case <-ctx.Done():
+			m.Logger.Info("Live score publisher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishLiveScores publishes the matches changed after revision since and returns the new cursor.
+func (m *Middleware) publishLiveScores(ctx context.Context, token string, since int64) (int64, error) {
+	if m.RedisCache != nil {
+		held, err := m.RedisCache.HoldLock(ctx, liveLockKey, token, liveLockTTL)
+		if err != nil {
+			return -1, fmt.Errorf("failed to take publisher lock: %w", err)
+		}
+		if !held {
+			// Another instance publishes; its cursor is picked up if the lock moves here
+			return -1, nil
+		}
+		if since < 0 {
+			if stored, ok := m.RedisCache.GetData(ctx, liveRevisionKey); ok {
+				since, _ = strconv.ParseInt(stored, 10, 64) // An unreadable cursor restarts below
+			}
+		}
+	}
+	if since <= 0 {
+		latest, err := m.DBConn.GetLatestMatchRevision(ctx)
+		if err != nil {
+			return -1, fmt.Errorf("failed to get latest match revision: %w", err)
+		}
+		since = latest
+	}
+
+	now := time.Now()
+	published := 0
+	for {
+		rows, err := m.DBConn.ListMatchUpdatesSince(ctx, dbtypes.ListMatchUpdatesSinceParams{
+			Since:      since,
+			LimitCount: liveBatchSize,
+		})
+		if err != nil {
+			return since, fmt.Errorf("failed to list match updates: %w", err)
+		}
+
+		var updates []liveUpdate
+		for _, row := range rows {
+			since = row.Revision
+			// Only started matches change score or status on the preview cards
+			if !row.ExpectedStartTime.Valid || row.ExpectedStartTime.Time.After(now) {
+				continue
+			}
+			updates = append(updates, liveUpdate{
+				ID:         row.ID,
+				GameID:     row.GameID,
+				LeagueID:   row.LeagueID,
+				Team1ID:    row.Team1ID,
+				Team2ID:    row.Team2ID,
+				Tier:       row.TournamentTier.Int32,
+				Finished:   row.Finished,
+				Team1Score: row.Team1Score,
+				Team2Score: row.Team2Score,
+			})
+		}
+		if err = m.sendLiveUpdates(ctx, updates); err != nil {
+			return since, err
+		}
+		published += len(updates)
+		if len(rows) < liveBatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if m.RedisCache != nil {
+		if err := m.RedisCache.SetData(ctx, liveRevisionKey, strconv.FormatInt(since, 10)); err != nil {
+			return since, fmt.Errorf("failed to store live score cursor: %w", err)
+		}
+	}
+	if published > 0 {
+		m.Logger.Debug("Live scores published", zap.Int("matches", published), zap.Int64("revision", since))
+	}
+	return since, nil
+}
+
+// sendLiveUpdates publishes a batch of updates over Redis, or broadcasts it locally without Redis.
+func (m *Middleware) sendLiveUpdates(ctx context.Context, updates []liveUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if m.RedisCache == nil {
+		for _, update := range updates {
+			m.Live.broadcast(update)
+		}
+		return nil
+	}
+	message, err := json.Marshal(updates)
+	if err != nil {
+		return fmt.Errorf("failed to encode live scores: %w", err)
+	}
+	if err = m.RedisCache.Publish(ctx, liveChannel, message); err != nil {
+		return fmt.Errorf("failed to publish live scores: %w", err)
+	}
+	return nil
+}
+
+// RunLiveScoreRelay forwards the live scores published over Redis to the streams of this instance
+// until ctx is cancelled. Without Redis the publisher broadcasts locally and no relay is needed.
+func (m *Middleware) RunLiveScoreRelay(ctx context.Context) {
+	if m.RedisCache == nil {
+		m.Logger.Info("Live score relay disabled, Redis is not configured")
+		return
+	}
+	pubsub := m.RedisCache.Subscribe(ctx, liveChannel)
+	defer func() {
+		_ = pubsub.Close() // The connection is dropped on shutdown either way
+	}()
+	m.Logger.Info("Live score relay started")
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			m.Logger.Info("Live score relay stopped")
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var updates []liveUpdate
+			if err := json.Unmarshal([]byte(message.Payload), &updates); err != nil {
+				m.Logger.Warn("Skipping undecodable live score message", zap.Error(err))
+				continue
+			}
+			for _, update := range updates {
+				m.Live.broadcast(update)
+			}
+		}
+	}
+}
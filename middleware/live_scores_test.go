@@ -0,0 +1,26 @@
+package middleware
+
+import "testing"
+
+func TestLiveHubCapsStreamsPerIP(t *testing.T) {
+	hub := NewLiveHub()
+	var unsubscribes []func()
+	for i := range liveMaxStreamsPerIP {
+		_, unsubscribe, ok := hub.subscribe("192.0.2.1")
+		if !ok {
+			t.Fatalf("stream %d was refused", i+1)
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+	if _, _, ok := hub.subscribe("192.0.2.1"); ok {
+		t.Fatal("stream over the cap was accepted")
+	}
+	if _, _, ok := hub.subscribe("192.0.2.2"); !ok {
+		t.Fatal("stream of another IP was refused")
+	}
+
+	unsubscribes[0]()
+	if _, _, ok := hub.subscribe("192.0.2.1"); !ok {
+		t.Fatal("stream was refused after one was closed")
+	}
+}
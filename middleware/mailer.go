@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"go.uber.org/zap"
+)
+
+const (
+	smtpTimeout    = 30 * time.Second
+	messageIDBytes = 16
+)
+
+// errMailerDisabled is returned by NewMailer when no SMTP server is configured.
+var errMailerDisabled = errors.New("SMTP_HOST not set")
+
+// Mailer sends multipart emails through an SMTP server.
+type Mailer struct {
+	addr   string
+	host   string
+	auth   smtp.Auth
+	from   *mail.Address
+	logger *zap.Logger
+}
+
+// emailMessage is an email with plain-text and HTML alternatives.
+type emailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Unsubscribe is a one-click unsubscribe URL (RFC 8058), empty for transactional mail.
+	Unsubscribe string
+}
+
+// NewMailer configures the SMTP server of email digests.
+func NewMailer(cfg SMTPConfig, logger *zap.Logger) (*Mailer, error) {
+	if cfg.Host == "" {
+		return nil, errMailerDisabled
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP sender: %w", err)
+	}
+
+	// Without credentials the server is expected to relay unauthenticated, like MailHog
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	logger.Info("SMTP mailer initialized",
+		zap.String("addr", addr),
+		zap.String("from", from.Address),
+		zap.Bool("auth", auth != nil))
+
+	return &Mailer{
+		addr:   addr,
+		host:   cfg.Host,
+		auth:   auth,
+		from:   from,
+		logger: logger,
+	}, nil
+}
+
+// Send delivers a message, upgrading to STARTTLS when the server offers it.
+func (ml *Mailer) Send(ctx context.Context, msg emailMessage) error {
+	body, err := ml.buildMessage(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout} //nolint:exhaustruct // Other dialer options use defaults
+	conn, err := dialer.DialContext(ctx, "tcp", ml.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if err = conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+	client, err := smtp.NewClient(conn, ml.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		//nolint:exhaustruct // Other TLS options use defaults
+		if err = client.StartTLS(&tls.Config{ServerName: ml.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if ml.auth != nil {
+		if err = client.Auth(ml.auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err = client.Mail(ml.from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM rejected: %w", err)
+	}
+	if err = client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("SMTP RCPT TO rejected: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA rejected: %w", err)
+	}
+	if _, err = writer.Write(body); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected email: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage encodes a message as multipart/alternative with quoted-printable parts.
+func (ml *Mailer) buildMessage(msg emailMessage, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, alternative := range [][2]string{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", alternative[0])
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		part, err := parts.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %w", err)
+		}
+		encoder := quotedprintable.NewWriter(part)
+		if _, err = encoder.Write([]byte(alternative[1])); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %w", err)
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish email: %w", err)
+	}
+
+	id := make([]byte, messageIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := ml.from.Address[strings.LastIndex(ml.from.Address, "@")+1:]
+
+	headers := [][2]string{
+		{"From", ml.from.String()},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{
+			"boundary": parts.Boundary(),
+		})},
+	}
+	if msg.Unsubscribe != "" {
+		headers = append(headers,
+			[2]string{"List-Unsubscribe", "<" + msg.Unsubscribe + ">"},
+			[2]string{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"})
+	}
+
+	var message bytes.Buffer
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// renderEmail renders the HTML and plain-text templates of an email. templ escapes
+// the text template like HTML, so its output is unescaped again.
+func renderEmail(ctx context.Context, htmlTemplate, textTemplate templ.Component) (string, string, error) {
+	var htmlBody, textBody strings.Builder
+	if err := htmlTemplate.Render(ctx, &htmlBody); err != nil {
+		return "", "", fmt.Errorf("failed to render HTML email: %w", err)
+	}
+	if err := textTemplate.Render(ctx, &textBody); err != nil {
+		return "", "", fmt.Errorf("failed to render text email: %w", err)
+	}
+	return htmlBody.String(), html.UnescapeString(textBody.String()), nil
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memoryEntry is a cached value and the time it leaves the cache.
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	element   *list.Element
+}
+
+// MemoryCache is a process-local Cache with Redis-like TTLs, evicting the least recently used
+// entry once maxSize entries are stored. It serves single-instance and Redis-less deployments.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	lruList *list.List
+	maxSize int
+	ttl     CacheTTLConfig
+	logger  *zap.Logger
+}
+
+// NewMemoryCache creates a memory cache holding up to maxSize entries.
+func NewMemoryCache(maxSize int, ttl CacheTTLConfig, logger *zap.Logger) *MemoryCache {
+	return &MemoryCache{
+		mu:      sync.Mutex{},
+		entries: make(map[string]*memoryEntry),
+		lruList: list.New(),
+		maxSize: maxSize,
+		ttl:     ttl,
+		logger:  logger,
+	}
+}
+
+// get returns an unexpired value and its expiry, marking it recently used.
+func (c *MemoryCache) get(key string) ([]byte, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return nil, time.Time{}, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		c.remove(entry)
+		return nil, time.Time{}, false
+	}
+	c.lruList.MoveToFront(entry.element)
+	return entry.value, entry.expiresAt, true
+}
+
+// set stores a value for ttl, evicting the least recently used entry if the cache is full.
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if entry, exists := c.entries[key]; exists {
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.lruList.MoveToFront(entry.element)
+		return
+	}
+
+	if c.lruList.Len() >= c.maxSize {
+		if oldest := c.lruList.Back(); oldest != nil {
+			if evicted, ok := oldest.Value.(*memoryEntry); ok {
+				c.remove(evicted)
+				c.logger.Debug("Memory cache entry evicted", zap.String("key", evicted.key))
+			}
+		}
+	}
+	entry := &memoryEntry{key: key, value: value, expiresAt: expiresAt, element: nil}
+	entry.element = c.lruList.PushFront(entry)
+	c.entries[key] = entry
+}
+
+// remove forgets an entry.
+// Must be called with lock held.
+func (c *MemoryCache) remove(entry *memoryEntry) {
+	delete(c.entries, entry.key)
+	c.lruList.Remove(entry.element)
+}
+
+// GetICS retrieves an ICS file from cache.
+func (c *MemoryCache) GetICS(_ context.Context, hash string) (string, bool) {
+	value, _, ok := c.get(icsPrefix + hash)
+	return string(value), ok
+}
+
+// GetICSWithExpiry retrieves an ICS file from cache together with the time its entry expires.
+func (c *MemoryCache) GetICSWithExpiry(_ context.Context, hash string) (string, time.Time, bool) {
+	value, expiresAt, ok := c.get(icsPrefix + hash)
+	return string(value), expiresAt, ok
+}
+
+// ICSExpiry returns when a cached ICS file expires.
+func (c *MemoryCache) ICSExpiry(_ context.Context, hash string) (time.Time, bool) {
+	_, expiresAt, ok := c.get(icsPrefix + hash)
+	return expiresAt, ok
+}
+
+// SetICS stores an ICS file in cache, fresh for the ICS TTL and stale for the ICS stale window after that.
+func (c *MemoryCache) SetICS(_ context.Context, hash string, content string) error {
+	c.set(icsPrefix+hash, []byte(content), c.ttl.icsLifetime())
+	return nil
+}
+
+// DeleteICS removes a specific ICS file from cache.
+func (c *MemoryCache) DeleteICS(_ context.Context, hash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, exists := c.entries[icsPrefix+hash]; exists {
+		c.remove(entry)
+	}
+	return nil
+}
+
+// GetData retrieves general data from cache.
+func (c *MemoryCache) GetData(_ context.Context, key string) (string, bool) {
+	value, _, ok := c.get(dataPrefix + key)
+	return string(value), ok
+}
+
+// SetData stores general data in cache with TTL.
+func (c *MemoryCache) SetData(_ context.Context, key string, value string) error {
+	c.set(dataPrefix+key, []byte(value), c.ttl.Data)
+	return nil
+}
+
+// GetBytes retrieves binary data from cache.
+func (c *MemoryCache) GetBytes(_ context.Context, key string) ([]byte, bool) {
+	value, _, ok := c.get(dataPrefix + key)
+	return value, ok
+}
+
+// GetBytesWithExpiry retrieves binary data from cache together with the time its entry expires.
+func (c *MemoryCache) GetBytesWithExpiry(_ context.Context, key string) ([]byte, time.Time, bool) {
+	return c.get(dataPrefix + key)
+}
+
+// SetBytes stores binary data in cache, fresh for the data TTL and stale for the data stale window after that.
+func (c *MemoryCache) SetBytes(_ context.Context, key string, value []byte) error {
+	c.set(dataPrefix+key, value, c.ttl.dataLifetime())
+	return nil
+}
+
+// DeleteData removes general or binary data from cache.
+func (c *MemoryCache) DeleteData(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, exists := c.entries[dataPrefix+key]; exists {
+		c.remove(entry)
+	}
+	return nil
+}
+
+// Clear removes all cache entries.
+func (c *MemoryCache) Clear(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*memoryEntry)
+	c.lruList = list.New()
+	c.logger.Info("Cache cleared")
+	return nil
+}
+
+// Close releases nothing; the entries are dropped with the process.
+func (c *MemoryCache) Close() error {
+	return nil
+}
+
+// GetCacheStats returns cache statistics, counting unexpired entries only.
+func (c *MemoryCache) GetCacheStats(context.Context) (map[string]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := map[string]int64{"ics_entries": 0, "data_entries": 0}
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(key, icsPrefix):
+			stats["ics_entries"]++
+		case strings.HasPrefix(key, dataPrefix):
+			stats["data_entries"]++
+		}
+	}
+	return stats, nil
+}
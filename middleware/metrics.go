@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const (
+	metricsNamespace = "esportscalendar"
+	// metricsCollectInterval is how often the URL mapping count and calendar poll rate are refreshed.
+	metricsCollectInterval = time.Minute
+	// metricsRouteKey holds the route label of requests that no registered route matched.
+	metricsRouteKey = "metrics_route"
+	unmatchedRoute  = "unmatched"
+	otherQuery      = "other"
+
+	// cacheFamilyAllGames is the games list of the index and selection pages. It is only counted;
+	// it expires instead of being invalidated.
+	cacheFamilyAllGames cacheFamily = "all-games"
+)
+
+// Metrics holds the Prometheus collectors of the server in a registry of its own.
+type Metrics struct {
+	registry        *prometheus.Registry
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	cacheLookups    *prometheus.CounterVec
+	dbDuration      *prometheus.HistogramVec
+	dbErrors        *prometheus.CounterVec
+	urlMappings     prometheus.Gauge
+	calendarPolls   prometheus.Counter
+	calendarPollHz  prometheus.Gauge
+	calendarPollSum atomic.Int64
+	rateLimits      *prometheus.CounterVec
+}
+
+// NewMetrics registers the server metrics together with the Go runtime and process collectors.
+func NewMetrics() *Metrics {
+	mt := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_lookups_total",
+			Help:      "Cache lookups by key family and result (hit, stale or miss).",
+		}, []string{"family", "result"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by sqlc query name.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"query"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed database queries by sqlc query name, not counting empty results.",
+		}, []string{"query"}),
+		urlMappings: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "url_mappings",
+			Help:      "Calendar links stored in URL_MAPPINGS.",
+		}),
+		calendarPolls: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "calendar_polls_total",
+			Help:      "Calendar feed requests.",
+		}),
+		calendarPollHz: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "calendar_poll_rate_per_second",
+			Help:      "Calendar feed requests per second over the last collection interval.",
+		}),
+		calendarPollSum: atomic.Int64{},
+		rateLimits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rate_limited_requests_total",
+			Help:      "Requests rejected by a rate limit, by limited route.",
+		}, []string{"route"}),
+	}
+	mt.registry.MustRegister(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewGoCollector(),
+		mt.httpRequests,
+		mt.httpDuration,
+		mt.cacheLookups,
+		mt.dbDuration,
+		mt.dbErrors,
+		mt.urlMappings,
+		mt.calendarPolls,
+		mt.calendarPollHz,
+		mt.rateLimits,
+	)
+	return mt
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (mt *Metrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(mt.registry, promhttp.HandlerOpts{}))
+}
+
+// Instrument counts requests and observes their latency, labelled by route pattern rather than path
+// so calendar hashes do not create a series each.
+func (mt *Metrics) Instrument(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := requestRoute(c)
+	method := c.Request.Method
+	mt.httpRequests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+	mt.httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+}
+
+// requestRoute returns the route pattern a request matched.
+func requestRoute(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	if route := c.GetString(metricsRouteKey); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// cacheLookup counts a cache lookup of a key family with its HIT, STALE or MISS status.
+func (mt *Metrics) cacheLookup(family cacheFamily, status string) {
+	mt.cacheLookups.WithLabelValues(string(family), strings.ToLower(status)).Inc()
+}
+
+// calendarPolled counts a calendar feed request.
+func (mt *Metrics) calendarPolled() {
+	mt.calendarPolls.Inc()
+	mt.calendarPollSum.Add(1)
+}
+
+// rateLimited counts a request rejected by the rate limit of route.
+func (mt *Metrics) rateLimited(route string) {
+	mt.rateLimits.WithLabelValues(route).Inc()
+}
+
+// RunMetricsCollector refreshes the gauges that need a query or a rate until ctx is cancelled.
+func (m *Middleware) RunMetricsCollector(ctx context.Context) {
+	ticker := time.NewTicker(metricsCollectInterval)
+	defer ticker.Stop()
+
+	lastPolls := m.Metrics.calendarPollSum.Load()
+	lastTick := time.Now()
+	for {
+		count, err := m.DBConn.CountURLMappings(ctx)
+		if err != nil {
+			m.Logger.Warn("Failed to count URL mappings", zap.Error(err))
+		} else {
+			m.Metrics.urlMappings.Set(float64(count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			polls := m.Metrics.calendarPollSum.Load()
+			m.Metrics.calendarPollHz.Set(float64(polls-lastPolls) / now.Sub(lastTick).Seconds())
+			lastPolls, lastTick = polls, now
+		}
+	}
+}
+
+// instrumentDB wraps a pool or transaction so every sqlc query is timed under its name and ends
+// after timeout at the latest.
+func (mt *Metrics) instrumentDB(db dbtypes.DBTX, timeout time.Duration) dbtypes.DBTX {
+	return instrumentedDB{db: db, metrics: mt, timeout: timeout}
+}
+
+// instrumentedDB is a dbtypes.DBTX that bounds queries by a deadline and records their durations
+// and errors.
+type instrumentedDB struct {
+	db      dbtypes.DBTX
+	metrics *Metrics
+	timeout time.Duration
+}
+
+func (d instrumentedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, observe := d.start(ctx, sql)
+	tag, err := d.db.Exec(ctx, sql, args...)
+	observe(err)
+	return tag, err
+}
+
+// Query is observed when its rows are closed, so the time spent reading them counts.
+func (d instrumentedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, observe := d.start(ctx, sql)
+	rows, err := d.db.Query(ctx, sql, args...)
+	if err != nil {
+		observe(err)
+		return rows, err
+	}
+	return &instrumentedRows{Rows: rows, observe: observe, once: sync.Once{}}, nil
+}
+
+// QueryRow is observed when its row is scanned.
+func (d instrumentedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, observe := d.start(ctx, sql)
+	return instrumentedRow{row: d.db.QueryRow(ctx, sql, args...), observe: observe}
+}
+
+// start sets the query deadline and starts timing. The returned function records the outcome
+// and releases the deadline, so it must be called once the query is done.
+func (d instrumentedDB) start(ctx context.Context, sql string) (context.Context, func(error)) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	observe := d.metrics.observeQuery(sql)
+	return ctx, func(err error) {
+		observe(err)
+		cancel()
+	}
+}
+
+// observeQuery starts timing a query and returns the function that records its outcome.
+func (mt *Metrics) observeQuery(sql string) func(error) {
+	name := queryName(sql)
+	start := time.Now()
+	return func(err error) {
+		mt.dbDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			mt.dbErrors.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// queryName reads the name from the "-- name: Query :kind" header sqlc puts in front of each query.
+func queryName(sql string) string {
+	header, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return otherQuery
+	}
+	name, _, _ := strings.Cut(header, " ")
+	return name
+}
+
+// instrumentedRows records its query once the rows are closed.
+type instrumentedRows struct {
+	pgx.Rows
+
+	observe func(error)
+	once    sync.Once
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(func() { r.observe(r.Rows.Err()) })
+}
+
+// instrumentedRow records its query once the row is scanned.
+type instrumentedRow struct {
+	row     pgx.Row
+	observe func(error)
+}
+
+func (r instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.observe(err)
+	return err
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// blockingDB is a dbtypes.DBTX whose queries wait for their context to end, like a query stuck
+// on a slow database. The error each query saw is sent to seen.
+type blockingDB struct {
+	started chan struct{}
+	seen    chan error
+}
+
+func newBlockingDB() *blockingDB {
+	return &blockingDB{started: make(chan struct{}, 1), seen: make(chan error, 1)}
+}
+
+func (db *blockingDB) wait(ctx context.Context) error {
+	db.started <- struct{}{}
+	<-ctx.Done()
+	db.seen <- ctx.Err()
+	return ctx.Err()
+}
+
+func (db *blockingDB) Exec(ctx context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, db.wait(ctx)
+}
+
+func (db *blockingDB) Query(ctx context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	return nil, db.wait(ctx)
+}
+
+func (db *blockingDB) QueryRow(ctx context.Context, _ string, _ ...any) pgx.Row {
+	return blockingRow{err: db.wait(ctx)}
+}
+
+type blockingRow struct {
+	err error
+}
+
+func (r blockingRow) Scan(...any) error {
+	return r.err
+}
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: GetAllGames :many\nSELECT 1": "GetAllGames",
+		"-- name: CountURLMappings :one":       "CountURLMappings",
+		"SELECT 1":                             otherQuery,
+	}
+	for sql, want := range tests {
+		if got := queryName(sql); got != want {
+			t.Errorf("queryName(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+// TestHandlerCancelsQueries checks that a handler's queries end when its request is cancelled.
+func TestHandlerCancelsQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newBlockingDB()
+	metrics := NewMetrics()
+	m := &Middleware{ //nolint:exhaustruct // The handler only uses the database and the logger
+		DBConn:  dbtypes.New(metrics.instrumentDB(db, time.Minute)),
+		Metrics: metrics,
+		Logger:  zap.NewNop(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/webhooks/abc", nil)
+	c.Request.Header.Set("Authorization", "Bearer owner")
+	c.Params = gin.Params{{Key: "hash", Value: "abc"}}
+
+	returned := make(chan struct{})
+	go func() {
+		m.ListWebhooksHandler(c)
+		close(returned)
+	}()
+
+	select {
+	case <-db.started:
+	case <-time.After(time.Second):
+		t.Fatal("handler did not query the database")
+	}
+	cancel()
+
+	select {
+	case err := <-db.seen:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("query saw %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("query was not cancelled")
+	}
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("handler did not return after its request was cancelled")
+	}
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("got status %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+}
@@ -2,8 +2,9 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/feimaomiao/esportscalendar/dbtypes"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -11,53 +12,111 @@ import (
 )
 
 type Middleware struct {
-	DB         *pgxpool.Pool
-	DBConn     *dbtypes.Queries
-	Context    context.Context
-	RedisCache *RedisCache
-	Logger     *zap.Logger
-	BaseURL    string
+	Config        Config
+	DB            *pgxpool.Pool
+	DBConn        *dbtypes.Queries
+	Cache         Cache
+	RedisCache    *RedisCache
+	Mailer        *Mailer
+	WebPush       *WebPush
+	Live          *LiveHub
+	Invalidation  *InvalidationBus
+	CalendarCache *CalendarCache
+	Coalescer     *RenderCoalescer
+	Readiness     *Readiness
+	RateLimiter   RateLimiter
+	Metrics       *Metrics
+	Tracing       *Tracing
+	Logger        *zap.Logger
+	BaseURL       string
+	StartedAt     time.Time
 }
 
-func InitMiddleHandler(logger *zap.Logger) Middleware {
+// InitMiddleHandler sets up the services of the server. The database pool connects lazily;
+// RunDatabaseMonitor reaches it and finishes the startup, so only invalid settings fail here.
+func InitMiddleHandler(cfg Config, logger *zap.Logger) (Middleware, error) {
 	logger.Info("Initializing middleware with database connection")
 	ctx := context.Background()
 
-	connStr := fmt.Sprintf("host=postgres port=5432 user=%s password=%s dbname=esports sslmode=disable",
-		os.Getenv("postgres_user"),
-		os.Getenv("postgres_password"))
-	conn, err := pgxpool.New(ctx, connStr)
+	// Tracing comes first so the database and Redis clients pick up its provider
+	tracing, err := NewTracing(ctx, cfg.Tracing, logger)
 	if err != nil {
-		panic(err)
+		return Middleware{}, err
 	}
 
-	dbConn := dbtypes.New(conn)
+	poolConfig, err := pgxpool.ParseConfig(cfg.Database.DSN())
+	if err != nil {
+		return Middleware{}, fmt.Errorf("invalid database settings: %w", err)
+	}
+	poolConfig.MaxConns = cfg.Database.MaxConns
+	poolConfig.MinConns = cfg.Database.MinConns
+	poolConfig.MaxConnLifetime = cfg.Database.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.Database.MaxConnIdleTime
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+	conn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return Middleware{}, fmt.Errorf("failed to create database pool: %w", err)
+	}
+
+	// Queries are timed per sqlc query name and bounded by the query timeout
+	metrics := NewMetrics()
+	dbConn := dbtypes.New(metrics.instrumentDB(conn, cfg.Database.QueryTimeout))
+
+	// Initialize the cache backend; redisCache is nil unless it is Redis
+	cache, redisCache := NewCache(ctx, cfg, logger)
+
+	// Initialize SMTP mailer for email digests; email features are disabled while it is nil
+	mailer, err := NewMailer(cfg.SMTP, logger)
+	if errors.Is(err, errMailerDisabled) {
+		logger.Info("SMTP_HOST not set, email digests disabled")
+	} else if err != nil {
+		logger.Error("Failed to initialize SMTP mailer, email digests disabled", zap.Error(err))
+	}
 
-	// Initialize Redis cache
-	redisCache, err := NewRedisCache(ctx, logger)
+	// Cache invalidations reach other instances over Redis pub/sub when Redis is available
+	invalidation, err := NewInvalidationBus(redisCache, logger)
 	if err != nil {
-		logger.Error("Failed to initialize Redis cache, falling back to no cache", zap.Error(err))
-		// Continue without cache - app will still work but slower
-		redisCache = nil
+		return Middleware{}, err
 	}
+	dropOptionsOnInvalidate(invalidation, cache, logger)
 
-	// Get base URL from environment variable with default fallback
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://esportscalendar.app"
-		logger.Info("BASE_URL not set, using default", zap.String("base_url", baseURL))
-	} else {
-		logger.Info("Using BASE_URL from environment", zap.String("base_url", baseURL))
+	// Rendered calendars are cached on local disk in front of Redis
+	icsCache, err := NewICSCache(cfg.Cache, logger)
+	if err != nil {
+		logger.Error("Failed to initialize ICS file cache, caching calendars in Redis only", zap.Error(err))
+		icsCache = nil
 	}
+	calendarCache := NewCalendarCache(icsCache, cache, cfg.Cache.TTL, invalidation, logger)
 
-	return Middleware{
-		DB:         conn,
-		DBConn:     dbConn,
-		Context:    ctx,
-		RedisCache: redisCache,
-		Logger:     logger,
-		BaseURL:    baseURL,
+	// Cache misses are rendered once per key, across instances while Redis is available
+	coalescer, err := NewRenderCoalescer(redisCache, logger)
+	if err != nil {
+		return Middleware{}, err
 	}
+
+	readiness := newReadiness()
+	readiness.redis.Store(redisCache != nil)
+
+	return Middleware{
+		Config:        cfg,
+		DB:            conn,
+		DBConn:        dbConn,
+		Cache:         cache,
+		RedisCache:    redisCache,
+		Mailer:        mailer,
+		WebPush:       nil, // Set by RunDatabaseMonitor once the database is reachable
+		Live:          NewLiveHub(),
+		Invalidation:  invalidation,
+		CalendarCache: calendarCache,
+		Coalescer:     coalescer,
+		Readiness:     readiness,
+		RateLimiter:   newRateLimiter(redisCache),
+		Metrics:       metrics,
+		Tracing:       tracing,
+		Logger:        logger,
+		BaseURL:       cfg.BaseURL,
+		StartedAt:     time.Now(),
+	}, nil
 }
 
 // Cleanup performs cleanup operations on shutdown.
@@ -70,11 +129,9 @@ func (m *Middleware) Cleanup() {
 		m.Logger.Info("Database connection closed")
 	}
 
-	// Close Redis connection
-	if m.RedisCache != nil {
-		if err := m.RedisCache.Close(); err != nil {
-			m.Logger.Error("Failed to close Redis cache", zap.Error(err))
-		}
+	// Close the cache backend
+	if err := m.Cache.Close(); err != nil {
+		m.Logger.Error("Failed to close cache", zap.Error(err))
 	}
 
 	m.Logger.Info("Cleanup complete")
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var errForbiddenAddress = errors.New("address is not publicly routable")
+
+// newOutboundClient returns the client for requests to user-supplied URLs, such as webhook receivers
+// and push services. Its dialer refuses loopback, private, link-local, reserved and unspecified addresses after
+// DNS resolution, so a host that re-resolves to an internal address is refused too, unless allowPrivate
+// is set for local development. Redirects are not followed and no proxy is used.
+func newOutboundClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout} //nolint:exhaustruct // Other dialer options use defaults
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addr) {
+				return fmt.Errorf("%w: %s", errForbiddenAddress, addr)
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicAddr reports whether addr may be the target of an outbound request.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!addr.IsUnspecified() && !isReservedAddr(addr)
+}
+
+// isReservedAddr reports whether addr is in one of the non-public IPv4 ranges netip.Addr has no predicate for:
+// "this network" (0.0.0.0/8), carrier-grade NAT (100.64.0.0/10) and benchmarking (198.18.0.0/15).
+func isReservedAddr(addr netip.Addr) bool {
+	for _, prefix := range []string{"0.0.0.0/8", "100.64.0.0/10", "198.18.0.0/15"} {
+		if netip.MustParsePrefix(prefix).Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOutboundURL accepts absolute http(s) URLs. Hosts that are IP literals or localhost must be
+// public unless allowPrivate is set; names resolving to internal addresses are refused when dialing.
+func validateOutboundURL(raw string, allowPrivate bool) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("URL must use http or https")
+	}
+	host := parsed.Hostname()
+	if host == "" {
+		return errors.New("URL must include a host")
+	}
+	if allowPrivate {
+		return nil
+	}
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" ||
+		strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, host)
+	}
+	if addr, parseErr := netip.ParseAddr(host); parseErr == nil && !isPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, addr)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateOutboundURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"https://discord.com/api/webhooks/1/abc", false, false},
+		{"http://example.com/hook", false, false},
+		{"ftp://example.com/hook", false, true},
+		{"https:///hook", false, true},
+		{"http://localhost:8080/hook", false, true},
+		{"http://api.localhost./hook", false, true},
+		{"http://127.0.0.1/hook", false, true},
+		{"http://10.1.2.3/hook", false, true},
+		{"http://169.254.169.254/latest/meta-data", false, true},
+		{"http://[::1]/hook", false, true},
+		{"http://[::ffff:192.168.0.1]/hook", false, true},
+		{"http://0.0.0.0/hook", false, true},
+		{"http://0.1.2.3/hook", false, true},
+		{"http://100.64.0.1/hook", false, true},
+		{"http://100.127.255.254/hook", false, true},
+		{"http://100.128.0.1/hook", false, false},
+		{"http://198.18.0.1/hook", false, true},
+		{"http://198.19.255.254/hook", false, true},
+		{"http://[::ffff:100.64.0.1]/hook", false, true},
+		{"http://localhost:8080/hook", true, false},
+		{"http://10.1.2.3/hook", true, false},
+	}
+	for _, tt := range tests {
+		err := validateOutboundURL(tt.url, tt.allowPrivate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateOutboundURL(%q, %v) = %v, want error %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOutboundClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := newOutboundClient(time.Second, false).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback server succeeded")
+	}
+	if !errors.Is(err, errForbiddenAddress) {
+		t.Fatalf("got %v, want %v", err, errForbiddenAddress)
+	}
+
+	resp, err = newOutboundClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with private receivers allowed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
+
+func TestOutboundClientDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			t.Errorf("redirect to %s was followed", r.URL.Path)
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	resp, err := newOutboundClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+}
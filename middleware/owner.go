@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ownerKeyBytes is the size of the random key that manages a calendar's notifications.
+	ownerKeyBytes = 32
+	// ownerCookie keeps a browser's owner key for the settings page and the push API.
+	ownerCookie       = "owner_key"
+	ownerCookieMaxAge = 400 * 24 * 60 * 60 // The longest lifetime browsers accept, in seconds
+	ownerContextKey   = "owner_key"
+)
+
+// newOwnerKey returns a random owner key. A calendar hash only names a selection and is shared by
+// everyone who picks it, so notifications are managed with the key of whoever created them.
+func newOwnerKey() string {
+	key := make([]byte, ownerKeyBytes)
+	_, _ = rand.Read(key) // Never fails, see crypto/rand.Read
+	return hex.EncodeToString(key)
+}
+
+// hashOwnerKey returns the form an owner key is stored and looked up in.
+func hashOwnerKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestOwnerKey returns the owner key sent as a bearer token, or else kept in the owner cookie.
+func requestOwnerKey(c *gin.Context) (string, bool) {
+	if key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && key != "" {
+		return key, true
+	}
+	if key, err := c.Cookie(ownerCookie); err == nil && len(key) == 2*ownerKeyBytes {
+		return key, true
+	}
+	return "", false
+}
+
+// browserOwnerKey returns the stored form of the request's owner key. Browsers without one get a new
+// key in the owner cookie, which is only sent on same-site requests so other sites cannot use it.
+func (m *Middleware) browserOwnerKey(c *gin.Context) string {
+	if key := c.GetString(ownerContextKey); key != "" {
+		return hashOwnerKey(key)
+	}
+	key, ok := requestOwnerKey(c)
+	if !ok {
+		key = newOwnerKey()
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(ownerCookie, key, ownerCookieMaxAge, "/", "", strings.HasPrefix(m.BaseURL, "https://"), true)
+	}
+	c.Set(ownerContextKey, key)
+	return hashOwnerKey(key)
+}
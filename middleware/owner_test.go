@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOwnerKeys(t *testing.T) {
+	first, second := newOwnerKey(), newOwnerKey()
+	if first == second || len(first) != 2*ownerKeyBytes {
+		t.Fatalf("owner keys %q and %q", first, second)
+	}
+	if hashOwnerKey(first) == first || hashOwnerKey(first) != hashOwnerKey(first) {
+		t.Fatal("owner keys are not stored hashed")
+	}
+}
+
+func TestBrowserOwnerKey(t *testing.T) {
+	m := &Middleware{BaseURL: "https://esportscalendar.app"} //nolint:exhaustruct // Only the base URL is used
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/settings/abc", nil)
+	stored := m.browserOwnerKey(c)
+	if again := m.browserOwnerKey(c); again != stored {
+		t.Fatal("a request got two owner keys")
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != ownerCookie {
+		t.Fatalf("got cookies %v, want one %s cookie", cookies, ownerCookie)
+	}
+	cookie := cookies[0]
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie %v is not HttpOnly, Secure and SameSite=Lax", cookie)
+	}
+	if hashOwnerKey(cookie.Value) != stored {
+		t.Fatal("the cookie does not hold the owner key")
+	}
+
+	// A returning browser keeps its key
+	recorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/settings/abc", nil)
+	c.Request.AddCookie(&http.Cookie{Name: ownerCookie, Value: cookie.Value}) //nolint:exhaustruct // Request cookie
+	if m.browserOwnerKey(c) != stored {
+		t.Fatal("the owner cookie was not used")
+	}
+	if len(recorder.Result().Cookies()) != 0 {
+		t.Fatal("the owner cookie was replaced")
+	}
+}
@@ -1,64 +1,68 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"html"
 	"net/http"
 	"strconv"
-	"time"
 
+	"github.com/a-h/templ"
 	"github.com/feimaomiao/esportscalendar/components"
 	"github.com/feimaomiao/esportscalendar/dbtypes"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
-func (m *Middleware) IndexHandler(c *gin.Context) {
-	m.Logger.Info("IndexHandler", zap.String("method", c.Request.Method), zap.String("path", c.Request.URL.Path))
+// previewMatchLimit is the number of matches shown on the preview page.
+const previewMatchLimit = 10
 
+func (m *Middleware) IndexHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
 	var games []dbtypes.Game
 	var cacheHit bool
 
 	// Check cache first
 	cacheKey := "all-games"
-	if m.RedisCache != nil {
-		if cachedJSON, ok := m.RedisCache.GetData(cacheKey); ok {
-			//nolint:musttag // dbtypes.Game has json tags defined
-			if err := json.Unmarshal([]byte(cachedJSON), &games); err == nil {
-				m.Logger.Info("Cache HIT",
-					zap.String("handler", "IndexHandler"),
-					zap.String("cache_key", cacheKey),
-					zap.Int("num_games", len(games)))
-				cacheHit = true
-			} else {
-				m.Logger.Warn("Failed to unmarshal cached games", zap.Error(err))
-			}
+	if cachedJSON, ok := m.Cache.GetData(c.Request.Context(), cacheKey); ok {
+		//nolint:musttag // dbtypes.Game has json tags defined
+		if err := json.Unmarshal([]byte(cachedJSON), &games); err == nil {
+			m.recordCacheLookup(c, cacheFamilyAllGames, cacheStatusHit)
+			logger.Info("Cache HIT",
+				zap.String("handler", "IndexHandler"),
+				zap.String("cache_key", cacheKey),
+				zap.Int("num_games", len(games)))
+			cacheHit = true
+		} else {
+			logger.Warn("Failed to unmarshal cached games", zap.Error(err))
 		}
 	}
 
 	// If not in cache, fetch from database
 	//nolint:nestif // Nested structure is readable and necessary for cache-then-db pattern
 	if games == nil {
-		m.Logger.Info("Cache MISS",
+		m.recordCacheLookup(c, cacheFamilyAllGames, cacheStatusMiss)
+		logger.Info("Cache MISS",
 			zap.String("handler", "IndexHandler"),
 			zap.String("cache_key", cacheKey))
 		var err error
-		games, err = m.DBConn.GetAllGames(m.Context)
+		games, err = m.DBConn.GetAllGames(c.Request.Context())
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Failed to fetch games")
 			return
 		}
 		// Cache the games list
-		if m.RedisCache != nil {
-			//nolint:musttag // dbtypes.Game has json tags defined
-			if gamesJSON, marshalErr := json.Marshal(games); marshalErr == nil {
-				if cacheErr := m.RedisCache.SetData(cacheKey, string(gamesJSON)); cacheErr != nil {
-					m.Logger.Warn("Failed to cache games", zap.Error(cacheErr))
-				} else {
-					m.Logger.Info("Data cached",
-						zap.String("handler", "IndexHandler"),
-						zap.String("cache_key", cacheKey),
-						zap.Int("num_games", len(games)))
-				}
+		//nolint:musttag // dbtypes.Game has json tags defined
+		if gamesJSON, marshalErr := json.Marshal(games); marshalErr == nil {
+			if cacheErr := m.Cache.SetData(c.Request.Context(), cacheKey, string(gamesJSON)); cacheErr != nil {
+				logger.Warn("Failed to cache games", zap.Error(cacheErr))
+			} else {
+				logger.Info("Data cached",
+					zap.String("handler", "IndexHandler"),
+					zap.String("cache_key", cacheKey),
+					zap.Int("num_games", len(games)))
 			}
 		}
 	}
@@ -82,8 +86,7 @@ func (m *Middleware) IndexHandler(c *gin.Context) {
 		})
 	}
 
-	// Set HTTP cache headers (cache for 5 minutes)
-	c.Header("Cache-Control", "public, max-age=300")
+	c.Header("Cache-Control", pageCacheControl)
 	if cacheHit {
 		c.Header("X-Cache", "HIT")
 	} else {
@@ -91,65 +94,51 @@ func (m *Middleware) IndexHandler(c *gin.Context) {
 	}
 
 	component := components.Index(options)
-	if err := component.Render(m.Context, c.Writer); err != nil {
-		m.Logger.Error("Failed to render index", zap.Error(err))
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		logger.Error("Failed to render index", zap.Error(err))
 		c.String(http.StatusInternalServerError, "Failed to render page")
 	}
 }
 
 func (m *Middleware) HowToUseHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "HowToUseHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
-
+	logger := m.requestLogger(c.Request.Context())
+	c.Header("Cache-Control", pageCacheControl)
 	component := components.HowToUsePage()
-	if err := component.Render(m.Context, c.Writer); err != nil {
-		m.Logger.Error("Failed to render how-to-use page", zap.Error(err))
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		logger.Error("Failed to render how-to-use page", zap.Error(err))
 		c.String(http.StatusInternalServerError, "Failed to render page")
 	}
 }
 
 func (m *Middleware) AboutHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "AboutHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
-
+	logger := m.requestLogger(c.Request.Context())
+	c.Header("Cache-Control", pageCacheControl)
 	component := components.AboutPage()
-	if err := component.Render(m.Context, c.Writer); err != nil {
-		m.Logger.Error("Failed to render about page", zap.Error(err))
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		logger.Error("Failed to render about page", zap.Error(err))
 		c.String(http.StatusInternalServerError, "Failed to render page")
 	}
 }
 
+// renderLoadingPage reopens /lts with the games remembered in sessionStorage, or the index without any.
 func (m *Middleware) renderLoadingPage(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	c.Header("Cache-Control", pageCacheControl)
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	if _, err := c.Writer.Write([]byte(`<!DOCTYPE html>
 <html>
 <head>
 	<title>Loading - EsportsCalendar</title>
-	<script>
+	<script nonce="` + html.EscapeString(templ.GetNonce(c.Request.Context())) + `">
 		const savedGameOptions = sessionStorage.getItem('selectedGameOptions');
-		if (savedGameOptions) {
-			const gameIds = JSON.parse(savedGameOptions);
-			fetch('/lts', {
-				method: 'POST',
-				headers: {
-					'Content-Type': 'application/json'
-				},
-				body: JSON.stringify({ options: gameIds })
-			}).then(response => response.text())
-			  .then(html => {
-				document.open();
-				document.write(html);
-				document.close();
-				// Update title after document is rewritten
-				document.title = 'Leagues & Teams - EsportsCalendar';
-			  });
+		const gameIds = savedGameOptions ? JSON.parse(savedGameOptions) : [];
+		if (gameIds.length > 0) {
+			const params = new URLSearchParams();
+			gameIds.forEach(id => params.append('options', id));
+			window.location.replace('/lts?' + params);
 		} else {
 			// No saved selections, redirect to home
-			window.location.href = '/';
+			window.location.replace('/');
 		}
 	</script>
 </head>
@@ -157,23 +146,19 @@ func (m *Middleware) renderLoadingPage(c *gin.Context) {
 	<p>Loading...</p>
 </body>
 </html>`)); err != nil {
-		m.Logger.Error("Failed to write response", zap.Error(err))
+		logger.Error("Failed to write response", zap.Error(err))
 	}
 }
 
 //nolint:gocognit // Handler complexity is acceptable for this use case
 func (m *Middleware) SecondPageHandler(c *gin.Context) {
-	m.Logger.Info("Handler",
-		zap.String("handler", "SecondPageHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path))
-
+	logger := m.requestLogger(c.Request.Context())
 	var selectedOptionIDs []string
 
 	// Parse form data first (HTMX default)
 	if err := c.Request.ParseForm(); err == nil && len(c.Request.Form["options"]) > 0 {
 		selectedOptionIDs = c.Request.Form["options"]
-		m.Logger.Debug("Parsed options from form", zap.Strings("options", selectedOptionIDs))
+		logger.Debug("Parsed options from form", zap.Strings("options", selectedOptionIDs))
 	} else if c.Request.Header.Get("Content-Type") == "application/json" {
 		// Fallback to JSON for backward compatibility
 		var requestBody struct {
@@ -181,9 +166,9 @@ func (m *Middleware) SecondPageHandler(c *gin.Context) {
 		}
 		if jsonErr := c.ShouldBindJSON(&requestBody); jsonErr == nil {
 			selectedOptionIDs = requestBody.Options
-			m.Logger.Debug("Parsed options from JSON body", zap.Strings("options", selectedOptionIDs))
+			logger.Debug("Parsed options from JSON body", zap.Strings("options", selectedOptionIDs))
 		} else {
-			m.Logger.Error("Failed to parse JSON body", zap.Error(jsonErr))
+			logger.Error("Failed to parse JSON body", zap.Error(jsonErr))
 		}
 	}
 
@@ -197,43 +182,41 @@ func (m *Middleware) SecondPageHandler(c *gin.Context) {
 	var games []dbtypes.Game
 	var cacheHit bool
 	cacheKey := "all-games"
-	if m.RedisCache != nil {
-		if cachedJSON, ok := m.RedisCache.GetData(cacheKey); ok {
-			//nolint:musttag // dbtypes.Game has json tags defined
-			if err := json.Unmarshal([]byte(cachedJSON), &games); err == nil {
-				m.Logger.Info("Cache HIT",
-					zap.String("handler", "SecondPageHandler"),
-					zap.String("cache_key", cacheKey),
-					zap.Int("num_games", len(games)))
-				cacheHit = true
-			} else {
-				m.Logger.Warn("Failed to unmarshal cached games", zap.Error(err))
-			}
+	if cachedJSON, ok := m.Cache.GetData(c.Request.Context(), cacheKey); ok {
+		//nolint:musttag // dbtypes.Game has json tags defined
+		if err := json.Unmarshal([]byte(cachedJSON), &games); err == nil {
+			m.recordCacheLookup(c, cacheFamilyAllGames, cacheStatusHit)
+			logger.Info("Cache HIT",
+				zap.String("handler", "SecondPageHandler"),
+				zap.String("cache_key", cacheKey),
+				zap.Int("num_games", len(games)))
+			cacheHit = true
+		} else {
+			logger.Warn("Failed to unmarshal cached games", zap.Error(err))
 		}
 	}
 
 	//nolint:nestif // Nested structure is readable and necessary for cache-then-db pattern
 	if games == nil {
-		m.Logger.Info("Cache MISS",
+		m.recordCacheLookup(c, cacheFamilyAllGames, cacheStatusMiss)
+		logger.Info("Cache MISS",
 			zap.String("handler", "SecondPageHandler"),
 			zap.String("cache_key", cacheKey))
 		var err error
-		games, err = m.DBConn.GetAllGames(m.Context)
+		games, err = m.DBConn.GetAllGames(c.Request.Context())
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Failed to fetch games")
 			return
 		}
-		if m.RedisCache != nil {
-			//nolint:musttag // dbtypes.Game has json tags defined
-			if gamesJSON, marshalErr := json.Marshal(games); marshalErr == nil {
-				if cacheErr := m.RedisCache.SetData(cacheKey, string(gamesJSON)); cacheErr != nil {
-					m.Logger.Warn("Failed to cache games", zap.Error(cacheErr))
-				} else {
-					m.Logger.Info("Data cached",
-						zap.String("handler", "SecondPageHandler"),
-						zap.String("cache_key", cacheKey),
-						zap.Int("num_games", len(games)))
-				}
+		//nolint:musttag // dbtypes.Game has json tags defined
+		if gamesJSON, marshalErr := json.Marshal(games); marshalErr == nil {
+			if cacheErr := m.Cache.SetData(c.Request.Context(), cacheKey, string(gamesJSON)); cacheErr != nil {
+				logger.Warn("Failed to cache games", zap.Error(cacheErr))
+			} else {
+				logger.Info("Data cached",
+					zap.String("handler", "SecondPageHandler"),
+					zap.String("cache_key", cacheKey),
+					zap.Int("num_games", len(games)))
 			}
 		}
 	}
@@ -258,8 +241,7 @@ func (m *Middleware) SecondPageHandler(c *gin.Context) {
 		}
 	}
 
-	// Set HTTP cache headers (cache for 5 minutes)
-	c.Header("Cache-Control", "public, max-age=300")
+	c.Header("Cache-Control", pageCacheControl)
 	if cacheHit {
 		c.Header("X-Cache", "HIT")
 	} else {
@@ -269,8 +251,8 @@ func (m *Middleware) SecondPageHandler(c *gin.Context) {
 	// For HTMX partial updates
 	if c.Request.Header.Get("Hx-Request") == "true" {
 		component := components.SecondPageContent(selectedOptions)
-		if err := component.Render(m.Context, c.Writer); err != nil {
-			m.Logger.Error("Failed to render second page content", zap.Error(err))
+		if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+			logger.Error("Failed to render second page content", zap.Error(err))
 			c.String(http.StatusInternalServerError, "Failed to render page")
 		}
 		return
@@ -278,60 +260,47 @@ func (m *Middleware) SecondPageHandler(c *gin.Context) {
 
 	// Full page load
 	component := components.SecondPage(selectedOptions)
-	if err := component.Render(m.Context, c.Writer); err != nil {
-		m.Logger.Error("Failed to render second page", zap.Error(err))
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		logger.Error("Failed to render second page", zap.Error(err))
 		c.String(http.StatusInternalServerError, "Failed to render page")
 	}
 }
 
+// PreviewHandler validates selections and answers with the URL of their preview page: POST /preview.
+// The selections are kept for previewTTL under their hash, which the URL carries. The browser navigates
+// there rather than writing the response into the current document, so the page gets its own CSP nonce.
 func (m *Middleware) PreviewHandler(c *gin.Context) {
-	// Generate unique request ID for debugging
-	requestID := c.GetHeader("X-Request-ID")
-	if requestID == "" {
-		requestID = c.RemoteIP() + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	payload, ok := m.bindSelectionPayload(c)
+	if !ok {
+		return
 	}
-
-	m.Logger.Info("Handler",
-		zap.String("handler", "PreviewHandler"),
-		zap.String("method", c.Request.Method),
-		zap.String("path", c.Request.URL.Path),
-		zap.String("request_id", requestID))
-
-	// Parse JSON body with selections and hideScores
-	var requestBody map[string]any
-	if c.Request.Header.Get("Content-Type") == "application/json" {
-		if err := c.ShouldBindJSON(&requestBody); err != nil {
-			m.Logger.Error("Failed to parse JSON body",
-				zap.String("request_id", requestID),
-				zap.Error(err))
-			c.String(http.StatusBadRequest, "Invalid request body")
-			return
-		}
-		m.Logger.Debug("Received request body",
-			zap.String("request_id", requestID),
-			zap.Any("request_body", requestBody))
+	hash, err := m.storePreviewSelection(c.Request.Context(), payload)
+	if err != nil {
+		m.requestLogger(c.Request.Context()).Error("Failed to store preview", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, map[string]any{"error": "Failed to store selections"})
+		return
 	}
+	c.JSON(http.StatusOK, map[string]string{"url": "/preview?" + previewQuery(hash)})
+}
 
-	// Extract hideScores flag (default to false)
-	hideScores := false
-	if hideScoresVal, ok := requestBody["hideScores"].(bool); ok {
-		hideScores = hideScoresVal
+// PreviewPageHandler renders the matches of the stored selections named in its query: GET /preview?id=.
+func (m *Middleware) PreviewPageHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash, payload, ok := m.bindPreviewQuery(c)
+	if !ok {
+		return
 	}
-
-	// Extract selections (handle both old and new format)
-	var selections map[string]any
-	if selectionsVal, ok := requestBody["selections"].(map[string]any); ok {
-		// New format with selections wrapper
-		selections = selectionsVal
-	} else {
-		// Old format without wrapper
-		selections = requestBody
+	setSpanAttributes(c, attrCalendarHash.String(hash))
+	subscription, err := m.decodeSubscription(hash, payload)
+	if err != nil {
+		logger.Error("Failed to decode selections", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load selections")
+		return
 	}
-
-	// Extract game IDs, league IDs, team IDs, and max tier from selections
-	gameIDs, leagueIDs, teamIDs, maxTier := parseSelections(selections, m.Logger)
-	m.Logger.Info("Preview request parsed",
-		zap.String("request_id", requestID),
+	gameIDs, leagueIDs, teamIDs := subscription.GameIDs, subscription.LeagueIDs, subscription.TeamIDs
+	maxTier, hideScores := subscription.MaxTier, subscription.HideScores
+	setSpanAttributes(c, gameIDsAttribute(gameIDs))
+	logger.Info("Preview request parsed",
 		zap.Int("num_games", len(gameIDs)),
 		zap.Int("num_leagues", len(leagueIDs)),
 		zap.Int("num_teams", len(teamIDs)),
@@ -342,51 +311,43 @@ func (m *Middleware) PreviewHandler(c *gin.Context) {
 		zap.Any("team_ids", teamIDs))
 
 	// Fetch matches from database - show up to 5 past and 5 future
-	startTime := time.Now()
-	matches, showingPast, err := m.fetchMatches(gameIDs, leagueIDs, teamIDs, maxTier)
-	fetchDuration := time.Since(startTime)
+	tracer := otel.Tracer(tracerName)
+	fetchCtx, fetchSpan := tracer.Start(c.Request.Context(), "preview.fetch_matches")
+	matches, showingPast, err := m.fetchMatches(fetchCtx, gameIDs, leagueIDs, teamIDs, maxTier, previewMatchLimit)
+	fetchSpan.SetAttributes(
+		attribute.Int("match.count", len(matches)),
+		attribute.Bool("match.showing_past", showingPast))
+	fetchSpan.End()
 
 	if err != nil {
-		m.Logger.Error("Failed to fetch matches",
-			zap.String("request_id", requestID),
-			zap.Error(err))
+		logger.Error("Failed to fetch matches", zap.Error(err))
 		c.String(http.StatusInternalServerError, "Failed to fetch matches")
 		return
 	}
 
-	m.Logger.Info("Preview matches fetched",
-		zap.String("request_id", requestID),
+	logger.Info("Preview matches fetched",
 		zap.Int("match_count", len(matches)),
-		zap.Bool("showing_past", showingPast),
-		zap.Duration("fetch_duration", fetchDuration))
-
-	// Render the preview page with matches
-	renderStart := time.Now()
-	component := components.PreviewPage(matches, showingPast, hideScores)
-	if renderErr := component.Render(m.Context, c.Writer); renderErr != nil {
-		m.Logger.Error("Failed to render preview page",
-			zap.String("request_id", requestID),
-			zap.Error(renderErr))
+		zap.Bool("showing_past", showingPast))
+
+	// Render the preview page with matches, streaming live scores of the same selections
+	renderCtx, renderSpan := tracer.Start(c.Request.Context(), "preview.render")
+	defer renderSpan.End()
+	c.Header("Cache-Control", pageCacheControl)
+	component := components.PreviewPage(matches, showingPast, hideScores, "/live?"+previewQuery(hash))
+	if renderErr := component.Render(renderCtx, c.Writer); renderErr != nil {
+		logger.Error("Failed to render preview page", zap.Error(renderErr))
 		c.String(http.StatusInternalServerError, "Failed to render page")
-		return
 	}
-
-	renderDuration := time.Since(renderStart)
-	totalDuration := time.Since(startTime)
-
-	m.Logger.Info("Preview request completed",
-		zap.String("request_id", requestID),
-		zap.Duration("render_duration", renderDuration),
-		zap.Duration("total_duration", totalDuration))
 }
 
-// fetchMatches retrieves matches based on selections, showing up to 10 total matches.
+// fetchMatches retrieves matches based on selections, showing up to totalLimit matches.
 // Prioritizes future matches and only uses past matches if there are no available future ones.
 func (m *Middleware) fetchMatches(
+	ctx context.Context,
 	gameIDs, leagueIDs, teamIDs []int32,
 	maxTier int32,
+	totalLimit int32,
 ) ([]dbtypes.GetFutureMatchesBySelectionsRow, bool, error) {
-	const totalLimit = 10
 	var matches []dbtypes.GetFutureMatchesBySelectionsRow
 	var showingPast bool
 
@@ -394,8 +355,8 @@ func (m *Middleware) fetchMatches(
 		return matches, showingPast, nil
 	}
 
-	// Fetch up to 10 future matches first (prioritize future matches)
-	futureMatches, err := m.DBConn.GetFutureMatchesBySelections(m.Context, dbtypes.GetFutureMatchesBySelectionsParams{
+	// Fetch up to totalLimit future matches first (prioritize future matches)
+	futureMatches, err := m.DBConn.GetFutureMatchesBySelections(ctx, dbtypes.GetFutureMatchesBySelectionsParams{
 		GameIds:    gameIDs,
 		LeagueIds:  leagueIDs,
 		TeamIds:    teamIDs,
@@ -408,19 +369,19 @@ func (m *Middleware) fetchMatches(
 
 	m.Logger.Debug("Found future matches", zap.Int("count", len(futureMatches)))
 
-	// Calculate how many past matches we need to fill up to 10 total
-	remainingSlots := totalLimit - len(futureMatches)
+	// Calculate how many past matches we need to fill up to totalLimit
+	remainingSlots := int(totalLimit) - len(futureMatches)
 
 	var pastMatches []dbtypes.GetPastMatchesBySelectionsRow
 	if remainingSlots > 0 {
 		// Only fetch past matches if we have remaining slots
 		var pastErr error
-		pastMatches, pastErr = m.DBConn.GetPastMatchesBySelections(m.Context, dbtypes.GetPastMatchesBySelectionsParams{
+		pastMatches, pastErr = m.DBConn.GetPastMatchesBySelections(ctx, dbtypes.GetPastMatchesBySelectionsParams{
 			GameIds:    gameIDs,
 			LeagueIds:  leagueIDs,
 			TeamIds:    teamIDs,
 			MaxTier:    maxTier,
-			LimitCount: int32(remainingSlots), // #nosec G115 -- remainingSlots is bounded by totalLimit (10)
+			LimitCount: int32(remainingSlots), // #nosec G115 -- remainingSlots is bounded by totalLimit
 		})
 		if pastErr != nil {
 			return nil, false, pastErr
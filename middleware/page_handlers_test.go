@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestPagesAreNotCached checks that pages carrying a CSP nonce are never served from a cache.
+func TestPagesAreNotCached(t *testing.T) {
+	m := &Middleware{Logger: zap.NewNop()} //nolint:exhaustruct // The static pages only use the logger
+	router := gin.New()
+	router.Use(m.SecurityHeaders)
+	router.GET("/about", m.AboutHandler)
+	router.GET("/how-to-use", m.HowToUseHandler)
+
+	for _, path := range []string{"/about", "/how-to-use"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d", path, recorder.Code)
+		}
+		if got := recorder.Header().Get("Cache-Control"); got != pageCacheControl {
+			t.Errorf("%s: Cache-Control = %q, want %q", path, got, pageCacheControl)
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Security-Policy"), "'nonce-") {
+			t.Errorf("%s: response has no CSP nonce", path)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"go.uber.org/zap"
+)
+
+const (
+	prerenderInterval = 5 * time.Minute
+	// prerenderLead is how long before a feed turns stale it is rendered again. It spans two runs,
+	// so a feed is refreshed even if one run is late.
+	prerenderLead = 2 * prerenderInterval
+	// prerenderActiveMinutes limits pre-rendering to calendars polled within the last day.
+	prerenderActiveMinutes = 24 * 60
+	prerenderBatchSize     = 200
+)
+
+// RunCalendarPrerenderer keeps the iCalendar feeds of the most accessed calendars in the cache,
+// rendering them before they turn stale, until ctx is cancelled.
+func (m *Middleware) RunCalendarPrerenderer(ctx context.Context) {
+	if _, noop := m.Cache.(NoopCache); noop && m.CalendarCache.local == nil {
+		m.Logger.Info("Calendar pre-rendering disabled, no calendar cache is available")
+		return
+	}
+	ticker := time.NewTicker(prerenderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.prerenderHotCalendars(ctx)
+		}
+	}
+}
+
+// prerenderHotCalendars renders the feeds of recently polled calendars, most polled first,
+// whose cached copy is missing or about to turn stale.
+func (m *Middleware) prerenderHotCalendars(ctx context.Context) {
+	mappings, err := m.DBConn.ListHotURLMappings(ctx, dbtypes.ListHotURLMappingsParams{
+		ActiveMinutes: prerenderActiveMinutes,
+		LimitCount:    prerenderBatchSize,
+	})
+	if err != nil {
+		m.Logger.Warn("Failed to list hot calendars", zap.Error(err))
+		return
+	}
+
+	format, _ := calendarFormatByExtension(".ics") // Always registered
+	refreshed := 0
+	for _, mapping := range mappings {
+		if ctx.Err() != nil {
+			return
+		}
+		cacheKey := calendarCacheKey(mapping.HashedKey, format)
+		expiresAt, cached := m.CalendarCache.Expiry(ctx, cacheKey)
+		if cached && time.Until(expiresAt) > m.Config.Cache.TTL.ICSStale+prerenderLead {
+			continue
+		}
+		sub, decodeErr := m.decodeSubscription(mapping.HashedKey, mapping.ValueList)
+		if decodeErr != nil {
+			m.Logger.Warn("Skipping undecodable calendar", zap.Error(decodeErr), zap.String("hash", mapping.HashedKey))
+			continue
+		}
+		// Shares the render with concurrent requests and gives way to instances already rendering it
+		var tier string
+		if m.Coalescer.refresh(ctx, cacheKey, m.calendarLookup(cacheKey, &tier), m.calendarProbe(cacheKey),
+			m.calendarRender(sub, format, cacheKey)) {
+			refreshed++
+		}
+	}
+	m.Logger.Info("Pre-rendered hot calendars",
+		zap.Int("hot", len(mappings)),
+		zap.Int("refreshed", refreshed))
+}
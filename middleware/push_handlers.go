@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// pushErrorStatus maps a registerPushSubscription error to an HTTP status and a user-facing message.
+func pushErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errInvalidPushSubscription):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, errPushDisabled):
+		return http.StatusServiceUnavailable, err.Error()
+	case errors.Is(err, errCalendarNotFound):
+		return http.StatusNotFound, "Calendar not found"
+	default:
+		return http.StatusInternalServerError, "Failed to save push subscription"
+	}
+}
+
+// PushPublicKeyHandler returns the VAPID public key browsers subscribe with: GET /api/push/vapid-public-key.
+func (m *Middleware) PushPublicKeyHandler(c *gin.Context) {
+	if m.WebPush == nil {
+		c.JSON(http.StatusServiceUnavailable, map[string]string{"error": errPushDisabled.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]string{"public_key": m.WebPush.PublicKey()})
+}
+
+// CreatePushSubscriptionHandler stores a browser push subscription for a calendar:
+// POST /api/push/:hash with the PushSubscription JSON and an optional "lead_minutes".
+// The subscription is managed on the settings page with the browser's owner key.
+func (m *Middleware) CreatePushSubscriptionHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	var request pushSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	sub, err := m.registerPushSubscription(c.Request.Context(), hash, m.browserOwnerKey(c), request)
+	if err != nil {
+		status, message := pushErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.Error("Failed to register push subscription", zap.Error(err), zap.String("hash", hash))
+		}
+		c.JSON(status, map[string]string{"error": message})
+		return
+	}
+	c.JSON(http.StatusCreated, map[string]any{
+		"id":           sub.ID,
+		"lead_minutes": sub.LeadMinutes,
+	})
+}
+
+// DeletePushSubscriptionHandler removes a browser's subscription from a calendar:
+// DELETE /api/push/:hash {"endpoint": "..."}.
+func (m *Middleware) DeletePushSubscriptionHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	var body struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Endpoint == "" {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	params := dbtypes.DeletePushSubscriptionByEndpointParams{HashedKey: hash, Endpoint: body.Endpoint}
+	deleted, err := m.DBConn.DeletePushSubscriptionByEndpoint(c.Request.Context(), params)
+	if err != nil {
+		logger.Error("Failed to delete push subscription", zap.Error(err), zap.String("hash", hash))
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete push subscription"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, map[string]string{"error": "Push subscription not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
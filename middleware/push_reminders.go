@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"go.uber.org/zap"
+)
+
+const (
+	pushPollInterval = time.Minute
+	// pushCleanupInterval is how often the record of sent reminders is pruned.
+	pushCleanupInterval    = time.Hour
+	defaultPushLeadMinutes = 15
+	maxPushLeadMinutes     = 24 * 60
+)
+
+var (
+	errInvalidPushSubscription = errors.New("invalid push subscription")
+	errPushNotFound            = errors.New("push subscription not found")
+)
+
+// pushSubscriptionRequest is a browser PushSubscription as serialized by toJSON(), plus the reminder lead time.
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	LeadMinutes int32 `json:"lead_minutes"`
+}
+
+// pushReminder is the JSON payload the service worker turns into a notification.
+type pushReminder struct {
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	URL      string `json:"url"`
+	Tag      string `json:"tag"`
+	StartsAt string `json:"starts_at,omitempty"`
+}
+
+// registerPushSubscription validates and stores a browser subscription for a calendar, managed with
+// ownerKey in its stored form. Subscribing the same browser again updates its keys, lead time and owner.
+func (m *Middleware) registerPushSubscription(
+	ctx context.Context,
+	hash, ownerKey string,
+	request pushSubscriptionRequest,
+) (dbtypes.PushSubscription, error) {
+	if m.WebPush == nil {
+		return dbtypes.PushSubscription{}, errPushDisabled
+	}
+	if err := validatePushEndpoint(request.Endpoint, m.Config.AllowPrivateReceivers); err != nil {
+		return dbtypes.PushSubscription{}, fmt.Errorf("%w: %w", errInvalidPushSubscription, err)
+	}
+	if _, _, err := decodePushKeys(request.Keys.P256dh, request.Keys.Auth); err != nil {
+		return dbtypes.PushSubscription{}, fmt.Errorf("%w: %w", errInvalidPushSubscription, err)
+	}
+	if request.LeadMinutes == 0 {
+		request.LeadMinutes = defaultPushLeadMinutes
+	}
+	if request.LeadMinutes < 1 || request.LeadMinutes > maxPushLeadMinutes {
+		return dbtypes.PushSubscription{}, fmt.Errorf("%w: lead_minutes must be between 1 and %d",
+			errInvalidPushSubscription, maxPushLeadMinutes)
+	}
+	if _, err := m.loadSubscription(ctx, hash); err != nil {
+		return dbtypes.PushSubscription{}, err
+	}
+
+	sub, err := m.DBConn.UpsertPushSubscription(ctx, dbtypes.UpsertPushSubscriptionParams{
+		HashedKey:   hash,
+		Endpoint:    request.Endpoint,
+		P256dh:      request.Keys.P256dh,
+		Auth:        request.Keys.Auth,
+		LeadMinutes: request.LeadMinutes,
+		OwnerKey:    ownerKey,
+	})
+	if err != nil {
+		return dbtypes.PushSubscription{}, fmt.Errorf("failed to store push subscription: %w", err)
+	}
+	m.Logger.Info("Push subscription registered",
+		zap.String("hash", hash),
+		zap.Int32("subscription_id", sub.ID),
+		zap.Int32("lead_minutes", sub.LeadMinutes))
+	return sub, nil
+}
+
+// RunPushReminderScheduler sends a push notification lead_minutes before each match of a subscribed
+// calendar until ctx is cancelled. Reminders are claimed in PUSH_REMINDERS, so instances never double-send.
+func (m *Middleware) RunPushReminderScheduler(ctx context.Context) {
+	if m.WebPush == nil {
+		m.Logger.Info("Push reminder scheduler disabled, Web Push is not configured")
+		return
+	}
+	m.Logger.Info("Push reminder scheduler started")
+	ticker := time.NewTicker(pushPollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		if err := m.sendPushReminders(ctx); err != nil && ctx.Err() == nil {
+			m.Logger.Error("Failed to send push reminders", zap.Error(err))
+		}
+		if time.Since(lastCleanup) >= pushCleanupInterval {
+			if err := m.DBConn.DeleteOldPushReminders(ctx); err != nil && ctx.Err() == nil {
+				m.Logger.Error("Failed to prune push reminders", zap.Error(err))
+			}
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			m.Logger.Info("Push reminder scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendPushReminders notifies every subscription of the matches starting within its lead time.
+// Targets are ordered by calendar, so each calendar's matches are fetched once per run.
+func (m *Middleware) sendPushReminders(ctx context.Context) error {
+	targets, err := m.DBConn.ListPushReminderTargets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list push subscriptions: %w", err)
+	}
+
+	now := time.Now()
+	currentHash := ""
+	var matches []dbtypes.GetCalendarMatchesBySelectionsRow
+	sent := 0
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if target.HashedKey != currentHash {
+			currentHash = target.HashedKey
+			matches = nil
+			sub, decodeErr := m.decodeSubscription(target.HashedKey, target.ValueList)
+			if decodeErr != nil {
+				m.Logger.Warn("Skipping push reminders of undecodable calendar",
+					zap.String("hash", target.HashedKey),
+					zap.Error(decodeErr))
+				continue
+			}
+			// The calendar's other subscriptions are skipped too, the next run tries again
+			if matches, err = m.fetchCalendarMatches(ctx, sub); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				m.Logger.Error("Skipping push reminders of calendar, failed to fetch matches",
+					zap.String("hash", target.HashedKey),
+					zap.Error(err))
+				continue
+			}
+		}
+
+		horizon := now.Add(time.Duration(target.LeadMinutes) * time.Minute)
+		for _, match := range matches {
+			start := match.ExpectedStartTime.Time
+			if match.Finished || !match.ExpectedStartTime.Valid || !start.After(now) || start.After(horizon) {
+				continue
+			}
+			delivered, gone := m.sendPushReminder(ctx, target, match, now)
+			if gone {
+				break
+			}
+			if delivered {
+				sent++
+			}
+		}
+	}
+	if sent > 0 {
+		m.Logger.Info("Push reminders sent", zap.Int("sent", sent))
+	}
+	return nil
+}
+
+// sendPushReminder claims and sends the reminder of one match. It reports whether a reminder went out
+// and whether the subscription turned out to be gone, in which case it has been deleted.
+func (m *Middleware) sendPushReminder(
+	ctx context.Context,
+	target dbtypes.ListPushReminderTargetsRow,
+	match dbtypes.GetCalendarMatchesBySelectionsRow,
+	now time.Time,
+) (bool, bool) {
+	claim := dbtypes.ClaimPushReminderParams{SubscriptionID: target.ID, MatchID: match.ID}
+	claimed, err := m.DBConn.ClaimPushReminder(ctx, claim)
+	if err != nil {
+		m.Logger.Error("Failed to claim push reminder", zap.Error(err), zap.Int32("subscription_id", target.ID))
+		return false, false
+	}
+	if claimed == 0 {
+		return false, false
+	}
+
+	untilStart := match.ExpectedStartTime.Time.Sub(now)
+	payload, err := json.Marshal(m.newPushReminder(target.HashedKey, match, untilStart))
+	if err == nil {
+		err = m.WebPush.Send(ctx, pushTarget{
+			Endpoint: target.Endpoint,
+			P256dh:   target.P256dh,
+			Auth:     target.Auth,
+		}, payload, untilStart, fmt.Sprintf("match-%d", match.ID))
+	}
+
+	switch {
+	case errors.Is(err, errPushGone):
+		m.Logger.Info("Removing expired push subscription", zap.Int32("subscription_id", target.ID))
+		if deleteErr := m.DBConn.DeletePushEndpoint(context.WithoutCancel(ctx), target.Endpoint); deleteErr != nil {
+			m.Logger.Error("Failed to remove push subscription", zap.Error(deleteErr))
+		}
+		return false, true
+	case err != nil:
+		// Released so the next run retries while the match has not started yet
+		m.Logger.Warn("Failed to send push reminder",
+			zap.Int32("subscription_id", target.ID),
+			zap.Int32("match_id", match.ID),
+			zap.Error(err))
+		release := dbtypes.ReleasePushReminderParams(claim)
+		if releaseErr := m.DBConn.ReleasePushReminder(context.WithoutCancel(ctx), release); releaseErr != nil {
+			m.Logger.Error("Failed to release push reminder", zap.Error(releaseErr))
+		}
+		return false, false
+	default:
+		return true, false
+	}
+}
+
+// newPushReminder builds the notification of a match starting in untilStart.
+func (m *Middleware) newPushReminder(
+	hash string,
+	match dbtypes.GetCalendarMatchesBySelectionsRow,
+	untilStart time.Duration,
+) pushReminder {
+	team1 := embedTeamName(match.Team1Acronym.String, match.Team1Name.String)
+	team2 := embedTeamName(match.Team2Acronym.String, match.Team2Name.String)
+	minutes := int(math.Ceil(untilStart.Minutes()))
+	return pushReminder{
+		Title:    fmt.Sprintf("%s vs %s starts in %d min", team1, team2, minutes),
+		Body:     joinNonEmpty(" · ", match.GameName, match.LeagueName, match.TournamentName),
+		URL:      fmt.Sprintf("%s/embed/%s", m.BaseURL, hash),
+		Tag:      fmt.Sprintf("match-%d", match.ID),
+		StartsAt: match.ExpectedStartTime.Time.UTC().Format(time.RFC3339),
+	}
+}
+
+// sendTestPush sends a sample notification to one of the push subscriptions of a calendar's owner.
+func (m *Middleware) sendTestPush(ctx context.Context, hash, ownerKey string, id int32) error {
+	if m.WebPush == nil {
+		return errPushDisabled
+	}
+	subs, err := m.DBConn.ListPushSubscriptionsByOwner(ctx, dbtypes.ListPushSubscriptionsByOwnerParams{
+		HashedKey: hash,
+		OwnerKey:  ownerKey,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load push subscription: %w", err)
+	}
+	for _, sub := range subs {
+		if sub.ID != id {
+			continue
+		}
+		payload, marshalErr := json.Marshal(pushReminder{
+			Title:    "EsportsCalendar reminders are on",
+			Body:     fmt.Sprintf("You will be notified %d minutes before each match.", sub.LeadMinutes),
+			URL:      fmt.Sprintf("%s/embed/%s", m.BaseURL, hash),
+			Tag:      "test",
+			StartsAt: "",
+		})
+		if marshalErr != nil {
+			return fmt.Errorf("failed to encode test notification: %w", marshalErr)
+		}
+		target := pushTarget{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}
+		return m.WebPush.Send(ctx, target, payload, time.Hour, "")
+	}
+	return errPushNotFound
+}
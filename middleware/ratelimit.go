@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	rateLimitPrefix = "ratelimit:"
+	// memoryRateLimitSweep is the bucket count at which the memory backend first drops full buckets.
+	memoryRateLimitSweep = 100000
+
+	// Rate-limited routes, as they appear in keys and metrics.
+	rateLimitExport   = "export"
+	rateLimitPreview  = "preview"
+	rateLimitCalendar = "calendar"
+	rateLimitRefresh  = "refresh"
+	// rateLimitNotifications covers the routes that register webhooks, push devices and email
+	// addresses or send test messages to them.
+	rateLimitNotifications = "notifications"
+	// rateLimitEmail is keyed by email address rather than client IP.
+	rateLimitEmail = "email"
+)
+
+// RateLimitPolicy is a token bucket holding Burst requests and refilled at PerMinute requests per
+// minute. A policy without a rate does not limit.
+type RateLimitPolicy struct {
+	PerMinute float64 `yaml:"per_minute"`
+	Burst     int     `yaml:"burst"`
+}
+
+// enabled reports whether the policy limits requests.
+func (p RateLimitPolicy) enabled() bool {
+	return p.PerMinute > 0
+}
+
+// interval is the time it takes to refill one token.
+func (p RateLimitPolicy) interval() time.Duration {
+	return time.Duration(float64(time.Minute) / p.PerMinute)
+}
+
+// RateLimiter keeps the token buckets of rate-limited routes. RedisCache shares them between
+// instances, MemoryRateLimiter keeps them in this process.
+type RateLimiter interface {
+	// TakeToken takes a token from the bucket of key. It returns 0 when a token was left and
+	// otherwise how long until the next one.
+	TakeToken(ctx context.Context, key string, policy RateLimitPolicy) (time.Duration, error)
+}
+
+// MemoryRateLimiter is a process-local RateLimiter. Each bucket is kept as the time it is full again,
+// so full buckets carry no state and are dropped once many keys were seen.
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]time.Time
+	sweepAt int
+}
+
+// NewMemoryRateLimiter creates an empty memory rate limiter.
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{mu: sync.Mutex{}, buckets: make(map[string]time.Time), sweepAt: memoryRateLimitSweep}
+}
+
+// TakeToken takes a token from the bucket of key.
+func (l *MemoryRateLimiter) TakeToken(_ context.Context, key string, policy RateLimitPolicy) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.buckets) >= l.sweepAt {
+		for bucket, full := range l.buckets {
+			if !full.After(now) {
+				delete(l.buckets, bucket)
+			}
+		}
+		// Buckets still in use are swept again only once as many more keys were seen
+		l.sweepAt = max(memoryRateLimitSweep, 2*len(l.buckets))
+	}
+
+	interval := policy.interval()
+	full := l.buckets[key]
+	if full.Before(now) {
+		full = now
+	}
+	if wait := full.Add(interval - time.Duration(policy.Burst)*interval).Sub(now); wait > 0 {
+		return wait, nil
+	}
+	l.buckets[key] = full.Add(interval)
+	return 0, nil
+}
+
+// newRateLimiter shares rate limits over Redis when it is available.
+func newRateLimiter(redisCache *RedisCache) RateLimiter {
+	if redisCache == nil {
+		return NewMemoryRateLimiter()
+	}
+	return redisCache
+}
+
+// RateLimitByIP limits the requests of each client IP to a route under policy.
+func (m *Middleware) RateLimitByIP(route string, policy RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.allowRequest(c, route, c.ClientIP(), policy) {
+			c.Abort()
+		}
+	}
+}
+
+// allowRequest takes a token for key from the bucket of route. Without one it answers 429 with
+// Retry-After and returns false. Requests are let through while the limiter is unavailable.
+func (m *Middleware) allowRequest(c *gin.Context, route, key string, policy RateLimitPolicy) bool {
+	if !policy.enabled() {
+		return true
+	}
+	logger := m.requestLogger(c.Request.Context())
+	wait, err := m.RateLimiter.TakeToken(c.Request.Context(), rateLimitPrefix+route+":"+key, policy)
+	if err != nil {
+		logger.Warn("Rate limiter unavailable, allowing request", zap.Error(err), zap.String("route", route))
+		return true
+	}
+	if wait <= 0 {
+		return true
+	}
+
+	m.Metrics.rateLimited(route)
+	retryAfter := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+	logger.Info("Request rate limited", zap.String("route", route), zap.String("retry_after", retryAfter))
+	c.Header("Retry-After", retryAfter)
+	message := "Too many requests, please try again in " + retryAfter + " seconds"
+	if c.ContentType() == gin.MIMEJSON {
+		c.JSON(http.StatusTooManyRequests, map[string]string{"error": message})
+	} else {
+		c.String(http.StatusTooManyRequests, message)
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRateLimitByIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := &Middleware{ //nolint:exhaustruct // The limiter only uses these
+		RateLimiter: NewMemoryRateLimiter(),
+		Metrics:     NewMetrics(),
+		Logger:      zap.NewNop(),
+	}
+	router := gin.New()
+	router.POST("/preview", m.RateLimitByIP(rateLimitPreview, RateLimitPolicy{PerMinute: 1, Burst: 2}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	for i := range 2 {
+		if code := post("192.0.2.1:1234").Code; code != http.StatusOK {
+			t.Fatalf("request %d: got status %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+	limited := post("192.0.2.1:1234")
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("Retry-After") == "" {
+		t.Fatalf("third request: got status %d and Retry-After %q, want 429 with Retry-After",
+			limited.Code, limited.Header().Get("Retry-After"))
+	}
+	if code := post("192.0.2.2:1234").Code; code != http.StatusOK {
+		t.Fatalf("other IP: got status %d, want %d", code, http.StatusOK)
+	}
+}
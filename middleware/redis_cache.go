@@ -2,73 +2,97 @@ package middleware
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 const (
-	// Cache key prefixes.
+	// Cache key prefixes. The ics: prefix holds every rendered calendar feed;
+	// non-iCalendar formats append their extension to the hash.
 	icsPrefix  = "ics:"
 	dataPrefix = "data:"
 
-	// Cache TTLs.
+	// Default cache TTLs, see CacheTTLConfig.
 	icsCacheTTL  = time.Hour        // ICS files expire after 1 hour
 	dataCacheTTL = 30 * time.Minute // General data cache expires after 30 minutes
+
+	// Stale windows: expired calendars and option lists stay in Redis this much longer,
+	// so they can be served while one request refreshes them.
+	icsStaleTTL  = 30 * time.Minute
+	dataStaleTTL = 10 * time.Minute
 )
 
-// RedisCache wraps the Redis client for caching operations.
+// RedisCache wraps the Redis client for caching operations. Besides the Cache interface it provides
+// the pub/sub channels, locks and rate limit buckets that coordinate instances.
 type RedisCache struct {
 	client *redis.Client
-	ctx    context.Context
+	ttl    CacheTTLConfig
 	logger *zap.Logger
 }
 
 // NewRedisCache creates a new Redis cache client.
-func NewRedisCache(ctx context.Context, logger *zap.Logger) (*RedisCache, error) {
-	// Get Redis address from environment variable, default to redis service in Docker network
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
-		redisAddr = "redis:6379"
+func NewRedisCache(ctx context.Context, cfg RedisConfig, ttl CacheTTLConfig, logger *zap.Logger) (*RedisCache, error) {
+	var tlsConfig *tls.Config
+	if cfg.TLS {
+		tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: cfg.TLSServerName,
+			//nolint:gosec // Opt-in for self-signed certificates of development servers
+			InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		}
 	}
 
-	// Get Redis password from environment variable (optional)
-	redisPassword := os.Getenv("REDIS_PASSWORD")
-
-	// Get Redis DB number from environment variable, default to 0
-	redisDB := 0
-
 	//nolint:exhaustruct // Other Redis options use defaults
 	client := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       redisDB,
+		Addr:        cfg.Addr,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		DB:          cfg.DB,
+		PoolSize:    cfg.PoolSize,
+		DialTimeout: cfg.DialTimeout,
+		// Commands end with the context of their caller, or after CommandTimeout without a deadline
+		ReadTimeout:           cfg.CommandTimeout,
+		WriteTimeout:          cfg.CommandTimeout,
+		ContextTimeoutEnabled: true,
+		TLSConfig:             tlsConfig,
 	})
 
 	// Test connection
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close() // Nothing was sent over the connection
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	// Commands end as soon as their context is cancelled, not only when it expires
+	client.AddHook(cancelHook{})
+
+	// Commands become client spans of the trace in their context
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		logger.Warn("Failed to instrument Redis tracing", zap.Error(err))
+	}
+
 	logger.Info("Redis cache initialized",
-		zap.String("addr", redisAddr),
-		zap.Int("db", redisDB))
+		zap.String("addr", cfg.Addr),
+		zap.Int("db", cfg.DB),
+		zap.Bool("tls", cfg.TLS))
 
 	return &RedisCache{
 		client: client,
-		ctx:    ctx,
+		ttl:    ttl,
 		logger: logger,
 	}, nil
 }
 
 // GetICS retrieves an ICS file from cache.
-func (c *RedisCache) GetICS(hash string) (string, bool) {
+func (c *RedisCache) GetICS(ctx context.Context, hash string) (string, bool) {
 	key := icsPrefix + hash
-	val, err := c.client.Get(c.ctx, key).Result()
+	val, err := c.client.Get(ctx, key).Result()
 	if errors.Is(err, redis.Nil) {
 		c.logger.Debug("ICS cache miss", zap.String("hash", hash))
 		return "", false
@@ -82,23 +106,70 @@ func (c *RedisCache) GetICS(hash string) (string, bool) {
 	return val, true
 }
 
-// SetICS stores an ICS file in cache with TTL.
-func (c *RedisCache) SetICS(hash string, content string) error {
+// GetICSWithExpiry retrieves an ICS file from cache together with the time its entry leaves Redis,
+// which is the stale window after it turns stale.
+func (c *RedisCache) GetICSWithExpiry(ctx context.Context, hash string) (string, time.Time, bool) {
+	return c.getWithExpiry(ctx, icsPrefix+hash, c.ttl.icsLifetime())
+}
+
+// getWithExpiry reads a key and its remaining TTL in one round trip.
+// Keys without a TTL are reported as expiring after fallbackTTL.
+func (c *RedisCache) getWithExpiry(
+	ctx context.Context,
+	key string,
+	fallbackTTL time.Duration,
+) (string, time.Time, bool) {
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.logger.Error("Failed to get from cache", zap.Error(err), zap.String("key", key))
+		}
+		return "", time.Time{}, false
+	}
+
+	expiresIn := ttl.Val()
+	if expiresIn <= 0 {
+		expiresIn = fallbackTTL
+	}
+	return get.Val(), time.Now().Add(expiresIn), true
+}
+
+// ICSExpiry returns when a cached ICS file expires, without reading it.
+func (c *RedisCache) ICSExpiry(ctx context.Context, hash string) (time.Time, bool) {
+	const keyMissing = -2 // PTTL reply for a key that does not exist
+	ttl, err := c.client.PTTL(ctx, icsPrefix+hash).Result()
+	if err != nil {
+		c.logger.Error("Failed to get TTL from cache", zap.Error(err), zap.String("hash", hash))
+		return time.Time{}, false
+	}
+	if ttl == keyMissing {
+		return time.Time{}, false
+	}
+	if ttl <= 0 {
+		ttl = c.ttl.icsLifetime()
+	}
+	return time.Now().Add(ttl), true
+}
+
+// SetICS stores an ICS file in cache, fresh for the ICS TTL and stale for the ICS stale window after that.
+func (c *RedisCache) SetICS(ctx context.Context, hash string, content string) error {
 	key := icsPrefix + hash
-	err := c.client.Set(c.ctx, key, content, icsCacheTTL).Err()
+	err := c.client.Set(ctx, key, content, c.ttl.icsLifetime()).Err()
 	if err != nil {
 		c.logger.Error("Failed to set ICS in cache", zap.Error(err), zap.String("hash", hash))
 		return err
 	}
 
-	c.logger.Debug("ICS cached", zap.String("hash", hash), zap.Duration("ttl", icsCacheTTL))
+	c.logger.Debug("ICS cached", zap.String("hash", hash), zap.Duration("ttl", c.ttl.ICS))
 	return nil
 }
 
 // DeleteICS removes a specific ICS file from cache.
-func (c *RedisCache) DeleteICS(hash string) error {
+func (c *RedisCache) DeleteICS(ctx context.Context, hash string) error {
 	key := icsPrefix + hash
-	err := c.client.Del(c.ctx, key).Err()
+	err := c.client.Del(ctx, key).Err()
 	if err != nil {
 		c.logger.Error("Failed to delete ICS from cache", zap.Error(err), zap.String("hash", hash))
 		return err
@@ -109,9 +180,9 @@ func (c *RedisCache) DeleteICS(hash string) error {
 }
 
 // GetData retrieves general data from cache (for games, leagues, teams, etc.).
-func (c *RedisCache) GetData(key string) (string, bool) {
+func (c *RedisCache) GetData(ctx context.Context, key string) (string, bool) {
 	fullKey := dataPrefix + key
-	val, err := c.client.Get(c.ctx, fullKey).Result()
+	val, err := c.client.Get(ctx, fullKey).Result()
 	if errors.Is(err, redis.Nil) {
 		c.logger.Debug("Data cache miss", zap.String("key", key))
 		return "", false
@@ -126,22 +197,22 @@ func (c *RedisCache) GetData(key string) (string, bool) {
 }
 
 // SetData stores general data in cache with TTL.
-func (c *RedisCache) SetData(key string, value string) error {
+func (c *RedisCache) SetData(ctx context.Context, key string, value string) error {
 	fullKey := dataPrefix + key
-	err := c.client.Set(c.ctx, fullKey, value, dataCacheTTL).Err()
+	err := c.client.Set(ctx, fullKey, value, c.ttl.Data).Err()
 	if err != nil {
 		c.logger.Error("Failed to set data in cache", zap.Error(err), zap.String("key", key))
 		return err
 	}
 
-	c.logger.Debug("Data cached", zap.String("key", key), zap.Duration("ttl", dataCacheTTL))
+	c.logger.Debug("Data cached", zap.String("key", key), zap.Duration("ttl", c.ttl.Data))
 	return nil
 }
 
 // GetBytes retrieves binary data from cache.
-func (c *RedisCache) GetBytes(key string) ([]byte, bool) {
+func (c *RedisCache) GetBytes(ctx context.Context, key string) ([]byte, bool) {
 	fullKey := dataPrefix + key
-	val, err := c.client.Get(c.ctx, fullKey).Bytes()
+	val, err := c.client.Get(ctx, fullKey).Bytes()
 	if errors.Is(err, redis.Nil) {
 		return nil, false
 	}
@@ -152,10 +223,17 @@ func (c *RedisCache) GetBytes(key string) ([]byte, bool) {
 	return val, true
 }
 
-// SetBytes stores binary data in cache with TTL.
-func (c *RedisCache) SetBytes(key string, value []byte) error {
+// GetBytesWithExpiry retrieves binary data from cache together with the time its entry leaves Redis,
+// which is the stale window after it turns stale.
+func (c *RedisCache) GetBytesWithExpiry(ctx context.Context, key string) ([]byte, time.Time, bool) {
+	value, expiresAt, ok := c.getWithExpiry(ctx, dataPrefix+key, c.ttl.dataLifetime())
+	return []byte(value), expiresAt, ok
+}
+
+// SetBytes stores binary data in cache, fresh for the data TTL and stale for the data stale window after that.
+func (c *RedisCache) SetBytes(ctx context.Context, key string, value []byte) error {
 	fullKey := dataPrefix + key
-	err := c.client.Set(c.ctx, fullKey, value, dataCacheTTL).Err()
+	err := c.client.Set(ctx, fullKey, value, c.ttl.dataLifetime()).Err()
 	if err != nil {
 		c.logger.Error("Failed to set bytes in cache", zap.Error(err), zap.String("key", key))
 		return err
@@ -163,12 +241,87 @@ func (c *RedisCache) SetBytes(key string, value []byte) error {
 	return nil
 }
 
+// DeleteData removes general or binary data from cache.
+func (c *RedisCache) DeleteData(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, dataPrefix+key).Err(); err != nil {
+		c.logger.Error("Failed to delete data from cache", zap.Error(err), zap.String("key", key))
+		return err
+	}
+	c.logger.Debug("Data cache entry deleted", zap.String("key", key))
+	return nil
+}
+
+// Publish sends a message to every subscriber of a Redis channel.
+func (c *RedisCache) Publish(ctx context.Context, channel string, message []byte) error {
+	if err := c.client.Publish(ctx, channel, message).Err(); err != nil {
+		c.logger.Error("Failed to publish message", zap.Error(err), zap.String("channel", channel))
+		return err
+	}
+	return nil
+}
+
+// Subscribe listens on a Redis channel until the returned subscription is closed.
+func (c *RedisCache) Subscribe(ctx context.Context, channel string) *redis.PubSub {
+	return c.client.Subscribe(ctx, channel)
+}
+
+// HoldLock takes the lock key for owner, or renews it if owner already holds it.
+// It reports whether owner holds the lock for the next ttl.
+func (c *RedisCache) HoldLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	const takeOrRenew = `if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+	held, err := c.client.Eval(ctx, takeOrRenew, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// ReleaseLock deletes a lock key if owner still holds it.
+func (c *RedisCache) ReleaseLock(ctx context.Context, key, owner string) {
+	const compareAndDelete = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+	if err := c.client.Eval(ctx, compareAndDelete, []string{key}, owner).Err(); err != nil {
+		// The lock expires on its own after its TTL
+		c.logger.Warn("Failed to release lock", zap.Error(err), zap.String("key", key))
+	}
+}
+
+// TakeToken takes a token from the rate limit bucket key, kept as the time the bucket is full
+// again on the Redis clock so all instances share it. It returns 0 when a token was left and
+// otherwise how long until the next one.
+func (c *RedisCache) TakeToken(ctx context.Context, key string, policy RateLimitPolicy) (time.Duration, error) {
+	const takeToken = `local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local interval = tonumber(ARGV[1])
+local full = math.max(tonumber(redis.call("GET", KEYS[1])) or now, now)
+local wait = full + interval - tonumber(ARGV[2]) * interval - now
+if wait > 0 then
+	return wait
+end
+redis.call("SET", KEYS[1], string.format("%.0f", full + interval), "PX", math.ceil((full + interval - now) / 1000))
+return 0`
+	wait, err := c.client.Eval(ctx, takeToken, []string{key},
+		policy.interval().Microseconds(), policy.Burst).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Microsecond, nil
+}
+
 // Clear removes all cache entries (for this application).
-func (c *RedisCache) Clear() error {
+func (c *RedisCache) Clear(ctx context.Context) error {
 	// Delete all keys with our prefixes
-	iter := c.client.Scan(c.ctx, 0, icsPrefix+"*", 0).Iterator()
-	for iter.Next(c.ctx) {
-		if err := c.client.Del(c.ctx, iter.Val()).Err(); err != nil {
+	iter := c.client.Scan(ctx, 0, icsPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
 			c.logger.Warn("Failed to delete ICS cache key", zap.Error(err), zap.String("key", iter.Val()))
 		}
 	}
@@ -176,9 +329,9 @@ func (c *RedisCache) Clear() error {
 		return err
 	}
 
-	iter = c.client.Scan(c.ctx, 0, dataPrefix+"*", 0).Iterator()
-	for iter.Next(c.ctx) {
-		if err := c.client.Del(c.ctx, iter.Val()).Err(); err != nil {
+	iter = c.client.Scan(ctx, 0, dataPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
 			c.logger.Warn("Failed to delete data cache key", zap.Error(err), zap.String("key", iter.Val()))
 		}
 	}
@@ -190,6 +343,11 @@ func (c *RedisCache) Clear() error {
 	return nil
 }
 
+// Ping checks that Redis is reachable.
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
 // Close closes the Redis connection.
 func (c *RedisCache) Close() error {
 	if err := c.client.Close(); err != nil {
@@ -201,13 +359,13 @@ func (c *RedisCache) Close() error {
 }
 
 // GetCacheStats returns cache statistics.
-func (c *RedisCache) GetCacheStats() (map[string]int64, error) {
+func (c *RedisCache) GetCacheStats(ctx context.Context) (map[string]int64, error) {
 	stats := make(map[string]int64)
 
 	// Count ICS cache entries
 	icsCount := int64(0)
-	iter := c.client.Scan(c.ctx, 0, icsPrefix+"*", 0).Iterator()
-	for iter.Next(c.ctx) {
+	iter := c.client.Scan(ctx, 0, icsPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
 		icsCount++
 	}
 	if err := iter.Err(); err != nil {
@@ -217,8 +375,8 @@ func (c *RedisCache) GetCacheStats() (map[string]int64, error) {
 
 	// Count data cache entries
 	dataCount := int64(0)
-	iter = c.client.Scan(c.ctx, 0, dataPrefix+"*", 0).Iterator()
-	for iter.Next(c.ctx) {
+	iter = c.client.Scan(ctx, 0, dataPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
 		dataCount++
 	}
 	if err := iter.Err(); err != nil {
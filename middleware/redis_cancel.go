@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cancelHook makes Redis commands return as soon as their context is cancelled. go-redis only
+// turns context deadlines into socket deadlines, so without it a cancelled request keeps waiting
+// for a slow Redis until the command timeout. The abandoned command still finishes in the
+// background, bounded by that timeout, on a copy of the command so its late reply is dropped.
+type cancelHook struct{}
+
+func (cancelHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (cancelHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if ctx.Done() == nil {
+			return next(ctx, cmd)
+		}
+		shadow := copyCmd(cmd)
+		done := make(chan error, 1)
+		go func() {
+			done <- next(ctx, shadow)
+		}()
+		select {
+		case err := <-done:
+			restoreCmd(cmd, shadow)
+			return err
+		case <-ctx.Done():
+			cmd.SetErr(ctx.Err())
+			return ctx.Err()
+		}
+	}
+}
+
+func (cancelHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if ctx.Done() == nil {
+			return next(ctx, cmds)
+		}
+		shadows := make([]redis.Cmder, len(cmds))
+		for i, cmd := range cmds {
+			shadows[i] = copyCmd(cmd)
+		}
+		done := make(chan error, 1)
+		go func() {
+			done <- next(ctx, shadows)
+		}()
+		select {
+		case err := <-done:
+			for i, cmd := range cmds {
+				restoreCmd(cmd, shadows[i])
+			}
+			return err
+		case <-ctx.Done():
+			for _, cmd := range cmds {
+				cmd.SetErr(ctx.Err())
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// copyCmd returns a shallow copy of a command. Commands are pointers to structs, and the copy
+// shares the read-only arguments while getting its own reply fields.
+func copyCmd(cmd redis.Cmder) redis.Cmder {
+	value := reflect.ValueOf(cmd).Elem()
+	shadow := reflect.New(value.Type())
+	shadow.Elem().Set(value)
+	copied, _ := shadow.Interface().(redis.Cmder) // Same type as cmd
+	return copied
+}
+
+// restoreCmd copies the reply read into shadow back to cmd.
+func restoreCmd(cmd, shadow redis.Cmder) {
+	reflect.ValueOf(cmd).Elem().Set(reflect.ValueOf(shadow).Elem())
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeRedis is a minimal RESP server. It answers the commands a client sends on connect and never
+// answers GET, like a Redis stuck on a slow command. The GET keys are sent to gets.
+type fakeRedis struct {
+	listener net.Listener
+	gets     chan string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{listener: listener, gets: make(chan string, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "HELLO":
+			reply = "-ERR unknown command 'HELLO'\r\n" // Keeps the client on RESP2
+		case "PING":
+			reply = "+PONG\r\n"
+		case "GET":
+			s.gets <- args[1]
+			_, _ = io.Copy(io.Discard, reader) // Never answers
+			return
+		default:
+			reply = "+OK\r\n"
+		}
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// readRESPCommand reads a command sent as a RESP array of bulk strings.
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("unexpected command %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		if _, err = reader.ReadString('\n'); err != nil { // $<length>
+			return nil, err
+		}
+		arg, readErr := reader.ReadString('\n')
+		if readErr != nil {
+			return nil, readErr
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+// TestRedisCacheCancelsCommands checks that a cache read ends when its context is cancelled, well
+// before the command timeout.
+func TestRedisCacheCancelsCommands(t *testing.T) {
+	server := newFakeRedis(t)
+	cfg := DefaultConfig()
+	cfg.Redis.Addr = server.listener.Addr().String()
+	cfg.Redis.CommandTimeout = time.Minute
+	core, logs := observer.New(zap.ErrorLevel)
+	cache, err := NewRedisCache(context.Background(), cfg.Redis, cfg.Cache.TTL, zap.New(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	returned := make(chan bool)
+	go func() {
+		_, found := cache.GetICS(ctx, "abc")
+		returned <- found
+	}()
+
+	select {
+	case key := <-server.gets:
+		if key != icsPrefix+"abc" {
+			t.Fatalf("GET %s, want %s", key, icsPrefix+"abc")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cache did not query Redis")
+	}
+	cancel()
+
+	select {
+	case found := <-returned:
+		if found {
+			t.Fatal("cancelled read reported a cache hit")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cache read did not return after its context was cancelled")
+	}
+	entries := logs.FilterMessage("Failed to get ICS from cache").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d failed reads, want 1", len(entries))
+	}
+	if message, _ := entries[0].ContextMap()["error"].(string); message != context.Canceled.Error() {
+		t.Fatalf("GET ended with %q, want %q", message, context.Canceled)
+	}
+}
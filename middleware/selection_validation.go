@@ -0,0 +1,332 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSelectionBodyBytes bounds the bodies of /preview and /export.
+	maxSelectionBodyBytes = 64 << 10
+	maxSelectionGames     = 20
+	// maxSelectionIDs bounds the leagues and the teams selected for one game.
+	maxSelectionIDs = 300
+	// Tiers of the tier slider, from S (1) to All (6).
+	minSelectionTier = 1
+	maxSelectionTier = 6
+	// previewQueryParam carries the hash of a stored selection in the URLs of the preview page and its stream.
+	previewQueryParam = "id"
+	// previewTTL is how long a preview link keeps working after the selection was last submitted.
+	previewTTL = 24 * time.Hour
+)
+
+// gameSelection is the selection of one game. Its fields are in alphabetical order, so a validated
+// payload encodes like the maps stored before validation.
+type gameSelection struct {
+	Leagues []int32 `json:"leagues"`
+	MaxTier int32   `json:"maxTier"`
+	Teams   []int32 `json:"teams"`
+}
+
+// submittedGameSelection is a game selection as submitted, telling a missing tier from tier 0.
+type submittedGameSelection struct {
+	Leagues []int32 `json:"leagues"`
+	MaxTier *int32  `json:"maxTier"`
+	Teams   []int32 `json:"teams"`
+}
+
+// wrappedSelections is the {"selections": ..., "hideScores": ...} payload format, fields in alphabetical order.
+type wrappedSelections struct {
+	HideScores bool                     `json:"hideScores"`
+	Selections map[string]gameSelection `json:"selections"`
+}
+
+// fieldError is a problem with one field of a request body, shown to the user by the UI.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// selectionErrors collects the field errors of a selection payload.
+type selectionErrors []fieldError
+
+func (e *selectionErrors) add(field, format string, args ...any) {
+	*e = append(*e, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// bindSelectionPayload reads and validates the selection body of /preview and /export, writing the
+// error response on failure. It returns the canonical payload, see validateSelectionPayload.
+func (m *Middleware) bindSelectionPayload(c *gin.Context) ([]byte, bool) {
+	logger := m.requestLogger(c.Request.Context())
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSelectionBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, map[string]any{
+			"error": fmt.Sprintf("Request body exceeds %d KiB", maxSelectionBodyBytes>>10),
+		})
+		return nil, false
+	}
+	if err != nil {
+		logger.Warn("Failed to read selection payload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, map[string]any{"error": "Invalid request body"})
+		return nil, false
+	}
+
+	encoded, problems, err := m.validateSelectionPayload(c.Request.Context(), body)
+	if err != nil {
+		logger.Error("Failed to validate selections", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, map[string]any{"error": "Failed to validate selections"})
+		return nil, false
+	}
+	if len(problems) > 0 {
+		logger.Info("Rejected selection payload", zap.Any("fields", problems))
+		c.JSON(http.StatusBadRequest, map[string]any{"error": "Invalid selections", "fields": problems})
+		return nil, false
+	}
+	return encoded, true
+}
+
+// storePreviewSelection keeps a canonical selection payload for previewTTL and returns its hash.
+func (m *Middleware) storePreviewSelection(ctx context.Context, payload []byte) (string, error) {
+	hash := generateHash(payload)
+	if err := m.DBConn.UpsertPreviewSelection(ctx, dbtypes.UpsertPreviewSelectionParams{
+		HashedKey:  hash,
+		ValueList:  payload,
+		TtlMinutes: int32(previewTTL / time.Minute),
+	}); err != nil {
+		return "", fmt.Errorf("failed to store preview selection: %w", err)
+	}
+	return hash, nil
+}
+
+// bindPreviewQuery loads the selection named by the id query parameter of the preview page and its
+// live score stream, writing a plain error response on failure. The selection was validated when
+// it was stored by POST /preview. It returns the hash and the canonical payload.
+func (m *Middleware) bindPreviewQuery(c *gin.Context) (string, []byte, bool) {
+	hash := c.Query(previewQueryParam)
+	if hash == "" {
+		c.String(http.StatusBadRequest, "Invalid preview link")
+		return "", nil, false
+	}
+	payload, err := m.DBConn.GetPreviewSelection(c.Request.Context(), hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.String(http.StatusNotFound, "This preview has expired, please select your leagues and teams again")
+		return "", nil, false
+	}
+	if err != nil {
+		m.requestLogger(c.Request.Context()).Error("Failed to load preview selection",
+			zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load selections")
+		return "", nil, false
+	}
+	return hash, payload, true
+}
+
+// previewQuery returns the query string naming a stored preview selection.
+func previewQuery(hash string) string {
+	return previewQueryParam + "=" + hash
+}
+
+// validateSelectionPayload checks a selection body. Without problems, it returns the payload
+// re-encoded from the validated values, so nothing but known fields is kept.
+func (m *Middleware) validateSelectionPayload(ctx context.Context, body []byte) ([]byte, selectionErrors, error) {
+	payload, problems := decodeSelectionPayload(body)
+	if len(problems) == 0 {
+		var err error
+		if problems, err = m.checkSelectionIDs(ctx, payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems, nil
+	}
+
+	var encoded []byte
+	var err error
+	if payload.wrapped {
+		encoded, err = json.Marshal(wrappedSelections{HideScores: payload.HideScores, Selections: payload.Games})
+	} else {
+		encoded, err = json.Marshal(payload.Games)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode selections: %w", err)
+	}
+	return encoded, nil, nil
+}
+
+// selectionPayload is a decoded selection body. Games are keyed by game ID, as submitted.
+type selectionPayload struct {
+	Games      map[string]gameSelection
+	HideScores bool
+	wrapped    bool
+	prefix     string // Field path of the selections, for error messages
+}
+
+// decodeSelectionPayload checks the shape of a selection body, its counts and its tier range.
+// Leagues and teams are sorted and deduplicated.
+func decodeSelectionPayload(body []byte) (selectionPayload, selectionErrors) {
+	var problems selectionErrors
+	payload := selectionPayload{Games: make(map[string]gameSelection), HideScores: false, wrapped: false, prefix: ""}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(body, &top); err != nil || top == nil {
+		problems.add("", "Selections must be a JSON object")
+		return payload, problems
+	}
+	games := top
+	if selections, ok := top["selections"]; ok {
+		payload.wrapped, payload.prefix = true, "selections."
+		for _, key := range slices.Sorted(maps.Keys(top)) {
+			if key != "selections" && key != "hideScores" {
+				problems.add(key, "Unknown field %q", key)
+			}
+		}
+		if hideScores, hasFlag := top["hideScores"]; hasFlag {
+			if err := json.Unmarshal(hideScores, &payload.HideScores); err != nil {
+				problems.add("hideScores", "hideScores must be true or false")
+			}
+		}
+		games = nil
+		if err := json.Unmarshal(selections, &games); err != nil || games == nil {
+			problems.add("selections", "Selections must be a JSON object")
+			return payload, problems
+		}
+	}
+
+	if len(games) > maxSelectionGames {
+		problems.add(strings.TrimSuffix(payload.prefix, "."), "At most %d games can be selected", maxSelectionGames)
+		return payload, problems
+	}
+	for _, key := range slices.Sorted(maps.Keys(games)) {
+		field := payload.prefix + key
+		if id, err := strconv.ParseInt(key, 10, 32); err != nil || id <= 0 || strconv.FormatInt(id, 10) != key {
+			problems.add(field, "%q is not a game ID", key)
+			continue
+		}
+		selection, ok := decodeGameSelection(games[key], field, &problems)
+		if ok {
+			payload.Games[key] = selection
+		}
+	}
+	if len(games) == 0 {
+		problems.add(strings.TrimSuffix(payload.prefix, "."), "Select at least one league or team")
+	}
+	return payload, problems
+}
+
+// decodeGameSelection decodes and checks the selection of one game.
+func decodeGameSelection(raw json.RawMessage, field string, problems *selectionErrors) (gameSelection, bool) {
+	var submitted submittedGameSelection
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&submitted); err != nil {
+		problems.add(field, "Invalid selection: %s", strings.TrimPrefix(err.Error(), "json: "))
+		return gameSelection{}, false
+	}
+
+	valid := true
+	selection := gameSelection{Leagues: nil, MaxTier: defaultMaxTier, Teams: nil}
+	if submitted.MaxTier != nil {
+		selection.MaxTier = *submitted.MaxTier
+		if selection.MaxTier < minSelectionTier || selection.MaxTier > maxSelectionTier {
+			problems.add(field+".maxTier", "Tier must be between %d and %d", minSelectionTier, maxSelectionTier)
+			valid = false
+		}
+	}
+	for _, list := range []struct {
+		name string
+		ids  []int32
+		dest *[]int32
+	}{
+		{"leagues", submitted.Leagues, &selection.Leagues},
+		{"teams", submitted.Teams, &selection.Teams},
+	} {
+		if len(list.ids) > maxSelectionIDs {
+			problems.add(field+"."+list.name, "At most %d %s can be selected per game", maxSelectionIDs, list.name)
+			valid = false
+			continue
+		}
+		if slices.ContainsFunc(list.ids, func(id int32) bool { return id <= 0 }) {
+			problems.add(field+"."+list.name, "IDs must be positive")
+			valid = false
+			continue
+		}
+		*list.dest = slices.Compact(slices.Sorted(slices.Values(list.ids)))
+		if *list.dest == nil {
+			*list.dest = []int32{}
+		}
+	}
+	if valid && len(selection.Leagues) == 0 && len(selection.Teams) == 0 {
+		problems.add(field, "Select at least one league or team of this game")
+		valid = false
+	}
+	return selection, valid
+}
+
+// checkSelectionIDs reports games that are not offered, and leagues and teams that do not exist
+// or belong to another game than the one they were selected for.
+func (m *Middleware) checkSelectionIDs(ctx context.Context, payload selectionPayload) (selectionErrors, error) {
+	games, err := m.DBConn.GetAllGames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load games: %w", err)
+	}
+	var leagueIDs, teamIDs []int32
+	for _, selection := range payload.Games {
+		leagueIDs = append(leagueIDs, selection.Leagues...)
+		teamIDs = append(teamIDs, selection.Teams...)
+	}
+	leagueGames := make(map[int32]int32, len(leagueIDs))
+	leagues, err := m.DBConn.GetLeagueGameIDs(ctx, leagueIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leagues: %w", err)
+	}
+	for _, league := range leagues {
+		leagueGames[league.ID] = league.GameID
+	}
+	teamGames := make(map[int32]int32, len(teamIDs))
+	teams, err := m.DBConn.GetTeamGameIDs(ctx, teamIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams: %w", err)
+	}
+	for _, team := range teams {
+		teamGames[team.ID] = team.GameID
+	}
+
+	var problems selectionErrors
+	for _, key := range slices.Sorted(maps.Keys(payload.Games)) {
+		field := payload.prefix + key
+		id, _ := strconv.ParseInt(key, 10, 32) // Checked by decodeSelectionPayload
+		gameID := int32(id)
+		if !slices.ContainsFunc(games, func(game dbtypes.Game) bool { return game.ID == gameID }) {
+			problems.add(field, "Game %d is not available", gameID)
+			continue
+		}
+		selection := payload.Games[key]
+		for _, leagueID := range selection.Leagues {
+			if leagueGames[leagueID] != gameID {
+				problems.add(field+".leagues", "League %d is not a league of game %d", leagueID, gameID)
+			}
+		}
+		for _, teamID := range selection.Teams {
+			if teamGames[teamID] != gameID {
+				problems.add(field+".teams", "Team %d is not a team of game %d", teamID, gameID)
+			}
+		}
+	}
+	return problems, nil
+}
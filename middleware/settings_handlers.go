@@ -0,0 +1,349 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/feimaomiao/esportscalendar/components"
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// maskedPathSuffix is how many trailing characters of a webhook URL stay visible on the settings page.
+const maskedPathSuffix = 4
+
+// SettingsHandler renders the notification settings page of a calendar: GET /settings/:hash.
+// The page only shows and manages the notifications created with the browser's owner key, as anyone
+// who picks the same selection gets the same calendar hash.
+func (m *Middleware) SettingsHandler(c *gin.Context) {
+	m.renderSettings(c, c.Param("hash"), http.StatusOK, settingsMessages("", ""))
+}
+
+// SettingsWebhookCreateHandler adds a webhook from the settings form: POST /settings/:hash/webhooks.
+func (m *Middleware) SettingsWebhookCreateHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	ownerKey := m.browserOwnerKey(c)
+	hook, err := m.registerWebhook(c.Request.Context(), hash, ownerKey, c.PostForm("url"), c.PostForm("kind"))
+	if err != nil {
+		status, message := webhookErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.Error("Failed to register webhook", zap.Error(err), zap.String("hash", hash))
+		}
+		m.renderSettings(c, hash, status, settingsMessages("", message))
+		return
+	}
+
+	data := settingsMessages(fmt.Sprintf("Added %s webhook.", hook.Kind), "")
+	// Chat services authenticate by URL; only raw JSON receivers need the signing secret
+	if hook.Kind == webhookKindJSON {
+		data.Secret = hook.Secret
+	}
+	m.renderSettings(c, c.Param("hash"), http.StatusOK, data)
+}
+
+// SettingsWebhookDeleteHandler removes a webhook from the settings page: POST /settings/:hash/webhooks/:id/delete.
+func (m *Middleware) SettingsWebhookDeleteHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		m.renderSettings(c, c.Param("hash"), http.StatusBadRequest, settingsMessages("", "Invalid webhook ID"))
+		return
+	}
+	if _, err = m.DBConn.DeleteOwnedWebhook(c.Request.Context(), dbtypes.DeleteOwnedWebhookParams{
+		ID:        int32(id),
+		HashedKey: hash,
+		OwnerKey:  m.browserOwnerKey(c),
+	}); err != nil {
+		logger.Error("Failed to delete webhook", zap.Error(err), zap.String("hash", hash))
+		m.renderSettings(c, hash, http.StatusInternalServerError, settingsMessages("", "Failed to remove webhook"))
+		return
+	}
+	// Post/Redirect/Get so a refresh does not resubmit the form
+	c.Redirect(http.StatusSeeOther, "/settings/"+url.PathEscape(hash))
+}
+
+// SettingsWebhookTestHandler sends a test message from the settings page: POST /settings/:hash/webhooks/:id/test.
+func (m *Middleware) SettingsWebhookTestHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		m.renderSettings(c, c.Param("hash"), http.StatusBadRequest, settingsMessages("", "Invalid webhook ID"))
+		return
+	}
+	hooks, err := m.DBConn.ListWebhooksByOwner(c.Request.Context(), dbtypes.ListWebhooksByOwnerParams{
+		HashedKey: c.Param("hash"),
+		OwnerKey:  m.browserOwnerKey(c),
+	})
+	if err != nil {
+		m.requestLogger(c.Request.Context()).Error("Failed to load webhook", zap.Error(err))
+		m.renderSettings(c, c.Param("hash"), http.StatusInternalServerError,
+			settingsMessages("", "Failed to load webhook"))
+		return
+	}
+	err = m.pingWebhook(c.Request.Context(), hooks, int32(id))
+	switch {
+	case errors.Is(err, errWebhookNotFound):
+		m.renderSettings(c, c.Param("hash"), http.StatusNotFound, settingsMessages("", "Webhook not found"))
+	case err != nil:
+		m.renderSettings(c, c.Param("hash"), http.StatusOK, settingsMessages("",
+			"Test message failed: "+m.pingFailureMessage(c.Request.Context(), err)))
+	default:
+		m.renderSettings(c, c.Param("hash"), http.StatusOK, settingsMessages("Test message sent.", ""))
+	}
+}
+
+// SettingsEmailSubscribeHandler starts a weekly digest subscription: POST /settings/:hash/email.
+func (m *Middleware) SettingsEmailSubscribeHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	sub, err := m.subscribeEmail(c.Request.Context(), hash, m.browserOwnerKey(c), c.PostForm("email"),
+		c.PostForm("timezone"))
+	switch {
+	case errors.Is(err, errInvalidEmail), errors.Is(err, errEmailDisabled), errors.Is(err, errEmailTaken):
+		m.renderSettings(c, hash, http.StatusBadRequest,
+			settingsMessages("", "Email subscription failed: "+err.Error()))
+	case errors.Is(err, errEmailRateLimited):
+		m.renderSettings(c, hash, http.StatusTooManyRequests,
+			settingsMessages("", "Email subscription failed: "+err.Error()))
+	case errors.Is(err, errCalendarNotFound):
+		c.String(http.StatusNotFound, "Calendar not found")
+	case err != nil:
+		logger.Error("Failed to subscribe email", zap.Error(err), zap.String("hash", hash))
+		m.renderSettings(c, hash, http.StatusInternalServerError,
+			settingsMessages("", "Failed to send the confirmation email, please try again later"))
+	case sub.ConfirmedAt.Valid:
+		m.renderSettings(c, hash, http.StatusOK, settingsMessages(sub.Email+" is already subscribed.", ""))
+	default:
+		m.renderSettings(c, hash, http.StatusOK,
+			settingsMessages("Check "+sub.Email+" for a link to confirm the subscription.", ""))
+	}
+}
+
+// SettingsEmailDeleteHandler removes a digest subscription: POST /settings/:hash/email/:id/delete.
+func (m *Middleware) SettingsEmailDeleteHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		m.renderSettings(c, hash, http.StatusBadRequest, settingsMessages("", "Invalid subscription ID"))
+		return
+	}
+	if _, err = m.DBConn.DeleteEmailSubscription(c.Request.Context(), dbtypes.DeleteEmailSubscriptionParams{
+		ID:        int32(id),
+		HashedKey: hash,
+		OwnerKey:  m.browserOwnerKey(c),
+	}); err != nil {
+		logger.Error("Failed to delete email subscription", zap.Error(err), zap.String("hash", hash))
+		m.renderSettings(c, hash, http.StatusInternalServerError, settingsMessages("", "Failed to remove subscription"))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/settings/"+url.PathEscape(hash))
+}
+
+// EmailConfirmHandler completes the double opt-in from the confirmation email: GET /email/confirm/:token.
+func (m *Middleware) EmailConfirmHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	confirmed, err := m.DBConn.ConfirmEmailSubscription(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.String(http.StatusNotFound, "This confirmation link is no longer valid")
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to confirm email subscription", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to confirm subscription")
+		return
+	}
+	m.renderSettings(c, confirmed.HashedKey, http.StatusOK,
+		settingsMessages(confirmed.Email+" will get a digest every Monday.", ""))
+}
+
+// EmailUnsubscribeHandler ends a digest subscription from the link in every digest:
+// GET or POST /email/unsubscribe/:token. POST serves one-click unsubscribes (RFC 8058).
+func (m *Middleware) EmailUnsubscribeHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash, err := m.DBConn.DeleteEmailSubscriptionByToken(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.String(http.StatusOK, "You are not subscribed to this digest.")
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to unsubscribe email", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to unsubscribe, please try again later")
+		return
+	}
+	if c.Request.Method == http.MethodPost {
+		c.String(http.StatusOK, "Unsubscribed.")
+		return
+	}
+	m.renderSettings(c, hash, http.StatusOK, settingsMessages("You will no longer receive weekly digests.", ""))
+}
+
+// SettingsPushDeleteHandler stops push reminders on a device: POST /settings/:hash/push/:id/delete.
+func (m *Middleware) SettingsPushDeleteHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		m.renderSettings(c, hash, http.StatusBadRequest, settingsMessages("", "Invalid device ID"))
+		return
+	}
+	if _, err = m.DBConn.DeletePushSubscription(c.Request.Context(), dbtypes.DeletePushSubscriptionParams{
+		ID:        int32(id),
+		HashedKey: hash,
+		OwnerKey:  m.browserOwnerKey(c),
+	}); err != nil {
+		logger.Error("Failed to delete push subscription", zap.Error(err), zap.String("hash", hash))
+		m.renderSettings(c, hash, http.StatusInternalServerError, settingsMessages("", "Failed to remove device"))
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/settings/"+url.PathEscape(hash))
+}
+
+// SettingsPushTestHandler sends a sample notification to a device: POST /settings/:hash/push/:id/test.
+func (m *Middleware) SettingsPushTestHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		m.renderSettings(c, hash, http.StatusBadRequest, settingsMessages("", "Invalid device ID"))
+		return
+	}
+	err = m.sendTestPush(c.Request.Context(), hash, m.browserOwnerKey(c), int32(id))
+	switch {
+	case errors.Is(err, errPushNotFound):
+		m.renderSettings(c, hash, http.StatusNotFound, settingsMessages("", "Device not found"))
+	case errors.Is(err, errPushGone):
+		if _, deleteErr := m.DBConn.DeletePushSubscription(c.Request.Context(), dbtypes.DeletePushSubscriptionParams{
+			ID:        int32(id),
+			HashedKey: hash,
+			OwnerKey:  m.browserOwnerKey(c),
+		}); deleteErr != nil {
+			logger.Error("Failed to delete push subscription", zap.Error(deleteErr), zap.String("hash", hash))
+		}
+		m.renderSettings(c, hash, http.StatusOK,
+			settingsMessages("", "The browser no longer accepts notifications, so the device was removed"))
+	case err != nil:
+		message := "the push service could not be reached"
+		var statusErr pushStatusError
+		if errors.As(err, &statusErr) {
+			message = statusErr.Error()
+		} else {
+			logger.Info("Test notification failed", zap.Error(err), zap.String("hash", hash))
+		}
+		m.renderSettings(c, hash, http.StatusOK, settingsMessages("", "Test notification failed: "+message))
+	default:
+		m.renderSettings(c, hash, http.StatusOK, settingsMessages("Test notification sent.", ""))
+	}
+}
+
+// settingsMessages builds page data carrying only the outcome of a form submission;
+// renderSettings fills in the rest.
+func settingsMessages(notice, errorMessage string) components.SettingsData {
+	return components.SettingsData{
+		Hash:          "",
+		CalendarURL:   "",
+		Webhooks:      nil,
+		EmailEnabled:  false,
+		Emails:        nil,
+		PushPublicKey: "",
+		PushDevices:   nil,
+		Notice:        notice,
+		Error:         errorMessage,
+		Secret:        "",
+	}
+}
+
+// renderSettings fills in the browser's current settings for the calendar and renders the page with
+// the given messages.
+func (m *Middleware) renderSettings(c *gin.Context, hash string, status int, data components.SettingsData) {
+	logger := m.requestLogger(c.Request.Context())
+	if _, err := m.loadSubscription(c.Request.Context(), hash); err != nil {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return
+	}
+	ownerKey := m.browserOwnerKey(c)
+	hooks, err := m.DBConn.ListWebhooksByOwner(c.Request.Context(), dbtypes.ListWebhooksByOwnerParams{
+		HashedKey: hash,
+		OwnerKey:  ownerKey,
+	})
+	if err != nil {
+		logger.Error("Failed to list webhooks", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+	emails, err := m.DBConn.ListEmailSubscriptionsByOwner(c.Request.Context(),
+		dbtypes.ListEmailSubscriptionsByOwnerParams{HashedKey: hash, OwnerKey: ownerKey})
+	if err != nil {
+		logger.Error("Failed to list email subscriptions", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+	devices, err := m.DBConn.ListPushSubscriptionsByOwner(c.Request.Context(),
+		dbtypes.ListPushSubscriptionsByOwnerParams{HashedKey: hash, OwnerKey: ownerKey})
+	if err != nil {
+		logger.Error("Failed to list push subscriptions", zap.Error(err), zap.String("hash", hash))
+		c.String(http.StatusInternalServerError, "Failed to load settings")
+		return
+	}
+
+	data.Hash = hash
+	data.CalendarURL = fmt.Sprintf("%s/%s.ics", m.BaseURL, hash)
+	data.Webhooks = make([]components.NotificationChannel, 0, len(hooks))
+	for _, hook := range hooks {
+		data.Webhooks = append(data.Webhooks, components.NotificationChannel{
+			ID:        hook.ID,
+			Kind:      hook.Kind,
+			Target:    maskWebhookURL(hook.Url),
+			CreatedAt: hook.CreatedAt.Time.Format("Jan 02, 2006"),
+		})
+	}
+	data.EmailEnabled = m.Mailer != nil
+	data.Emails = make([]components.EmailChannel, 0, len(emails))
+	for _, email := range emails {
+		data.Emails = append(data.Emails, components.EmailChannel{
+			ID:        email.ID,
+			Address:   maskEmail(email.Email),
+			Timezone:  email.Timezone,
+			Confirmed: email.ConfirmedAt.Valid,
+		})
+	}
+	if m.WebPush != nil {
+		data.PushPublicKey = m.WebPush.PublicKey()
+	}
+	data.PushDevices = make([]components.PushDevice, 0, len(devices))
+	for _, device := range devices {
+		data.PushDevices = append(data.PushDevices, components.PushDevice{
+			ID:          device.ID,
+			Target:      maskWebhookURL(device.Endpoint),
+			LeadMinutes: device.LeadMinutes,
+			CreatedAt:   device.CreatedAt.Time.Format("Jan 02, 2006"),
+		})
+	}
+
+	c.Header("Cache-Control", pageCacheControl)
+	c.Status(status)
+	component := components.SettingsPage(data)
+	if renderErr := component.Render(c.Request.Context(), c.Writer); renderErr != nil {
+		logger.Error("Failed to render settings page", zap.Error(renderErr))
+		c.String(http.StatusInternalServerError, "Failed to render page")
+	}
+}
+
+// maskWebhookURL hides the token part of an incoming-webhook URL, which grants posting rights.
+func maskWebhookURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return "…"
+	}
+	path := parsed.EscapedPath()
+	if len(path) > maskedPathSuffix {
+		path = "/…" + path[len(path)-maskedPathSuffix:]
+	}
+	return parsed.Scheme + "://" + parsed.Host + path
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/components"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	startupBackoffMin = time.Second
+	startupBackoffMax = 30 * time.Second
+	// redisStartupAttempts bounds how long startup waits for Redis before using the memory cache.
+	redisStartupAttempts = 5
+	// databaseCheckInterval is how often a reachable database is pinged again.
+	databaseCheckInterval = 15 * time.Second
+	databasePingTimeout   = 5 * time.Second
+	// maintenanceRetryAfter is the Retry-After header of responses sent while the database is down, in seconds.
+	maintenanceRetryAfter = "30"
+)
+
+var errMissingAssets = errors.New("missing built front-end assets")
+
+// builtAssets are the files make assets adds to the static directory. Every page links them.
+func builtAssets() []string {
+	return []string{"css/tw.css", "js/vendor/htmx.min.js", "js/vendor/sse.js"}
+}
+
+// CheckBuiltAssets reports built front-end assets that are missing from the embedded static files,
+// which happens when the binary is built without running make assets first.
+func CheckBuiltAssets(static fs.FS) error {
+	var missing []string
+	for _, name := range builtAssets() {
+		if info, err := fs.Stat(static, name); err != nil || info.Size() == 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s (run make assets before go build)", errMissingAssets, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Readiness records which backing services are reachable. Handlers and workers consult it
+// instead of failing on their first query.
+type Readiness struct {
+	database atomic.Bool
+	redis    atomic.Bool
+	// databaseUp is closed the first time the database is reached.
+	databaseUp chan struct{}
+	once       sync.Once
+}
+
+// newReadiness returns a state where no service has been reached yet.
+func newReadiness() *Readiness {
+	return &Readiness{
+		database:   atomic.Bool{},
+		redis:      atomic.Bool{},
+		databaseUp: make(chan struct{}),
+		once:       sync.Once{},
+	}
+}
+
+// Database reports whether the last database check succeeded.
+func (r *Readiness) Database() bool {
+	return r.database.Load()
+}
+
+// Redis reports whether the last Redis check succeeded. It is false when Redis is not used.
+func (r *Readiness) Redis() bool {
+	return r.redis.Load()
+}
+
+// setDatabase records the result of a database check.
+func (r *Readiness) setDatabase(up bool) {
+	r.database.Store(up)
+	if up {
+		r.once.Do(func() { close(r.databaseUp) })
+	}
+}
+
+// WaitForDatabase blocks until the database has been reached once, and reports false if ctx ends first.
+func (r *Readiness) WaitForDatabase(ctx context.Context) bool {
+	select {
+	case <-r.databaseUp:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// nextBackoff doubles a retry delay up to startupBackoffMax.
+func nextBackoff(delay time.Duration) time.Duration {
+	return min(2*delay, startupBackoffMax)
+}
+
+// connectRedis connects to Redis, retrying with backoff for up to redisStartupAttempts attempts.
+func connectRedis(ctx context.Context, cfg Config, logger *zap.Logger) (*RedisCache, error) {
+	delay := startupBackoffMin
+	for attempt := 1; ; attempt++ {
+		redisCache, err := NewRedisCache(ctx, cfg.Redis, cfg.Cache.TTL, logger)
+		if err == nil || attempt == redisStartupAttempts {
+			return redisCache, err
+		}
+		logger.Warn("Redis unavailable, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", delay))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = nextBackoff(delay)
+	}
+}
+
+// RunDatabaseMonitor connects to the database with backoff, finishes the startup steps that need it,
+// and keeps checking the database and Redis until ctx is cancelled.
+func (m *Middleware) RunDatabaseMonitor(ctx context.Context) {
+	delay := startupBackoffMin
+	started := false
+	for {
+		err := m.pingDatabase(ctx)
+		switch {
+		case err == nil && !started:
+			m.initDatabaseServices(ctx)
+			started = true
+			m.Readiness.setDatabase(true)
+			m.Logger.Info("Database connected")
+		case err == nil && !m.Readiness.Database():
+			m.Readiness.setDatabase(true)
+			m.Logger.Info("Database connection restored")
+		case err != nil && m.Readiness.Database():
+			m.Readiness.setDatabase(false)
+			m.Logger.Error("Database connection lost", zap.Error(err))
+		case err != nil:
+			m.Logger.Warn("Database unavailable, retrying", zap.Error(err), zap.Duration("retry_in", delay))
+		}
+		if m.RedisCache != nil {
+			m.Readiness.redis.Store(m.RedisCache.Ping(ctx) == nil)
+		}
+
+		wait := databaseCheckInterval
+		if err != nil {
+			wait = delay
+			delay = nextBackoff(delay)
+		} else {
+			delay = startupBackoffMin
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pingDatabase checks that the pool can reach the database.
+func (m *Middleware) pingDatabase(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, databasePingTimeout)
+	defer cancel()
+	return m.DB.Ping(pingCtx)
+}
+
+// initDatabaseServices sets up the services that read from the database at startup. It runs before
+// the database is marked ready, so handlers and workers gated on readiness see its results.
+func (m *Middleware) initDatabaseServices(ctx context.Context) {
+	// Load or create the VAPID keys for push reminders; push features are disabled while it is nil
+	webPush, err := NewWebPush(ctx, m.Logger, m.DBConn, m.Config.VAPID, m.BaseURL, m.Config.AllowPrivateReceivers)
+	if err != nil {
+		m.Logger.Error("Failed to initialize Web Push, push reminders disabled", zap.Error(err))
+	}
+	m.WebPush = webPush
+}
+
+// RequireDatabase answers with the maintenance page while the database is unreachable,
+// or with a plain error for API, CalDAV and stream clients.
+func (m *Middleware) RequireDatabase(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	if m.Readiness.Database() {
+		c.Next()
+		return
+	}
+	c.Abort()
+	c.Header("Retry-After", maintenanceRetryAfter)
+	path := c.Request.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/api/"):
+		c.JSON(http.StatusServiceUnavailable, map[string]any{
+			"error":   true,
+			"message": "EsportsCalendar is temporarily unavailable. Please try again later.",
+		})
+	case strings.HasPrefix(path, "/caldav/"), path == "/live":
+		c.String(http.StatusServiceUnavailable, "Temporarily unavailable")
+	default:
+		c.Status(http.StatusServiceUnavailable)
+		c.Header("Cache-Control", pageCacheControl)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := components.MaintenancePage().Render(c.Request.Context(), c.Writer); err != nil {
+			logger.Error("Failed to render maintenance page", zap.Error(err))
+		}
+	}
+}
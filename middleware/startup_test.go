@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCheckBuiltAssets(t *testing.T) {
+	static := fstest.MapFS{
+		"css/app.css":           {Data: []byte("body{}")},
+		"css/tw.css":            {Data: []byte("*{}")},
+		"js/vendor/htmx.min.js": {Data: []byte("htmx")},
+		"js/vendor/sse.js":      {Data: []byte("")},
+	}
+	err := CheckBuiltAssets(static)
+	if !errors.Is(err, errMissingAssets) || !strings.Contains(err.Error(), "js/vendor/sse.js") {
+		t.Fatalf("CheckBuiltAssets() = %v, want the empty sse.js reported", err)
+	}
+
+	static["js/vendor/sse.js"] = &fstest.MapFile{Data: []byte("sse")}
+	if err = CheckBuiltAssets(static); err != nil {
+		t.Fatalf("CheckBuiltAssets() = %v with every asset present", err)
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// errCalendarNotFound is returned when no URL mapping exists for a hash.
+var errCalendarNotFound = errors.New("calendar not found")
+
+// calendarSubscription is a stored URL mapping decoded into the parameters of the match queries.
+type calendarSubscription struct {
+	Hash       string
+	HideScores bool
+	GameIDs    []int32
+	LeagueIDs  []int32
+	TeamIDs    []int32
+	MaxTier    int32
+}
+
+// loadSubscription looks up the URL mapping for a hash and decodes its stored selections.
+func (m *Middleware) loadSubscription(ctx context.Context, hash string) (calendarSubscription, error) {
+	mapping, err := m.DBConn.GetURLMapping(ctx, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return calendarSubscription{}, fmt.Errorf("%w: %w", errCalendarNotFound, err)
+	}
+	if err != nil {
+		return calendarSubscription{}, fmt.Errorf("failed to load calendar: %w", err)
+	}
+	return m.decodeSubscription(hash, mapping.ValueList)
+}
+
+// decodeSubscription decodes the stored JSON of a URL mapping.
+func (m *Middleware) decodeSubscription(hash string, valueList []byte) (calendarSubscription, error) {
+	// Parse stored data from JSON
+	var storedData map[string]any
+	if unmarshalErr := json.Unmarshal(valueList, &storedData); unmarshalErr != nil {
+		return calendarSubscription{}, fmt.Errorf("invalid calendar data: %w", unmarshalErr)
+	}
+
+	// Extract selections and hideScores flag
+	selections, hideScores := extractPayload(storedData)
+
+	// Extract game IDs, league IDs, team IDs, and max tier from selections
+	gameIDs, leagueIDs, teamIDs, maxTier := parseSelections(selections, m.Logger)
+	m.Logger.Debug("Parsed IDs from selections",
+		zap.String("hash", hash),
+		zap.Any("game_ids", gameIDs),
+		zap.Any("league_ids", leagueIDs),
+		zap.Any("team_ids", teamIDs),
+		zap.Int32("max_tier", maxTier),
+		zap.Bool("hide_scores", hideScores))
+
+	return calendarSubscription{
+		Hash:       hash,
+		HideScores: hideScores,
+		GameIDs:    gameIDs,
+		LeagueIDs:  leagueIDs,
+		TeamIDs:    teamIDs,
+		MaxTier:    maxTier,
+	}, nil
+}
+
+// includes reports whether a match belongs to the subscription,
+// mirroring the WHERE clause of the selection queries.
+func (s calendarSubscription) includes(gameID, leagueID, team1ID, team2ID int32, tier pgtype.Int4) bool {
+	if !slices.Contains(s.GameIDs, gameID) {
+		return false
+	}
+	if slices.Contains(s.TeamIDs, team1ID) || slices.Contains(s.TeamIDs, team2ID) {
+		return true
+	}
+	// A NULL tier counts as 0, like COALESCE(tour.tier, 0)
+	return slices.Contains(s.LeagueIDs, leagueID) && tier.Int32 <= s.MaxTier
+}
+
+// fetchCalendarMatches returns the matches of a subscription
+// (3 days old and future, filtered by tier).
+func (m *Middleware) fetchCalendarMatches(
+	ctx context.Context,
+	sub calendarSubscription,
+) ([]dbtypes.GetCalendarMatchesBySelectionsRow, error) {
+	if len(sub.GameIDs) == 0 {
+		return nil, nil
+	}
+	return m.DBConn.GetCalendarMatchesBySelections(ctx, dbtypes.GetCalendarMatchesBySelectionsParams{
+		GameIds:   sub.GameIDs,
+		LeagueIds: sub.LeagueIDs,
+		TeamIds:   sub.TeamIDs,
+		MaxTier:   sub.MaxTier,
+	})
+}
+
+// loadCalendarFeed loads a subscription and builds its calendar model.
+func (m *Middleware) loadCalendarFeed(ctx context.Context, hash string) (calendarSubscription, calendarFeed, error) {
+	sub, err := m.loadSubscription(ctx, hash)
+	if err != nil {
+		return calendarSubscription{}, calendarFeed{}, err
+	}
+	matches, err := m.fetchCalendarMatches(ctx, sub)
+	if err != nil {
+		return calendarSubscription{}, calendarFeed{}, fmt.Errorf("failed to fetch matches: %w", err)
+	}
+	return sub, buildCalendarFeed(hash, matches, sub.HideScores, m.BaseURL), nil
+}
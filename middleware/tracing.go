@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	tracerName = "github.com/feimaomiao/esportscalendar/middleware"
+	// otlpTracesPath is appended to the collector URL, as for OTEL_EXPORTER_OTLP_ENDPOINT.
+	otlpTracesPath = "/v1/traces"
+
+	// Span attributes of calendar requests.
+	attrCalendarHash   = attribute.Key("calendar.hash")
+	attrCalendarFormat = attribute.Key("calendar.format")
+	attrGameID         = attribute.Key("game.id")
+	attrGameIDs        = attribute.Key("game.ids")
+	attrCacheFamily    = attribute.Key("cache.family")
+	attrCacheOutcome   = attribute.Key("cache.outcome")
+	attrCacheTier      = attribute.Key("cache.tier")
+)
+
+// Tracing owns the OpenTelemetry tracer provider. It propagates W3C trace context in any case and
+// exports spans only while an OTLP endpoint is configured.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+	service  string
+	logger   *zap.Logger
+}
+
+// NewTracing installs the global propagator and, with an endpoint, a tracer provider exporting
+// sampled spans over OTLP/HTTP.
+func NewTracing(ctx context.Context, cfg TracingConfig, logger *zap.Logger) (*Tracing, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	tracing := &Tracing{provider: nil, service: cfg.ServiceName, logger: logger}
+	if cfg.Endpoint == "" {
+		logger.Info("Trace export disabled, no OTLP endpoint configured")
+		return tracing, nil
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(endpoint.Path, "/") + otlpTracesPath),
+	}
+	if endpoint.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	build := readBuildStatus()
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName), semconv.ServiceVersion(build.Version)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	tracing.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tracing.provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("OpenTelemetry error", zap.Error(err))
+	}))
+
+	logger.Info("Trace export enabled",
+		zap.String("endpoint", endpoint.Redacted()),
+		zap.Float64("sample_ratio", cfg.SampleRatio))
+	return tracing, nil
+}
+
+// Middleware starts a server span per request, continuing the caller's trace. Probes, metrics scrapes
+// and static files are not traced.
+func (t *Tracing) Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(t.service,
+		otelgin.WithFilter(func(r *http.Request) bool {
+			switch {
+			case r.URL.Path == "/healthz", r.URL.Path == "/readyz", r.URL.Path == "/metrics":
+				return false
+			default:
+				return !strings.HasPrefix(r.URL.Path, "/static/")
+			}
+		}),
+		otelgin.WithSpanNameFormatter(spanName))
+}
+
+// spanName names a span after its route. Calendar downloads have no registered route and are
+// named after their format; other unmatched requests after their method only.
+func spanName(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		format, ok := calendarFormatByExtension(filepath.Ext(c.Request.URL.Path))
+		if !ok {
+			return c.Request.Method
+		}
+		route = "/:hash" + format.extension
+	}
+	return c.Request.Method + " " + route
+}
+
+// Shutdown flushes buffered spans.
+func (t *Tracing) Shutdown(ctx context.Context) {
+	if t.provider == nil {
+		return
+	}
+	if err := t.provider.Shutdown(ctx); err != nil {
+		t.logger.Error("Failed to flush traces", zap.Error(err))
+	}
+}
+
+// setSpanAttributes annotates the server span of a request.
+func setSpanAttributes(c *gin.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attrs...)
+}
+
+// recordCacheLookup counts a cache lookup and records its outcome on the request span and access log.
+func (m *Middleware) recordCacheLookup(c *gin.Context, family cacheFamily, status string) {
+	m.Metrics.cacheLookup(family, status)
+	outcome := strings.ToLower(status)
+	setSpanAttributes(c, attrCacheFamily.String(string(family)), attrCacheOutcome.String(outcome))
+	c.Set(cacheOutcomeKey, outcome)
+}
+
+// gameIDsAttribute converts game IDs to a span attribute.
+func gameIDsAttribute(gameIDs []int32) attribute.KeyValue {
+	ids := make([]int64, len(gameIDs))
+	for i, id := range gameIDs {
+		ids[i] = int64(id)
+	}
+	return attrGameIDs.Int64Slice(ids)
+}
+
+// queryTracer records a client span per Postgres query, named after its sqlc query.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+// newQueryTracer returns a pgx tracer using the global tracer provider.
+func newQueryTracer() *queryTracer {
+	return &queryTracer{tracer: otel.Tracer(tracerName)}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, _ = t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(data.SQL)))
+	return ctx
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+func TestSpanName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var names []string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		names = append(names, spanName(c))
+	})
+	router.GET("/api/matches/:hash", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/api/matches/abc", "/abc.ics", "/wp-login.php"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	want := []string{"GET /api/matches/:hash", "GET /:hash.ics", "GET"}
+	for i := range want {
+		if i >= len(names) || names[i] != want[i] {
+			t.Fatalf("span names = %q, want %q", names, want)
+		}
+	}
+}
+
+// TestTracingExportsSpans checks that request spans reach an OTLP/HTTP collector, here a stub
+// recording the export requests.
+func TestTracingExportsSpans(t *testing.T) {
+	exports := make(chan []byte, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/otlp"+otlpTracesPath {
+			http.NotFound(w, r)
+			return
+		}
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = gz
+		}
+		payload, _ := io.ReadAll(body)
+		select {
+		case exports <- payload:
+		default: // Only the first export is checked
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	cfg := DefaultConfig().Tracing
+	cfg.Endpoint = collector.URL + "/otlp"
+	tracing, err := NewTracing(context.Background(), cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/api/matches/:hash", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/matches/abc", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracing.Shutdown(ctx) // Flushes the batch
+
+	select {
+	case payload := <-exports:
+		for _, want := range []string{"GET /api/matches/:hash", cfg.ServiceName} {
+			if !bytes.Contains(payload, []byte(want)) {
+				t.Errorf("exported spans do not mention %q", want)
+			}
+		}
+	default:
+		t.Fatal("no spans were exported")
+	}
+}
@@ -10,6 +10,15 @@ import (
 
 const defaultMaxTier = 2 // Default to tier A (tier 2)
 
+// tierLabel converts a tournament tier number into the letter shown in the UI (1 = S, 2 = A, ...).
+func tierLabel(tier int32) string {
+	labels := map[int32]string{1: "S", 2: "A", 3: "B", 4: "C", 5: "D"}
+	if label, ok := labels[tier]; ok {
+		return label
+	}
+	return strconv.Itoa(int(tier))
+}
+
 // generateHash creates a consistent hash from the selections JSON.
 func generateHash(data []byte) string {
 	hash := sha256.Sum256(data)
@@ -17,6 +26,24 @@ func generateHash(data []byte) string {
 	return hex.EncodeToString(hash[:])[:16]
 }
 
+// extractPayload splits a stored or submitted payload into its selections and hideScores flag.
+// Both the current {"selections": ..., "hideScores": ...} format and the old unwrapped format are accepted.
+func extractPayload(payload map[string]any) (map[string]any, bool) {
+	// Extract hideScores flag (default to false)
+	hideScores := false
+	if hideScoresVal, ok := payload["hideScores"].(bool); ok {
+		hideScores = hideScoresVal
+	}
+
+	// Extract selections (handle both old and new format)
+	if selectionsVal, ok := payload["selections"].(map[string]any); ok {
+		// New format with selections wrapper
+		return selectionsVal, hideScores
+	}
+	// Old format without wrapper
+	return payload, hideScores
+}
+
 // parseSelections extracts game IDs, league IDs, team IDs, and max tier from selections JSON.
 func parseSelections(
 	selections map[string]any,
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhook kinds. json receivers get the signed raw payload; discord and slack
+// receive native rich messages built from it.
+const (
+	webhookKindJSON    = "json"
+	webhookKindDiscord = "discord"
+	webhookKindSlack   = "slack"
+
+	webhookUsername = "EsportsCalendar"
+
+	// Embed colors per event, matching the site's badge palette.
+	discordColorRescheduled = 0xF59E0B
+	discordColorLive        = 0xEF4444
+	discordColorFinished    = 0x22C55E
+	discordColorDefault     = 0x4F46E5
+)
+
+type discordEmbedAuthor struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+type discordEmbedImage struct {
+	URL string `json:"url"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	URL         string              `json:"url,omitempty"`
+	Color       int                 `json:"color"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Author      *discordEmbedAuthor `json:"author,omitempty"`
+	Thumbnail   *discordEmbedImage  `json:"thumbnail,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      *discordEmbedFooter `json:"footer,omitempty"`
+}
+
+// discordMessage is the body of a Discord incoming-webhook execution.
+type discordMessage struct {
+	Username string         `json:"username"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackElement is a context block element: mrkdwn text or an image.
+type slackElement struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	AltText  string `json:"alt_text,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string         `json:"type"`
+	Text     *slackText     `json:"text,omitempty"`
+	Fields   []slackText    `json:"fields,omitempty"`
+	Elements []slackElement `json:"elements,omitempty"`
+}
+
+// slackMessage is the body of a Slack incoming-webhook post. Text is the notification fallback.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// formatWebhookPayload renders a payload in the body format of a webhook kind.
+// Formatting only depends on the payload, so the same input always yields the same bytes.
+func formatWebhookPayload(kind string, payload webhookPayload) ([]byte, error) {
+	switch kind {
+	case webhookKindDiscord:
+		return json.Marshal(formatDiscord(payload))
+	case webhookKindSlack:
+		return json.Marshal(formatSlack(payload))
+	default:
+		return json.Marshal(payload)
+	}
+}
+
+// detectWebhookKind guesses the kind from an incoming-webhook URL.
+func detectWebhookKind(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return webhookKindJSON
+	}
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case (host == "discord.com" || strings.HasSuffix(host, ".discord.com") || host == "discordapp.com") &&
+		strings.HasPrefix(parsed.Path, "/api/webhooks/"):
+		return webhookKindDiscord
+	case host == "hooks.slack.com":
+		return webhookKindSlack
+	default:
+		return webhookKindJSON
+	}
+}
+
+// webhookEventTitle is the human-readable headline of an event.
+func webhookEventTitle(event string) string {
+	switch event {
+	case webhookEvent(matchChangeRescheduled):
+		return "Match rescheduled"
+	case webhookEvent(matchChangeLive):
+		return "Match is live"
+	case webhookEvent(matchChangeFinished):
+		return "Match finished"
+	default:
+		return "EsportsCalendar notification"
+	}
+}
+
+func discordColor(event string) int {
+	switch event {
+	case webhookEvent(matchChangeRescheduled):
+		return discordColorRescheduled
+	case webhookEvent(matchChangeLive):
+		return discordColorLive
+	case webhookEvent(matchChangeFinished):
+		return discordColorFinished
+	default:
+		return discordColorDefault
+	}
+}
+
+// formatDiscord builds a rich embed: team logos as author icon and thumbnail,
+// tournament, start time in Discord <t:unix> markup and the score.
+func formatDiscord(payload webhookPayload) discordMessage {
+	message := discordMessage{Username: webhookUsername, Content: "", Embeds: nil}
+	match := payload.Match
+	if match == nil {
+		message.Content = "EsportsCalendar notifications are set up for this channel."
+		return message
+	}
+
+	embed := discordEmbed{
+		Title:       fmt.Sprintf("%s: %s vs %s", webhookEventTitle(payload.Event), match.Team1, match.Team2),
+		Description: joinNonEmpty(" · ", match.League, match.Tournament),
+		URL:         "",
+		Color:       discordColor(payload.Event),
+		Timestamp:   payload.OccurredAt,
+		Author:      &discordEmbedAuthor{Name: match.Team1, IconURL: imageURL(match.Team1Image)},
+		Thumbnail:   nil,
+		Fields:      nil,
+		Footer:      &discordEmbedFooter{Text: "EsportsCalendar · " + match.Game},
+	}
+	if logo := imageURL(match.Team2Image); logo != "" {
+		embed.Thumbnail = &discordEmbedImage{URL: logo}
+	}
+	if start, ok := parseRFC3339(match.ScheduledAt); ok {
+		embed.Fields = append(embed.Fields, discordEmbedField{
+			Name:   "Starts",
+			Value:  fmt.Sprintf("<t:%d:F> (<t:%d:R>)", start.Unix(), start.Unix()),
+			Inline: true,
+		})
+	}
+	if previous, ok := parseRFC3339(match.PreviousScheduledAt); ok {
+		embed.Fields = append(embed.Fields, discordEmbedField{
+			Name:   "Previously",
+			Value:  fmt.Sprintf("<t:%d:F>", previous.Unix()),
+			Inline: true,
+		})
+	}
+	if match.Score != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Score", Value: match.Score, Inline: true})
+	}
+	message.Embeds = []discordEmbed{embed}
+	return message
+}
+
+// formatSlack builds a Block Kit message with team logos in a context block
+// and times in Slack <!date> markup, which renders in each reader's timezone.
+func formatSlack(payload webhookPayload) slackMessage {
+	match := payload.Match
+	if match == nil {
+		text := "EsportsCalendar notifications are set up for this channel."
+		return slackMessage{
+			Text:   text,
+			Blocks: []slackBlock{newSlackTextBlock("section", "mrkdwn", text)},
+		}
+	}
+
+	title := webhookEventTitle(payload.Event)
+	teams := fmt.Sprintf("*%s* vs *%s*", slackEscape(match.Team1), slackEscape(match.Team2))
+	// The fallback is mrkdwn too, so names like "<!channel>" must not reach it unescaped
+	fallback := fmt.Sprintf("%s: %s vs %s", title, slackEscape(match.Team1), slackEscape(match.Team2))
+
+	blocks := []slackBlock{
+		newSlackTextBlock("header", "plain_text", title),
+		newSlackTextBlock("section", "mrkdwn",
+			teams+"\n"+slackEscape(joinNonEmpty(" · ", match.Game, match.League, match.Tournament))),
+	}
+
+	var logos []slackElement
+	for _, team := range [][2]string{{match.Team1, match.Team1Image}, {match.Team2, match.Team2Image}} {
+		if logo := imageURL(team[1]); logo != "" {
+			logos = append(logos, slackElement{Type: "image", Text: "", ImageURL: logo, AltText: team[0]})
+		}
+	}
+	if len(logos) > 0 {
+		blocks = append(blocks, slackBlock{Type: "context", Text: nil, Fields: nil, Elements: logos})
+	}
+
+	var fields []slackText
+	if start, ok := parseRFC3339(match.ScheduledAt); ok {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Starts*\n" + slackDate(start)})
+	}
+	if previous, ok := parseRFC3339(match.PreviousScheduledAt); ok {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Previously*\n" + slackDate(previous)})
+	}
+	if match.Score != "" {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Score*\n" + slackEscape(match.Score)})
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, slackBlock{Type: "section", Text: nil, Fields: fields, Elements: nil})
+	}
+
+	return slackMessage{Text: fallback, Blocks: blocks}
+}
+
+func newSlackTextBlock(blockType, textType, text string) slackBlock {
+	return slackBlock{
+		Type:     blockType,
+		Text:     &slackText{Type: textType, Text: text},
+		Fields:   nil,
+		Elements: nil,
+	}
+}
+
+// slackDate renders a time with Slack's date formatting and a UTC fallback for old clients.
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", t.Unix(), t.UTC().Format(time.RFC1123))
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// imageURL returns the link if it is an absolute http(s) URL, which chat services require for images.
+func imageURL(link string) string {
+	if strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://") {
+		return link
+	}
+	return ""
+}
+
+func parseRFC3339(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, err == nil
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
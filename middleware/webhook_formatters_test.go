@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of the webhook formatter tests")
+
+// webhookFormatterCases covers every event kind, plus the ping and a calendar that hides scores.
+func webhookFormatterCases() map[string]webhookPayload {
+	match := func() *webhookMatch {
+		return &webhookMatch{
+			ID:                  1234,
+			Name:                "Grand Final: T1 vs G2",
+			Game:                "League of Legends",
+			League:              "Worlds",
+			Tournament:          "Playoffs",
+			Team1:               "T1",
+			Team1Image:          "https://cdn.example.com/t1.png",
+			Team2:               "G2 <Esports> & Co",
+			Team2Image:          "https://cdn.example.com/g2.png",
+			ScheduledAt:         "2026-11-02T12:00:00Z",
+			PreviousScheduledAt: "",
+			Finished:            false,
+			Score:               "",
+			URL:                 "https://esportscalendar.app/embed/abc",
+		}
+	}
+	rescheduled := match()
+	rescheduled.PreviousScheduledAt = "2026-11-01T15:00:00Z"
+	rescheduled.Team2Image = "" // No logo
+	finished := match()
+	finished.Finished = true
+	finished.Score = "3-1"
+	hidden := match()
+	hidden.Finished = true
+	hidden.Score = "Finished"
+
+	payload := func(event string, changeID int64, m *webhookMatch) webhookPayload {
+		return webhookPayload{
+			Event:      event,
+			ChangeID:   changeID,
+			OccurredAt: "2026-11-02T14:30:00Z",
+			Calendar:   "abc",
+			Match:      m,
+		}
+	}
+	return map[string]webhookPayload{
+		"ping":                   payload(webhookEventPing, 0, nil),
+		"rescheduled":            payload(webhookEvent(matchChangeRescheduled), 1, rescheduled),
+		"live":                   payload(webhookEvent(matchChangeLive), 2, match()),
+		"finished":               payload(webhookEvent(matchChangeFinished), 3, finished),
+		"finished_hidden_scores": payload(webhookEvent(matchChangeFinished), 4, hidden),
+	}
+}
+
+func TestFormatWebhookPayloadGolden(t *testing.T) {
+	for _, kind := range []string{webhookKindJSON, webhookKindDiscord, webhookKindSlack} {
+		for name, payload := range webhookFormatterCases() {
+			t.Run(kind+"/"+name, func(t *testing.T) {
+				body, err := formatWebhookPayload(kind, payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var indented bytes.Buffer
+				if err = json.Indent(&indented, body, "", "  "); err != nil {
+					t.Fatal(err)
+				}
+				indented.WriteByte('\n')
+
+				golden := filepath.Join("testdata", kind+"_"+name+".golden")
+				if *updateGolden {
+					if err = os.WriteFile(golden, indented.Bytes(), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if !bytes.Equal(indented.Bytes(), want) {
+					t.Errorf("%s differs from the output:\n%s", golden, indented.String())
+				}
+			})
+		}
+	}
+}
+
+func TestDetectWebhookKind(t *testing.T) {
+	tests := map[string]string{
+		"https://discord.com/api/webhooks/1/abc":       webhookKindDiscord,
+		"https://canary.discord.com/api/webhooks/1/ab": webhookKindDiscord,
+		"https://discordapp.com/api/webhooks/1/abc":    webhookKindDiscord,
+		"https://discord.com/channels/1":               webhookKindJSON,
+		"https://hooks.slack.com/services/T/B/X":       webhookKindSlack,
+		"https://example.com/hook":                     webhookKindJSON,
+	}
+	for rawURL, want := range tests {
+		if got := detectWebhookKind(rawURL); got != want {
+			t.Errorf("detectWebhookKind(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
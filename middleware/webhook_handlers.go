@@ -0,0 +1,320 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+var (
+	errInvalidWebhook  = errors.New("invalid webhook")
+	errWebhookExists   = errors.New("webhook already registered")
+	errWebhookNotFound = errors.New("webhook not found")
+)
+
+const (
+	webhookSecretBytes = 32
+	// pgUniqueViolation is the PostgreSQL SQLSTATE for unique constraint violations.
+	pgUniqueViolation = "23505"
+)
+
+// webhookResponse is a registered webhook as returned by the API. The URL is masked, as chat services
+// authenticate by it. The secret and a newly generated owner key are only shown once, on creation.
+type webhookResponse struct {
+	ID        int32  `json:"id"`
+	Kind      string `json:"kind"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	OwnerKey  string `json:"owner_key,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// deadLetterResponse is a delivery that exhausted its retries.
+type deadLetterResponse struct {
+	ID        int64  `json:"id"`
+	WebhookID int32  `json:"webhook_id"`
+	Event     string `json:"event"`
+	Attempts  int32  `json:"attempts"`
+	LastError string `json:"last_error"`
+	FailedAt  string `json:"failed_at"`
+}
+
+func newWebhookResponse(hook dbtypes.Webhook, withSecret bool) webhookResponse {
+	response := webhookResponse{
+		ID:        hook.ID,
+		Kind:      hook.Kind,
+		URL:       maskWebhookURL(hook.Url),
+		Secret:    "",
+		OwnerKey:  "",
+		CreatedAt: timestampRFC3339(hook.CreatedAt),
+	}
+	if withSecret {
+		response.Secret = hook.Secret
+	}
+	return response
+}
+
+// registerWebhook validates and stores a webhook for a calendar, managed with ownerKey in its stored form.
+// An empty or "auto" kind is detected from the URL.
+func (m *Middleware) registerWebhook(
+	ctx context.Context,
+	hash, ownerKey, rawURL, kind string,
+) (dbtypes.Webhook, error) {
+	if err := validateOutboundURL(rawURL, m.Config.AllowPrivateReceivers); err != nil {
+		return dbtypes.Webhook{}, fmt.Errorf("%w: %w", errInvalidWebhook, err)
+	}
+	switch kind {
+	case "", "auto":
+		kind = detectWebhookKind(rawURL)
+	case webhookKindJSON, webhookKindDiscord, webhookKindSlack:
+	default:
+		return dbtypes.Webhook{}, fmt.Errorf("%w: unknown kind %q", errInvalidWebhook, kind)
+	}
+	if _, err := m.loadSubscription(ctx, hash); err != nil {
+		return dbtypes.Webhook{}, err
+	}
+
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return dbtypes.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	hook, err := m.DBConn.InsertWebhook(ctx, dbtypes.InsertWebhookParams{
+		HashedKey: hash,
+		Url:       rawURL,
+		Secret:    hex.EncodeToString(secret),
+		Kind:      kind,
+		OwnerKey:  ownerKey,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return dbtypes.Webhook{}, errWebhookExists
+	}
+	if err != nil {
+		return dbtypes.Webhook{}, fmt.Errorf("failed to store webhook: %w", err)
+	}
+
+	m.Logger.Info("Webhook registered",
+		zap.String("hash", hash),
+		zap.Int32("webhook_id", hook.ID),
+		zap.String("kind", hook.Kind))
+	return hook, nil
+}
+
+// webhookErrorStatus maps a registerWebhook error to an HTTP status and a user-facing message.
+func webhookErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errInvalidWebhook):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, errCalendarNotFound):
+		return http.StatusNotFound, "Calendar not found"
+	case errors.Is(err, errWebhookExists):
+		return http.StatusConflict, "Webhook already registered for this URL"
+	default:
+		return http.StatusInternalServerError, "Failed to create webhook"
+	}
+}
+
+// CreateWebhookHandler registers a webhook for a calendar:
+// POST /api/webhooks/:hash {"url": "...", "kind": "json|discord|slack"}.
+// Webhooks are managed with the owner key sent as a bearer token or kept in the owner cookie;
+// without one a new key is returned.
+func (m *Middleware) CreateWebhookHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	var body struct {
+		URL  string `json:"url"`
+		Kind string `json:"kind"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	ownerKey, hasKey := requestOwnerKey(c)
+	if !hasKey {
+		ownerKey = newOwnerKey()
+	}
+	hook, err := m.registerWebhook(c.Request.Context(), hash, hashOwnerKey(ownerKey), body.URL, body.Kind)
+	if err != nil {
+		status, message := webhookErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.Error("Failed to register webhook", zap.Error(err), zap.String("hash", hash))
+		}
+		c.JSON(status, map[string]string{"error": message})
+		return
+	}
+	response := newWebhookResponse(hook, true)
+	if !hasKey {
+		response.OwnerKey = ownerKey
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// requireOwnerKey returns the stored form of the request's owner key, answering 401 without one.
+func requireOwnerKey(c *gin.Context) (string, bool) {
+	ownerKey, ok := requestOwnerKey(c)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="webhooks"`)
+		c.JSON(http.StatusUnauthorized, map[string]string{"error": "Owner key required"})
+		return "", false
+	}
+	return hashOwnerKey(ownerKey), true
+}
+
+// ListWebhooksHandler lists the webhooks of a calendar created with the request's owner key, and
+// their recent dead letters: GET /api/webhooks/:hash.
+func (m *Middleware) ListWebhooksHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	ownerKey, ok := requireOwnerKey(c)
+	if !ok {
+		return
+	}
+	hooks, err := m.DBConn.ListWebhooksByOwner(c.Request.Context(), dbtypes.ListWebhooksByOwnerParams{
+		HashedKey: hash,
+		OwnerKey:  ownerKey,
+	})
+	if err != nil {
+		logger.Error("Failed to list webhooks", zap.Error(err), zap.String("hash", hash))
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list webhooks"})
+		return
+	}
+	deadLetters, err := m.DBConn.ListWebhookDeadLettersByOwner(c.Request.Context(),
+		dbtypes.ListWebhookDeadLettersByOwnerParams{HashedKey: hash, OwnerKey: ownerKey})
+	if err != nil {
+		logger.Error("Failed to list dead letters", zap.Error(err), zap.String("hash", hash))
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list webhooks"})
+		return
+	}
+
+	webhooks := make([]webhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		webhooks = append(webhooks, newWebhookResponse(hook, false))
+	}
+	failed := make([]deadLetterResponse, 0, len(deadLetters))
+	for _, dl := range deadLetters {
+		failed = append(failed, deadLetterResponse{
+			ID:        dl.ID,
+			WebhookID: dl.WebhookID,
+			Event:     dl.Event,
+			Attempts:  dl.Attempts,
+			LastError: dl.LastError,
+			FailedAt:  timestampRFC3339(dl.FailedAt),
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"webhooks":     webhooks,
+		"dead_letters": failed,
+	})
+}
+
+// DeleteWebhookHandler removes a webhook and its pending deliveries: DELETE /api/webhooks/:hash/:id.
+func (m *Middleware) DeleteWebhookHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+		return
+	}
+	ownerKey, ok := requireOwnerKey(c)
+	if !ok {
+		return
+	}
+	deleted, err := m.DBConn.DeleteOwnedWebhook(c.Request.Context(), dbtypes.DeleteOwnedWebhookParams{
+		ID:        int32(id),
+		HashedKey: hash,
+		OwnerKey:  ownerKey,
+	})
+	if err != nil {
+		logger.Error("Failed to delete webhook", zap.Error(err), zap.String("hash", hash))
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete webhook"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PingWebhookHandler sends a signed "ping" event right away and reports the receiver's answer:
+// POST /api/webhooks/:hash/:id/ping.
+func (m *Middleware) PingWebhookHandler(c *gin.Context) {
+	logger := m.requestLogger(c.Request.Context())
+	hash := c.Param("hash")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook ID"})
+		return
+	}
+	ownerKey, ok := requireOwnerKey(c)
+	if !ok {
+		return
+	}
+	hooks, err := m.DBConn.ListWebhooksByOwner(c.Request.Context(), dbtypes.ListWebhooksByOwnerParams{
+		HashedKey: hash,
+		OwnerKey:  ownerKey,
+	})
+	if err != nil {
+		logger.Error("Failed to load webhook", zap.Error(err), zap.String("hash", hash))
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load webhook"})
+		return
+	}
+
+	err = m.pingWebhook(c.Request.Context(), hooks, int32(id))
+	switch {
+	case errors.Is(err, errWebhookNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+	case err != nil:
+		c.JSON(http.StatusBadGateway, map[string]string{"error": m.pingFailureMessage(c.Request.Context(), err)})
+	default:
+		c.JSON(http.StatusOK, map[string]string{"status": "delivered"})
+	}
+}
+
+// pingFailureMessage describes a failed ping to the calendar's owner. Only the receiver's status code
+// is shown; other failures are logged, as their details would tell internal hosts and ports apart.
+func (m *Middleware) pingFailureMessage(ctx context.Context, err error) string {
+	var statusErr webhookStatusError
+	if errors.As(err, &statusErr) {
+		return fmt.Sprintf("Receiver responded with status %d", statusErr.StatusCode)
+	}
+	m.requestLogger(ctx).Info("Webhook ping failed", zap.Error(err))
+	return "Receiver could not be reached"
+}
+
+// pingWebhook sends a "ping" event to the webhook with the given ID among hooks and waits for the receiver.
+func (m *Middleware) pingWebhook(ctx context.Context, hooks []dbtypes.Webhook, id int32) error {
+	for _, hook := range hooks {
+		if hook.ID != id {
+			continue
+		}
+		body, marshalErr := formatWebhookPayload(hook.Kind, webhookPayload{
+			Event:      webhookEventPing,
+			ChangeID:   0,
+			OccurredAt: time.Now().UTC().Format(time.RFC3339),
+			Calendar:   hook.HashedKey,
+			Match:      nil,
+		})
+		if marshalErr != nil {
+			return fmt.Errorf("failed to encode ping: %w", marshalErr)
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, webhookTimeout)
+		defer cancel()
+		client := newOutboundClient(webhookTimeout, m.Config.AllowPrivateReceivers)
+		return sendWebhook(pingCtx, client, hook.Url, hook.Secret, webhookEventPing, webhookEventPing, body)
+	}
+	return errWebhookNotFound
+}
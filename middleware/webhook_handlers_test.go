@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestWebhookManagementRequiresOwnerKey checks that the calendar hash alone, which anyone picking the
+// same selection gets, cannot list, remove or ping webhooks. The database is never reached.
+func TestWebhookManagementRequiresOwnerKey(t *testing.T) {
+	m := &Middleware{ //nolint:exhaustruct // The handlers reject the requests before using anything else
+		DBConn: dbtypes.New(newBlockingDB()),
+		Logger: zap.NewNop(),
+	}
+	router := gin.New()
+	router.GET("/api/webhooks/:hash", m.ListWebhooksHandler)
+	router.DELETE("/api/webhooks/:hash/:id", m.DeleteWebhookHandler)
+	router.POST("/api/webhooks/:hash/:id/ping", m.PingWebhookHandler)
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/webhooks/abc", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/webhooks/abc/1", nil),
+		httptest.NewRequest(http.MethodPost, "/api/webhooks/abc/1/ping", nil),
+	}
+	for _, request := range requests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: got status %d, want %d",
+				request.Method, request.URL.Path, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestWebhookResponseMasksURL(t *testing.T) {
+	hook := dbtypes.Webhook{ //nolint:exhaustruct // Only the fields shown by the API
+		ID:     1,
+		Kind:   webhookKindDiscord,
+		Url:    "https://discord.com/api/webhooks/123/secret-token",
+		Secret: "abc",
+	}
+	response := newWebhookResponse(hook, false)
+	if response.URL != "https://discord.com/…oken" {
+		t.Fatalf("URL = %q", response.URL)
+	}
+	if response.Secret != "" || response.OwnerKey != "" {
+		t.Fatalf("listing shows secrets: %+v", response)
+	}
+}
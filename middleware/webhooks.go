@@ -0,0 +1,345 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookPollInterval = 15 * time.Second
+	webhookTimeout      = 10 * time.Second
+	webhookBatchSize    = 100
+	// webhookLeaseSeconds hides a claimed delivery from other dispatchers while it is being sent.
+	webhookLeaseSeconds = 60
+	webhookMaxAttempts  = 8
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	// webhookErrorBodyLimit caps how much of a failed response is drained.
+	webhookErrorBodyLimit = 512
+
+	webhookSignatureHeader = "X-EsportsCalendar-Signature"
+	webhookTimestampHeader = "X-EsportsCalendar-Timestamp"
+	webhookEventHeader     = "X-EsportsCalendar-Event"
+	webhookDeliveryHeader  = "X-EsportsCalendar-Delivery"
+	webhookUserAgent       = "EsportsCalendar-Webhooks/1.0"
+
+	webhookEventPing = "ping"
+
+	// MATCH_CHANGES kinds written by the record_match_change trigger.
+	matchChangeRescheduled = "rescheduled"
+	matchChangeLive        = "live"
+	matchChangeFinished    = "finished"
+)
+
+// webhookMatch is the match section of a webhook payload.
+type webhookMatch struct {
+	ID                  int32  `json:"id"`
+	Name                string `json:"name"`
+	Game                string `json:"game"`
+	League              string `json:"league"`
+	Tournament          string `json:"tournament"`
+	Team1               string `json:"team1"`
+	Team1Image          string `json:"team1_image,omitempty"`
+	Team2               string `json:"team2"`
+	Team2Image          string `json:"team2_image,omitempty"`
+	ScheduledAt         string `json:"scheduled_at,omitempty"`
+	PreviousScheduledAt string `json:"previous_scheduled_at,omitempty"`
+	Finished            bool   `json:"finished"`
+	// Score is "2-1" for finished matches, "Finished" when the calendar hides scores and empty otherwise.
+	Score string `json:"score,omitempty"`
+	URL   string `json:"url"`
+}
+
+// webhookPayload is the signed JSON body POSTed to webhook endpoints.
+type webhookPayload struct {
+	Event      string        `json:"event"`
+	ChangeID   int64         `json:"change_id,omitempty"`
+	OccurredAt string        `json:"occurred_at"`
+	Calendar   string        `json:"calendar"`
+	Match      *webhookMatch `json:"match,omitempty"`
+}
+
+// webhookStatusError is a non-2xx answer of a receiver. Only the status is kept: the body is not
+// shown to the calendar's owner, so webhooks cannot be used to read responses of other servers.
+type webhookStatusError struct {
+	StatusCode int
+}
+
+func (e webhookStatusError) Error() string {
+	return fmt.Sprintf("receiver responded with status %d", e.StatusCode)
+}
+
+// webhookEvent maps a MATCH_CHANGES kind to the event name sent to receivers.
+func webhookEvent(kind string) string {
+	return "match." + kind
+}
+
+// signWebhook returns the signature header value for a payload: an HMAC-SHA256 over "<timestamp>.<body>".
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the delay before retrying a delivery that has failed attempts times.
+func webhookBackoff(attempts int32) time.Duration {
+	delay := webhookBaseBackoff
+	for range attempts {
+		delay *= 2
+		if delay >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return delay
+}
+
+// RunWebhookDispatcher turns detected match changes into webhook deliveries and sends due deliveries
+// until ctx is cancelled. Several instances can run side by side; rows are claimed with SKIP LOCKED.
+func (m *Middleware) RunWebhookDispatcher(ctx context.Context) {
+	m.Logger.Info("Webhook dispatcher started")
+	client := newOutboundClient(webhookTimeout, m.Config.AllowPrivateReceivers)
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.fanOutMatchChanges(ctx); err != nil && ctx.Err() == nil {
+			m.Logger.Error("Failed to fan out match changes", zap.Error(err))
+		}
+		if err := m.deliverWebhooks(ctx, client); err != nil && ctx.Err() == nil {
+			m.Logger.Error("Failed to deliver webhooks", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.Logger.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fanOutMatchChanges claims pending match changes, queues one delivery per interested webhook
+// and invalidates the cached feeds showing the changed matches.
+// Claiming and queueing share a transaction so a crash leaves the changes pending.
+func (m *Middleware) fanOutMatchChanges(ctx context.Context) error {
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx) // No-op after a successful commit
+	}()
+	queries := dbtypes.New(m.Metrics.instrumentDB(tx, m.Config.Database.QueryTimeout))
+
+	changes, err := queries.ClaimMatchChanges(ctx, webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim match changes: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	hooks, err := queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	subscriptions := make(map[string]calendarSubscription, len(hooks))
+	for _, hook := range hooks {
+		if _, decoded := subscriptions[hook.HashedKey]; decoded {
+			continue
+		}
+		sub, decodeErr := m.decodeSubscription(hook.HashedKey, hook.ValueList)
+		if decodeErr != nil {
+			m.Logger.Warn("Skipping webhooks of undecodable calendar",
+				zap.String("hash", hook.HashedKey),
+				zap.Error(decodeErr))
+			continue
+		}
+		subscriptions[hook.HashedKey] = sub
+	}
+
+	queued := 0
+	matches := make([]dbtypes.GetMatchForWebhookRow, 0, len(changes))
+	for _, change := range changes {
+		match, matchErr := queries.GetMatchForWebhook(ctx, change.MatchID)
+		if matchErr != nil {
+			return fmt.Errorf("failed to load match %d: %w", change.MatchID, matchErr)
+		}
+		matches = append(matches, match)
+		for _, hook := range hooks {
+			sub, ok := subscriptions[hook.HashedKey]
+			if !ok || !sub.includes(match.GameID, match.LeagueID, match.Team1ID, match.Team2ID, match.TournamentTier) {
+				continue
+			}
+			body, marshalErr := formatWebhookPayload(hook.Kind, m.matchChangePayload(change, match, sub))
+			if marshalErr != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", marshalErr)
+			}
+			if insertErr := queries.InsertWebhookDelivery(ctx, dbtypes.InsertWebhookDeliveryParams{
+				WebhookID: hook.ID,
+				ChangeID:  change.ID,
+				Event:     webhookEvent(change.Kind),
+				Payload:   body,
+			}); insertErr != nil {
+				return fmt.Errorf("failed to queue webhook delivery: %w", insertErr)
+			}
+			queued++
+		}
+	}
+
+	if commitErr := tx.Commit(ctx); commitErr != nil {
+		return fmt.Errorf("failed to commit webhook fan-out: %w", commitErr)
+	}
+	m.Logger.Info("Match changes dispatched",
+		zap.Int("changes", len(changes)),
+		zap.Int("deliveries", queued))
+
+	// Feeds showing the changed matches are stale now, on every instance
+	m.invalidateChangedCalendars(ctx, matches)
+	return nil
+}
+
+func (m *Middleware) matchChangePayload(
+	change dbtypes.ClaimMatchChangesRow,
+	match dbtypes.GetMatchForWebhookRow,
+	sub calendarSubscription,
+) webhookPayload {
+	payload := webhookMatch{
+		ID:                  match.ID,
+		Name:                match.Name,
+		Game:                match.GameName,
+		League:              match.LeagueName,
+		Tournament:          match.TournamentName,
+		Team1:               textOrTBD(match.Team1Name),
+		Team1Image:          match.Team1Image.String,
+		Team2:               textOrTBD(match.Team2Name),
+		Team2Image:          match.Team2Image.String,
+		ScheduledAt:         timestampRFC3339(match.ExpectedStartTime),
+		PreviousScheduledAt: timestampRFC3339(change.PreviousStartTime),
+		Finished:            match.Finished,
+		Score:               "",
+		URL:                 fmt.Sprintf("%s/%s.ics", m.BaseURL, sub.Hash),
+	}
+	if match.Finished {
+		payload.Score = "Finished"
+		if !sub.HideScores {
+			payload.Score = fmt.Sprintf("%d-%d", match.Team1Score, match.Team2Score)
+		}
+	}
+	return webhookPayload{
+		Event:      webhookEvent(change.Kind),
+		ChangeID:   change.ID,
+		OccurredAt: timestampRFC3339(change.CreatedAt),
+		Calendar:   sub.Hash,
+		Match:      &payload,
+	}
+}
+
+// deliverWebhooks sends every due delivery once, then deletes, reschedules or dead-letters it.
+func (m *Middleware) deliverWebhooks(ctx context.Context, client *http.Client) error {
+	deliveries, err := m.DBConn.ClaimDueWebhookDeliveries(ctx, dbtypes.ClaimDueWebhookDeliveriesParams{
+		LeaseSeconds: webhookLeaseSeconds,
+		LimitCount:   webhookBatchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		sendErr := sendWebhook(ctx, client, delivery.Url, delivery.Secret, delivery.Event,
+			strconv.FormatInt(delivery.ID, 10), delivery.Payload)
+		if sendErr == nil {
+			if deleteErr := m.DBConn.DeleteWebhookDelivery(ctx, delivery.ID); deleteErr != nil {
+				m.Logger.Error("Failed to delete delivered webhook", zap.Error(deleteErr))
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			// Shutting down: the lease expires and another run retries the delivery
+			return nil
+		}
+
+		m.Logger.Warn("Webhook delivery failed",
+			zap.Int64("delivery_id", delivery.ID),
+			zap.Int32("webhook_id", delivery.WebhookID),
+			zap.Int32("attempts", delivery.Attempts+1),
+			zap.Error(sendErr))
+		if delivery.Attempts+1 >= webhookMaxAttempts {
+			if dlErr := m.DBConn.DeadLetterWebhookDelivery(ctx, dbtypes.DeadLetterWebhookDeliveryParams{
+				ID:        delivery.ID,
+				LastError: sendErr.Error(),
+			}); dlErr != nil {
+				m.Logger.Error("Failed to dead-letter webhook delivery", zap.Error(dlErr))
+			}
+			continue
+		}
+		if rescheduleErr := m.DBConn.RescheduleWebhookDelivery(ctx, dbtypes.RescheduleWebhookDeliveryParams{
+			DelaySeconds: int32(webhookBackoff(delivery.Attempts).Seconds()),
+			LastError:    sendErr.Error(),
+			ID:           delivery.ID,
+		}); rescheduleErr != nil {
+			m.Logger.Error("Failed to reschedule webhook delivery", zap.Error(rescheduleErr))
+		}
+	}
+	return nil
+}
+
+// sendWebhook POSTs a signed payload. Any non-2xx response is an error.
+func sendWebhook(
+	ctx context.Context,
+	client *http.Client,
+	url, secret, event, deliveryID string,
+	body []byte,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(webhookEventHeader, event)
+	req.Header.Set(webhookDeliveryHeader, deliveryID)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhook(secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookErrorBodyLimit)) // Lets the connection be reused
+		return webhookStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+func textOrTBD(text pgtype.Text) string {
+	if text.Valid && text.String != "" {
+		return text.String
+	}
+	return "TBD"
+}
+
+func timestampRFC3339(ts pgtype.Timestamp) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.UTC().Format(time.RFC3339)
+}
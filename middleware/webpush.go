@@ -0,0 +1,383 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feimaomiao/esportscalendar/dbtypes"
+	"go.uber.org/zap"
+)
+
+const (
+	pushTimeout = 10 * time.Second
+	// vapidTokenLifetime must stay below the 24 hours push services accept.
+	vapidTokenLifetime = 12 * time.Hour
+	// pushRecordSize is the aes128gcm record size; reminders always fit in a single record.
+	pushRecordSize = 4096
+	pushSaltBytes  = 16
+	pushAuthBytes  = 16
+	pushKeyBytes   = 16
+	pushNonceBytes = 12
+	gcmTagBytes    = 16
+	// pushPaddingDelimiter marks the last record of an aes128gcm body (RFC 8188).
+	pushPaddingDelimiter = 0x02
+	// p256PublicKeyBytes is the length of an uncompressed P-256 point.
+	p256PublicKeyBytes = 65
+	p256ScalarBytes    = 32
+	hkdfMaxLength      = sha256.Size
+	// pushErrorBodyLimit caps how much of a failed push service response is logged.
+	pushErrorBodyLimit = 512
+)
+
+var (
+	// errPushGone is returned when the push service reports a subscription as expired or unsubscribed.
+	errPushGone = errors.New("push subscription is gone")
+	// errPushDisabled is returned by the push endpoints when no VAPID key could be loaded.
+	errPushDisabled = errors.New("web push is not configured on this server")
+	errPushEndpoint = errors.New("endpoint is not a known push service")
+)
+
+// pushStatusError is a non-2xx answer of a push service other than 404 and 410. The response body is
+// only logged, as the error is shown on the settings page.
+type pushStatusError struct {
+	StatusCode int
+}
+
+func (e pushStatusError) Error() string {
+	return fmt.Sprintf("push service responded with status %d", e.StatusCode)
+}
+
+// WebPush sends encrypted Web Push messages (RFC 8291) authenticated with VAPID (RFC 8292).
+type WebPush struct {
+	privateKey *ecdsa.PrivateKey
+	// publicKey is the base64url uncompressed public key, the applicationServerKey of browser subscriptions.
+	publicKey string
+	subject   string
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// pushTarget is where and for whom a push message is encrypted.
+type pushTarget struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// NewWebPush signs with the configured VAPID private key. Without one, a key pair is generated and
+// stored in the database so that every instance signs with the same key. The subject defaults to baseURL.
+// allowPrivate lets messages reach local push service stubs, see Config.AllowPrivateReceivers.
+func NewWebPush(
+	ctx context.Context,
+	logger *zap.Logger,
+	queries *dbtypes.Queries,
+	vapid VAPIDConfig,
+	baseURL string,
+	allowPrivate bool,
+) (*WebPush, error) {
+	encoded := vapid.PrivateKey
+	source := "configuration"
+	if encoded == "" {
+		generated, err := ecdh.P256().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate VAPID key: %w", err)
+		}
+		// Keeps the stored pair when another instance generated one first
+		keys, err := queries.EnsureVAPIDKeys(ctx, dbtypes.EnsureVAPIDKeysParams{
+			PublicKey:  base64.RawURLEncoding.EncodeToString(generated.PublicKey().Bytes()),
+			PrivateKey: base64.RawURLEncoding.EncodeToString(generated.Bytes()),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to store VAPID keys: %w", err)
+		}
+		encoded = keys.PrivateKey
+		source = "database"
+	}
+
+	privateKey, err := parseVAPIDPrivateKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := privateKey.PublicKey.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	subject := vapid.Subject
+	if subject == "" {
+		subject = baseURL
+	}
+
+	push := &WebPush{
+		privateKey: privateKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(publicKey.Bytes()),
+		subject:    subject,
+		client:     newOutboundClient(pushTimeout, allowPrivate),
+		logger:     logger,
+	}
+	logger.Info("Web Push initialized",
+		zap.String("key_source", source),
+		zap.String("public_key", push.publicKey),
+		zap.String("subject", subject))
+	return push, nil
+}
+
+// PublicKey returns the applicationServerKey browsers subscribe with.
+func (wp *WebPush) PublicKey() string {
+	return wp.publicKey
+}
+
+// parseVAPIDPrivateKey decodes a raw base64url P-256 scalar, the format web-push tooling generates.
+func parseVAPIDPrivateKey(encoded string) (*ecdsa.PrivateKey, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil || len(raw) != p256ScalarBytes {
+		return nil, errors.New("invalid VAPID private key: expected 32 base64url-encoded bytes")
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	// crypto/ecdsa has no raw scalar import, so the key takes a round trip through PKCS #8
+	der, err := x509.MarshalPKCS8PrivateKey(ecdhKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid VAPID private key: not an ECDSA key")
+	}
+	return key, nil
+}
+
+// Send encrypts a payload for a subscription and posts it to the push service. The TTL tells the
+// service how long to keep an undelivered message; a non-empty topic replaces earlier undelivered
+// messages with the same topic.
+func (wp *WebPush) Send(ctx context.Context, target pushTarget, payload []byte, ttl time.Duration, topic string) error {
+	body, err := encryptPushPayload(target.P256dh, target.Auth, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := wp.vapidAuthorization(target.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+	if topic != "" {
+		req.Header.Set("Topic", topic)
+	}
+
+	resp, err := wp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errPushGone
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return nil
+	default:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, pushErrorBodyLimit))
+		wp.logger.Warn("Push service rejected a message",
+			zap.Int("status", resp.StatusCode),
+			zap.String("response", strings.TrimSpace(string(detail))))
+		return pushStatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// vapidAuthorization builds the "vapid" Authorization header: an ES256 JWT for the push service's origin.
+func (wp *WebPush) vapidAuthorization(endpoint string, now time.Time) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+	claims, err := json.Marshal(struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}{
+		Aud: parsed.Scheme + "://" + parsed.Host,
+		Exp: now.Add(vapidTokenLifetime).Unix(),
+		Sub: wp.subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode VAPID claims: %w", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, wp.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	// JWS wants the fixed-size r || s encoding rather than ASN.1
+	signature := make([]byte, 2*p256ScalarBytes)
+	r.FillBytes(signature[:p256ScalarBytes])
+	s.FillBytes(signature[p256ScalarBytes:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, wp.publicKey), nil
+}
+
+// encryptPushPayload encrypts a payload for a browser subscription with the aes128gcm
+// content coding, as a single record (RFC 8291 section 3.4).
+func encryptPushPayload(p256dh, auth string, payload []byte) ([]byte, error) {
+	clientPublic, authSecret, err := decodePushKeys(p256dh, auth)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload)+1+gcmTagBytes > pushRecordSize {
+		return nil, fmt.Errorf("push payload of %d bytes is too large", len(payload))
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate push key: %w", err)
+	}
+	salt := make([]byte, pushSaltBytes)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate push salt: %w", err)
+	}
+	return sealPushPayload(clientPublic, authSecret, serverKey, salt, payload)
+}
+
+// sealPushPayload encrypts a payload with a given server key pair and salt, which must be fresh for
+// every message.
+func sealPushPayload(
+	clientPublic *ecdh.PublicKey,
+	authSecret []byte,
+	serverKey *ecdh.PrivateKey,
+	salt, payload []byte,
+) ([]byte, error) {
+	sharedSecret, err := serverKey.ECDH(clientPublic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push secret: %w", err)
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), clientPublic.Bytes()...)
+	keyInfo = append(keyInfo, serverPublic...)
+	inputKey := hkdfSHA256(authSecret, sharedSecret, keyInfo, hkdfMaxLength)
+
+	contentKey := hkdfSHA256(salt, inputKey, []byte("Content-Encoding: aes128gcm\x00"), pushKeyBytes)
+	nonce := hkdfSHA256(salt, inputKey, []byte("Content-Encoding: nonce\x00"), pushNonceBytes)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create push cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create push cipher: %w", err)
+	}
+
+	// Header: salt, record size, key ID length and the server's public key as key ID
+	body := make([]byte, 0, pushSaltBytes+4+1+len(serverPublic)+len(payload)+1+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, pushRecordSize)
+	body = append(body, byte(len(serverPublic)))
+	body = append(body, serverPublic...)
+	record := append(append([]byte{}, payload...), pushPaddingDelimiter)
+	return gcm.Seal(body, nonce, record, nil), nil
+}
+
+// validatePushEndpoint accepts https URLs of known push services, or any URL allowed by
+// validateOutboundURL when allowPrivate is set for development.
+func validatePushEndpoint(raw string, allowPrivate bool) error {
+	if err := validateOutboundURL(raw, allowPrivate); err != nil {
+		return err
+	}
+	if allowPrivate {
+		return nil
+	}
+	parsed, _ := url.Parse(raw) // Checked by validateOutboundURL
+	if parsed.Scheme != "https" {
+		return errors.New("endpoint must use https")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if !isPushServiceHost(host) {
+		return fmt.Errorf("%w: %s", errPushEndpoint, host)
+	}
+	return nil
+}
+
+// isPushServiceHost reports whether host belongs to the push service of a major browser, so push
+// delivery cannot be aimed at arbitrary servers.
+func isPushServiceHost(host string) bool {
+	for _, service := range []string{
+		"fcm.googleapis.com",        // Chrome, Edge on Android, Opera, Samsung Internet
+		"push.services.mozilla.com", // Firefox
+		"notify.windows.com",        // Edge on Windows
+		"push.apple.com",            // Safari
+	} {
+		if host == service || strings.HasSuffix(host, "."+service) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePushKeys decodes the p256dh and auth keys of a browser push subscription.
+func decodePushKeys(p256dh, auth string) (*ecdh.PublicKey, []byte, error) {
+	rawPublic, err := decodeBase64URL(p256dh)
+	if err != nil || len(rawPublic) != p256PublicKeyBytes {
+		return nil, nil, errors.New("invalid p256dh key")
+	}
+	clientPublic, err := ecdh.P256().NewPublicKey(rawPublic)
+	if err != nil {
+		return nil, nil, errors.New("invalid p256dh key")
+	}
+	authSecret, err := decodeBase64URL(auth)
+	if err != nil || len(authSecret) != pushAuthBytes {
+		return nil, nil, errors.New("invalid auth secret")
+	}
+	return clientPublic, authSecret, nil
+}
+
+// hkdfSHA256 derives up to 32 bytes with HKDF-SHA256 (RFC 5869); one expand block is always enough here.
+func hkdfSHA256(salt, secret, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers and tools differ.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(value), "="))
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func mustDecodeBase64URL(t *testing.T, encoded string) []byte {
+	t.Helper()
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		t.Fatalf("decode %q: %v", encoded, err)
+	}
+	return raw
+}
+
+// TestSealPushPayloadRFC8291 checks the encryption against the example of RFC 8291 Appendix A.
+func TestSealPushPayloadRFC8291(t *testing.T) {
+	const (
+		plaintext = "When I grow up, I want to be a watermelon"
+		asPrivate = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+		uaPublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+		salt      = "DGv6ra1nlYgDCS1FRnbzlw"
+		auth      = "BTBZMqHH6r4Tts7J_aSIgg"
+		want      = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6Tlz" +
+			"AC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	)
+	clientPublic, authSecret, err := decodePushKeys(uaPublic, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey, err := ecdh.P256().NewPrivateKey(mustDecodeBase64URL(t, asPrivate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := sealPushPayload(clientPublic, authSecret, serverKey, mustDecodeBase64URL(t, salt), []byte(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Fatalf("ciphertext mismatch\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestEncryptPushPayloadUsesFreshKeys(t *testing.T) {
+	clientKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256dh := base64.RawURLEncoding.EncodeToString(clientKey.PublicKey().Bytes())
+	auth := base64.RawURLEncoding.EncodeToString(make([]byte, pushAuthBytes))
+
+	first, err := encryptPushPayload(p256dh, auth, []byte("reminder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := encryptPushPayload(p256dh, auth, []byte("reminder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first[:pushSaltBytes], second[:pushSaltBytes]) {
+		t.Fatal("two messages share a salt")
+	}
+	if _, err = encryptPushPayload(p256dh, auth, make([]byte, pushRecordSize)); err == nil {
+		t.Fatal("oversized payload was accepted")
+	}
+}
+
+// TestVAPIDAuthorization checks the claims and the ES256 signature of the VAPID token (RFC 8292).
+func TestVAPIDAuthorization(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := privateKey.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wp := &WebPush{
+		privateKey: privateKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(publicKey.Bytes()),
+		subject:    "mailto:admin@example.com",
+		client:     nil,
+		logger:     zap.NewNop(),
+	}
+	now := time.Unix(1700000000, 0)
+
+	authorization, err := wp.vapidAuthorization("https://fcm.googleapis.com/fcm/send/abc:def", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, key, ok := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	if !ok || !strings.HasPrefix(authorization, "vapid t=") {
+		t.Fatalf("malformed header %q", authorization)
+	}
+	if key != wp.publicKey {
+		t.Fatalf("k = %s, want %s", key, wp.publicKey)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts, want 3", len(parts))
+	}
+	var header struct {
+		Typ string `json:"typ"`
+		Alg string `json:"alg"`
+	}
+	if err = json.Unmarshal(mustDecodeBase64URL(t, parts[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Alg != "ES256" || header.Typ != "JWT" {
+		t.Fatalf("header = %+v, want ES256 JWT", header)
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err = json.Unmarshal(mustDecodeBase64URL(t, parts[1]), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Aud != "https://fcm.googleapis.com" || claims.Sub != wp.subject ||
+		claims.Exp != now.Add(vapidTokenLifetime).Unix() {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	signature := mustDecodeBase64URL(t, parts[2])
+	if len(signature) != 2*p256ScalarBytes {
+		t.Fatalf("signature has %d bytes, want %d", len(signature), 2*p256ScalarBytes)
+	}
+	r := new(big.Int).SetBytes(signature[:p256ScalarBytes])
+	s := new(big.Int).SetBytes(signature[p256ScalarBytes:])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(&privateKey.PublicKey, digest[:], r, s) {
+		t.Fatal("signature does not verify with the VAPID public key")
+	}
+}
+
+func TestValidatePushEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint     string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc", false, false},
+		{"https://updates.push.services.mozilla.com/wpush/v2/abc", false, false},
+		{"https://wns2-par02p.notify.windows.com/w/?token=abc", false, false},
+		{"https://web.push.apple.com/abc", false, false},
+		{"http://fcm.googleapis.com/fcm/send/abc", false, true},
+		{"https://example.com/push", false, true},
+		{"https://fcm.googleapis.com.example.com/push", false, true},
+		{"https://127.0.0.1/push", false, true},
+		{"http://localhost:9000/push", true, false},
+	}
+	for _, tt := range tests {
+		err := validatePushEndpoint(tt.endpoint, tt.allowPrivate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePushEndpoint(%q, %v) = %v, want error %v", tt.endpoint, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// xCalNamespace is the XML namespace of xCal documents (RFC 6321 section 3).
+const xCalNamespace = "urn:ietf:params:xml:ns:icalendar-2.0"
+
+// generateXCal serializes a calendar feed as xCal (RFC 6321).
+func generateXCal(feed calendarFeed) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	root := xml.StartElement{
+		Name: xml.Name{Space: "", Local: "icalendar"},
+		Attr: []xml.Attr{{Name: xml.Name{Space: "", Local: "xmlns"}, Value: xCalNamespace}},
+	}
+
+	tokens := []xml.Token{root, xCalStart("vcalendar")}
+	tokens = append(tokens, xCalPropertyTokens(feed.properties())...)
+	tokens = append(tokens, xCalStart("components"))
+	for _, event := range feed.Events {
+		tokens = append(tokens, xCalStart("vevent"))
+		tokens = append(tokens, xCalPropertyTokens(event.properties())...)
+		tokens = append(tokens, xCalEnd("vevent"))
+	}
+	tokens = append(tokens, xCalEnd("components"), xCalEnd("vcalendar"), root.End())
+
+	for _, token := range tokens {
+		if err := enc.EncodeToken(token); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// xCalPropertyTokens wraps properties in a <properties> element,
+// each as <name><type>value</type></name>.
+func xCalPropertyTokens(props []calendarProperty) []xml.Token {
+	tokens := []xml.Token{xCalStart("properties")}
+	for _, prop := range props {
+		value := prop.Value
+		if prop.ValueType == valueTypeDateTime {
+			value = prop.Time.UTC().Format(structuredDateTimeLayout)
+		}
+		tokens = append(tokens,
+			xCalStart(prop.Name),
+			xCalStart(prop.ValueType),
+			xml.CharData(value),
+			xCalEnd(prop.ValueType),
+			xCalEnd(prop.Name),
+		)
+	}
+	return append(tokens, xCalEnd("properties"))
+}
+
+func xCalStart(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Space: "", Local: name}, Attr: nil}
+}
+
+func xCalEnd(name string) xml.EndElement {
+	return xml.EndElement{Name: xml.Name{Space: "", Local: name}}
+}
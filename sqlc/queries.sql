@@ -129,6 +129,16 @@ FROM teams
 WHERE game_id = $1
 ORDER BY name ASC;
 
+-- name: GetLeagueGameIDs :many
+SELECT id, game_id
+FROM leagues
+WHERE id = ANY(@ids::int[]);
+
+-- name: GetTeamGameIDs :many
+SELECT id, game_id
+FROM teams
+WHERE id = ANY(@ids::int[]);
+
 -- ============================================================================
 -- Match Selection Queries (for Preview)
 -- ============================================================================
@@ -240,3 +250,350 @@ WHERE hashed_key = $1;
 UPDATE url_mappings
 SET access_count = access_count + 1, accessed_at = CURRENT_TIMESTAMP
 WHERE hashed_key = $1;
+
+-- name: ListHotURLMappings :many
+SELECT hashed_key, value_list
+FROM url_mappings
+WHERE accessed_at > CURRENT_TIMESTAMP - make_interval(mins => sqlc.arg(active_minutes)::int)
+ORDER BY access_count DESC, accessed_at DESC
+LIMIT sqlc.arg(limit_count)::int;
+
+-- name: CountURLMappings :one
+SELECT COUNT(*) FROM url_mappings;
+
+-- ============================================================================
+-- Preview Selection Queries (for Preview Links)
+-- ============================================================================
+
+-- name: UpsertPreviewSelection :exec
+INSERT INTO preview_selections (hashed_key, value_list, expires_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP + make_interval(mins => sqlc.arg(ttl_minutes)::int))
+ON CONFLICT (hashed_key) DO UPDATE SET expires_at = EXCLUDED.expires_at;
+
+-- name: GetPreviewSelection :one
+SELECT value_list
+FROM preview_selections
+WHERE hashed_key = $1 AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: DeleteExpiredPreviewSelections :execrows
+DELETE FROM preview_selections
+WHERE expires_at <= CURRENT_TIMESTAMP;
+
+-- ============================================================================
+-- Match Revision Queries (for CalDAV Sync)
+-- ============================================================================
+
+-- name: GetLatestMatchRevision :one
+SELECT COALESCE(MAX(revision), 0)::bigint AS revision
+FROM match_revisions;
+
+-- name: GetMatchRevisionsSince :many
+SELECT r.match_id, MAX(r.revision)::bigint AS revision
+FROM match_revisions r
+JOIN matches m ON r.match_id = m.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE r.revision > sqlc.arg(since)::bigint
+    AND m.game_id = ANY(sqlc.arg(game_ids)::int[])
+    AND (
+        (CARDINALITY(sqlc.arg(team_ids)::int[]) > 0 AND (m.team1_id = ANY(sqlc.arg(team_ids)::int[]) OR m.team2_id = ANY(sqlc.arg(team_ids)::int[])))
+        OR (CARDINALITY(sqlc.arg(league_ids)::int[]) > 0 AND m.league_id = ANY(sqlc.arg(league_ids)::int[]) AND COALESCE(tour.tier, 0) <= sqlc.arg(max_tier)::int)
+    )
+GROUP BY r.match_id;
+
+-- name: DeleteOldMatchRevisions :execrows
+-- Revisions older than every valid sync token. The latest revision is kept, so new tokens never go back.
+DELETE FROM match_revisions
+WHERE changed_at < CURRENT_TIMESTAMP - make_interval(hours => sqlc.arg(max_age_hours)::int)
+    AND revision < (SELECT MAX(revision) FROM match_revisions);
+
+-- name: GetMatchesLeftCalendarWindow :many
+-- Matches of a selection that fell out of the calendar window since issued_at, a Unix time.
+SELECT m.id
+FROM matches m
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE m.expected_start_time >= TO_TIMESTAMP(sqlc.arg(issued_at)::bigint) - INTERVAL '3 days'
+    AND m.expected_start_time < NOW() - INTERVAL '3 days'
+    AND m.game_id = ANY(sqlc.arg(game_ids)::int[])
+    AND (
+        (CARDINALITY(sqlc.arg(team_ids)::int[]) > 0 AND (m.team1_id = ANY(sqlc.arg(team_ids)::int[]) OR m.team2_id = ANY(sqlc.arg(team_ids)::int[])))
+        OR (CARDINALITY(sqlc.arg(league_ids)::int[]) > 0 AND m.league_id = ANY(sqlc.arg(league_ids)::int[]) AND COALESCE(tour.tier, 0) <= sqlc.arg(max_tier)::int)
+    );
+
+-- ============================================================================
+-- Webhook Queries (for Match Change Notifications)
+-- ============================================================================
+
+-- name: DeleteDispatchedMatchChanges :execrows
+-- Changes already fanned out whose deliveries have all been sent or dead-lettered.
+DELETE FROM match_changes c
+WHERE c.dispatched
+    AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.change_id = c.id);
+
+-- name: InsertWebhook :one
+INSERT INTO webhooks (hashed_key, url, secret, kind, owner_key)
+VALUES ($1, $2, $3, $4, $5::text)
+RETURNING id, hashed_key, url, secret, created_at, kind, owner_key;
+
+-- name: ListWebhooksByOwner :many
+SELECT id, hashed_key, url, secret, created_at, kind, owner_key
+FROM webhooks
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY id;
+
+-- name: DeleteOwnedWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text;
+
+-- name: ListWebhookSubscriptions :many
+SELECT w.id, w.hashed_key, w.kind, u.value_list
+FROM webhooks w
+JOIN url_mappings u ON w.hashed_key = u.hashed_key
+ORDER BY w.id;
+
+-- name: ClaimMatchChanges :many
+UPDATE match_changes
+SET dispatched = TRUE
+WHERE id IN (
+    SELECT id FROM match_changes
+    WHERE NOT dispatched
+    ORDER BY id
+    LIMIT sqlc.arg(limit_count)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, match_id, kind, previous_start_time, created_at;
+
+-- name: GetMatchForWebhook :one
+SELECT
+    m.id, m.name, m.expected_start_time, m.finished,
+    m.team1_id, m.team2_id, m.team1_score, m.team2_score,
+    m.game_id, m.league_id,
+    g.name AS game_name,
+    l.name AS league_name,
+    tour.name AS tournament_name,
+    tour.tier AS tournament_tier,
+    t1.name AS team1_name, t1.image_link AS team1_image,
+    t2.name AS team2_name, t2.image_link AS team2_image
+FROM matches m
+JOIN games g ON m.game_id = g.id
+JOIN leagues l ON m.league_id = l.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+LEFT JOIN teams t1 ON m.team1_id = t1.id
+LEFT JOIN teams t2 ON m.team2_id = t2.id
+WHERE m.id = $1;
+
+-- name: InsertWebhookDelivery :exec
+INSERT INTO webhook_deliveries (webhook_id, change_id, event, payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (webhook_id, change_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+FROM webhooks w
+WHERE d.webhook_id = w.id
+    AND d.id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg(limit_count)::int
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret;
+
+-- name: DeleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: RescheduleWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg(delay_seconds)::int),
+    last_error = sqlc.arg(last_error)::text
+WHERE id = sqlc.arg(id);
+
+-- name: DeadLetterWebhookDelivery :exec
+WITH failed AS (
+    DELETE FROM webhook_deliveries
+    WHERE id = sqlc.arg(id)
+    RETURNING webhook_id, change_id, event, payload, attempts
+)
+INSERT INTO webhook_dead_letters (webhook_id, change_id, event, payload, attempts, last_error)
+SELECT webhook_id, change_id, event, payload, attempts + 1, sqlc.arg(last_error)::text
+FROM failed;
+
+-- name: ListWebhookDeadLettersByOwner :many
+SELECT dl.id, dl.webhook_id, dl.event, dl.attempts, dl.last_error, dl.failed_at
+FROM webhook_dead_letters dl
+JOIN webhooks w ON dl.webhook_id = w.id
+WHERE w.hashed_key = $1 AND w.owner_key = $2::text
+ORDER BY dl.failed_at DESC
+LIMIT 100;
+
+-- ============================================================================
+-- Email Digest Queries (for Weekly Emails)
+-- ============================================================================
+
+-- name: UpsertEmailSubscription :one
+-- Returns no row when the address was signed up for the calendar with another owner key.
+INSERT INTO email_subscriptions (hashed_key, email, timezone, token, owner_key)
+VALUES ($1, $2, $3, $4, $5::text)
+ON CONFLICT (hashed_key, email) DO UPDATE
+SET timezone = EXCLUDED.timezone
+WHERE email_subscriptions.owner_key = EXCLUDED.owner_key
+RETURNING id, hashed_key, email, timezone, token, confirmed_at, last_sent_at, created_at, confirmation_sent_at, owner_key;
+
+-- name: ClaimEmailConfirmation :execrows
+-- Records that the confirmation mail of a pending subscription is being sent, unless one
+-- went out within the cooldown. Times are stored in UTC.
+UPDATE email_subscriptions
+SET confirmation_sent_at = NOW() AT TIME ZONE 'UTC'
+WHERE id = sqlc.arg(id)
+  AND confirmed_at IS NULL
+  AND (confirmation_sent_at IS NULL
+      OR confirmation_sent_at < NOW() AT TIME ZONE 'UTC' - make_interval(secs => sqlc.arg(cooldown_seconds)::int));
+
+-- name: ReleaseEmailConfirmation :exec
+-- Lets the confirmation mail be sent again right away after a failed send.
+UPDATE email_subscriptions
+SET confirmation_sent_at = NULL
+WHERE id = $1;
+
+-- name: ConfirmEmailSubscription :one
+UPDATE email_subscriptions
+SET confirmed_at = COALESCE(confirmed_at, NOW() AT TIME ZONE 'UTC')
+WHERE token = $1
+RETURNING hashed_key, email;
+
+-- name: DeleteEmailSubscriptionByToken :one
+DELETE FROM email_subscriptions
+WHERE token = $1
+RETURNING hashed_key;
+
+-- name: DeleteEmailSubscription :execrows
+DELETE FROM email_subscriptions
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text;
+
+-- name: ListEmailSubscriptionsByOwner :many
+SELECT id, hashed_key, email, timezone, token, confirmed_at, last_sent_at, created_at, confirmation_sent_at, owner_key
+FROM email_subscriptions
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY created_at;
+
+-- name: ClaimDueEmailDigests :many
+-- Claims confirmed subscriptions whose local time is past the send hour on Monday
+-- and that have not been mailed since that Monday began. Times are stored in UTC.
+UPDATE email_subscriptions e
+SET last_sent_at = NOW() AT TIME ZONE 'UTC'
+FROM url_mappings u
+WHERE e.hashed_key = u.hashed_key
+  AND e.id IN (
+    SELECT s.id FROM email_subscriptions s
+    WHERE s.confirmed_at IS NOT NULL
+      AND NOW() AT TIME ZONE s.timezone
+          >= date_trunc('week', NOW() AT TIME ZONE s.timezone) + make_interval(hours => sqlc.arg(send_hour)::int)
+      AND NOW() AT TIME ZONE s.timezone
+          < date_trunc('week', NOW() AT TIME ZONE s.timezone) + INTERVAL '1 day'
+      AND (s.last_sent_at IS NULL
+          OR s.last_sent_at AT TIME ZONE 'UTC' < date_trunc('week', NOW() AT TIME ZONE s.timezone) AT TIME ZONE s.timezone)
+    ORDER BY s.id
+    LIMIT sqlc.arg(limit_count)::int
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING e.id, e.email, e.timezone, e.token, e.hashed_key, u.value_list;
+
+-- name: ReleaseEmailDigest :exec
+-- Makes a claimed digest due again after a failed send.
+UPDATE email_subscriptions
+SET last_sent_at = NULL
+WHERE id = $1;
+
+-- ============================================================================
+-- Web Push Queries (for Match Reminders)
+-- ============================================================================
+
+-- name: EnsureVAPIDKeys :one
+INSERT INTO vapid_keys (id, public_key, private_key)
+VALUES (1, $1, $2)
+ON CONFLICT (id) DO UPDATE
+SET id = vapid_keys.id
+RETURNING public_key, private_key;
+
+-- name: UpsertPushSubscription :one
+-- Only the browser knows its endpoint, so subscribing again moves the subscription to its current owner key.
+INSERT INTO push_subscriptions (hashed_key, endpoint, p256dh, auth, lead_minutes, owner_key)
+VALUES ($1, $2, $3, $4, $5, $6::text)
+ON CONFLICT (hashed_key, endpoint) DO UPDATE
+SET p256dh = EXCLUDED.p256dh,
+    auth = EXCLUDED.auth,
+    lead_minutes = EXCLUDED.lead_minutes,
+    owner_key = EXCLUDED.owner_key
+RETURNING id, hashed_key, endpoint, p256dh, auth, lead_minutes, created_at, owner_key;
+
+-- name: ListPushSubscriptionsByOwner :many
+SELECT id, hashed_key, endpoint, p256dh, auth, lead_minutes, created_at, owner_key
+FROM push_subscriptions
+WHERE hashed_key = $1 AND owner_key = $2::text
+ORDER BY id;
+
+-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE id = $1 AND hashed_key = $2 AND owner_key = $3::text;
+
+-- name: DeletePushSubscriptionByEndpoint :execrows
+DELETE FROM push_subscriptions
+WHERE hashed_key = $1 AND endpoint = $2;
+
+-- name: DeletePushEndpoint :exec
+DELETE FROM push_subscriptions
+WHERE endpoint = $1;
+
+-- name: ListPushReminderTargets :many
+SELECT p.id, p.hashed_key, p.endpoint, p.p256dh, p.auth, p.lead_minutes, u.value_list
+FROM push_subscriptions p
+JOIN url_mappings u ON p.hashed_key = u.hashed_key
+ORDER BY p.hashed_key, p.id;
+
+-- name: ClaimPushReminder :execrows
+INSERT INTO push_reminders (subscription_id, match_id)
+VALUES ($1, $2)
+ON CONFLICT (subscription_id, match_id) DO NOTHING;
+
+-- name: ReleasePushReminder :exec
+DELETE FROM push_reminders
+WHERE subscription_id = $1 AND match_id = $2;
+
+-- name: DeleteOldPushReminders :exec
+DELETE FROM push_reminders
+WHERE sent_at < NOW() - INTERVAL '7 days';
+
+-- ============================================================================
+-- Live Score Queries (for Preview Streams)
+-- ============================================================================
+
+-- name: ListMatchUpdatesSince :many
+SELECT
+    m.id, m.finished, m.expected_start_time,
+    m.team1_id, m.team2_id, m.team1_score, m.team2_score,
+    m.game_id, m.league_id,
+    tour.tier AS tournament_tier,
+    MAX(r.revision)::bigint AS revision
+FROM match_revisions r
+JOIN matches m ON r.match_id = m.id
+JOIN tournaments tour ON m.tournament_id = tour.id
+WHERE r.revision > sqlc.arg(since)::bigint
+GROUP BY m.id, tour.tier
+ORDER BY revision ASC
+LIMIT sqlc.arg(limit_count)::int;
+
+-- ============================================================================
+-- Health Queries (for Readiness and Diagnostics)
+-- ============================================================================
+
+-- name: ListMissingTables :many
+SELECT t.name::text AS table_name
+FROM unnest(sqlc.arg(table_names)::text[]) AS t(name)
+WHERE to_regclass(t.name) IS NULL;
+
+-- name: GetLatestMatchChange :one
+SELECT MAX(changed_at)::timestamp AS changed_at
+FROM match_revisions;
@@ -75,4 +75,183 @@ CREATE TABLE IF NOT EXISTS URL_MAPPINGS(
     access_count INT NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-)
\ No newline at end of file
+);
+
+-- Validated selections of preview pages, keyed by their hash so preview URLs stay short.
+-- Rows expire; exporting a selection stores it in URL_MAPPINGS instead.
+CREATE TABLE IF NOT EXISTS PREVIEW_SELECTIONS(
+    hashed_key VARCHAR(16) NOT NULL PRIMARY KEY,
+    value_list JSON NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Change log of match revisions, appended by a trigger on every insert or
+-- effective update of MATCHES. Used as the source of CalDAV sync tokens.
+CREATE TABLE IF NOT EXISTS MATCH_REVISIONS(
+    revision BIGSERIAL PRIMARY KEY,
+    match_id INT NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (match_id) REFERENCES MATCHES(id)
+);
+
+CREATE INDEX IF NOT EXISTS match_revisions_match_id_idx ON MATCH_REVISIONS(match_id);
+
+CREATE OR REPLACE FUNCTION record_match_revision() RETURNS TRIGGER AS $$
+BEGIN
+    -- Upserts that rewrite identical values do not produce a new revision
+    IF TG_OP = 'UPDATE' AND NEW IS NOT DISTINCT FROM OLD THEN
+        RETURN NEW;
+    END IF;
+    INSERT INTO match_revisions (match_id) VALUES (NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS matches_record_revision ON MATCHES;
+CREATE TRIGGER matches_record_revision
+AFTER INSERT OR UPDATE ON MATCHES
+FOR EACH ROW EXECUTE FUNCTION record_match_revision();
+
+-- Notable match changes detected by a trigger in the MATCHES upsert path and
+-- fanned out to webhooks by the dispatcher:
+--   rescheduled  expected_start_time moved
+--   live         actual_game_time set on an unfinished match
+--   finished     finished flipped to true
+CREATE TABLE IF NOT EXISTS MATCH_CHANGES(
+    id BIGSERIAL PRIMARY KEY,
+    match_id INT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    previous_start_time TIMESTAMP,
+    dispatched BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (match_id) REFERENCES MATCHES(id)
+);
+
+CREATE INDEX IF NOT EXISTS match_changes_pending_idx ON MATCH_CHANGES(id) WHERE NOT dispatched;
+
+CREATE OR REPLACE FUNCTION record_match_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.expected_start_time IS DISTINCT FROM OLD.expected_start_time THEN
+        INSERT INTO match_changes (match_id, kind, previous_start_time)
+        VALUES (NEW.id, 'rescheduled', OLD.expected_start_time);
+    END IF;
+    IF NOT NEW.finished AND OLD.actual_game_time = 0 AND NEW.actual_game_time <> 0 THEN
+        INSERT INTO match_changes (match_id, kind) VALUES (NEW.id, 'live');
+    END IF;
+    IF NEW.finished AND NOT OLD.finished THEN
+        INSERT INTO match_changes (match_id, kind) VALUES (NEW.id, 'finished');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS matches_record_change ON MATCHES;
+CREATE TRIGGER matches_record_change
+AFTER UPDATE ON MATCHES
+FOR EACH ROW EXECUTE FUNCTION record_match_change();
+
+-- Webhook endpoints registered per calendar link. The secret signs every payload.
+CREATE TABLE IF NOT EXISTS WEBHOOKS(
+    id SERIAL PRIMARY KEY,
+    hashed_key VARCHAR(16) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (hashed_key) REFERENCES URL_MAPPINGS(hashed_key),
+    UNIQUE (hashed_key, url)
+);
+
+-- Pending webhook deliveries. Rows are claimed with FOR UPDATE SKIP LOCKED,
+-- deleted on success and moved to WEBHOOK_DEAD_LETTERS after the last retry.
+CREATE TABLE IF NOT EXISTS WEBHOOK_DELIVERIES(
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    change_id BIGINT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES WEBHOOKS(id) ON DELETE CASCADE,
+    FOREIGN KEY (change_id) REFERENCES MATCH_CHANGES(id),
+    UNIQUE (webhook_id, change_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON WEBHOOK_DELIVERIES(next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS WEBHOOK_DEAD_LETTERS(
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    change_id BIGINT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL,
+    last_error TEXT NOT NULL,
+    failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (webhook_id) REFERENCES WEBHOOKS(id) ON DELETE CASCADE
+);
+
+-- Webhook payload format: json (signed raw payload), discord or slack (native rich messages).
+ALTER TABLE WEBHOOKS ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'json';
+
+-- SHA-256 of the owner key returned when the webhook was created. The calendar hash is shared by
+-- everyone who picks the same selection, so listing and removing webhooks requires the owner key.
+ALTER TABLE WEBHOOKS ADD COLUMN IF NOT EXISTS owner_key VARCHAR(64);
+
+-- Weekly email digests per calendar link. Nothing but the confirmation mail is sent
+-- until confirmed_at is set (double opt-in); the token confirms and unsubscribes.
+CREATE TABLE IF NOT EXISTS EMAIL_SUBSCRIPTIONS(
+    id SERIAL PRIMARY KEY,
+    hashed_key VARCHAR(16) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    token VARCHAR(64) NOT NULL UNIQUE,
+    confirmed_at TIMESTAMP,
+    last_sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (hashed_key) REFERENCES URL_MAPPINGS(hashed_key),
+    UNIQUE (hashed_key, email)
+);
+
+-- When the last confirmation mail of a pending subscription went out, so signing up again
+-- within the cooldown does not mail the address again.
+ALTER TABLE EMAIL_SUBSCRIPTIONS ADD COLUMN IF NOT EXISTS confirmation_sent_at TIMESTAMP;
+
+-- SHA-256 of the owner key of the browser that signed up, like WEBHOOKS.owner_key.
+ALTER TABLE EMAIL_SUBSCRIPTIONS ADD COLUMN IF NOT EXISTS owner_key VARCHAR(64);
+
+-- VAPID key pair identifying this server to Web Push services. Generated once and shared
+-- by all instances; a configured vapid.private_key takes precedence over the stored pair.
+CREATE TABLE IF NOT EXISTS VAPID_KEYS(
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    public_key VARCHAR(128) NOT NULL,
+    private_key VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Browser push subscriptions per calendar link. lead_minutes is how long before
+-- a match starts the reminder is sent.
+CREATE TABLE IF NOT EXISTS PUSH_SUBSCRIPTIONS(
+    id SERIAL PRIMARY KEY,
+    hashed_key VARCHAR(16) NOT NULL,
+    endpoint TEXT NOT NULL,
+    p256dh VARCHAR(128) NOT NULL,
+    auth VARCHAR(64) NOT NULL,
+    lead_minutes INT NOT NULL DEFAULT 15,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (hashed_key) REFERENCES URL_MAPPINGS(hashed_key),
+    UNIQUE (hashed_key, endpoint)
+);
+
+-- SHA-256 of the owner key of the browser that subscribed, like WEBHOOKS.owner_key.
+ALTER TABLE PUSH_SUBSCRIPTIONS ADD COLUMN IF NOT EXISTS owner_key VARCHAR(64);
+
+-- Reminders already sent, so each match is announced once per subscription.
+CREATE TABLE IF NOT EXISTS PUSH_REMINDERS(
+    subscription_id INT NOT NULL,
+    match_id INT NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription_id, match_id),
+    FOREIGN KEY (subscription_id) REFERENCES PUSH_SUBSCRIPTIONS(id) ON DELETE CASCADE
+);
@@ -17,6 +17,15 @@ function checkAndUpdateSubmitButton() {
 	}
 }
 
+// Fall back to the default logo for images that fail to load (inline onerror handlers are blocked by the CSP)
+function useDefaultLogoOnError(container) {
+	container.querySelectorAll('img').forEach(img => {
+		img.addEventListener('error', () => {
+			img.src = '/static/images/default-logo.png';
+		}, { once: true });
+	});
+}
+
 // Game selection - League and Team management
 function initGameSelection(gameId) {
 	console.log('initGameSelection called with gameId:', gameId, 'type:', typeof gameId);
@@ -122,15 +131,16 @@ function initGameSelection(gameId) {
 		const html = leagues.map((league, index) => `
 			<li data-index="${index}">
 				<label class="label cursor-pointer justify-start gap-2 p-2">
-					<input type="checkbox" class="checkbox checkbox-sm checkbox-primary" ${selectedLeagues.has(league.id) ? 'checked' : ''} onchange="toggleLeague(${league.id})">
+					<input type="checkbox" class="checkbox checkbox-sm checkbox-primary" ${selectedLeagues.has(league.id) ? 'checked' : ''}>
 					<div class="item-icon-container">
-						<img src="${league.image || '/static/images/default-logo.png'}" alt="${league.name}" class="item-icon" onerror="this.src='/static/images/default-logo.png'">
+						<img src="${league.image || '/static/images/default-logo.png'}" alt="${league.name}" class="item-icon">
 					</div>
 					<span class="text-sm">${league.name}</span>
 				</label>
 			</li>
 		`).join('');
 		leagueList.innerHTML = html;
+		useDefaultLogoOnError(leagueList);
 		highlightedLeagueIndex = -1;
 		// Re-bind toggle functions
 		leagueList.querySelectorAll('input[type="checkbox"]').forEach((cb, i) => {
@@ -321,13 +331,14 @@ function initGameSelection(gameId) {
 				<label class="label cursor-pointer justify-start gap-2 p-2">
 					<input type="checkbox" class="checkbox checkbox-sm checkbox-primary" ${selectedTeams.has(team.id) ? 'checked' : ''}>
 					<div class="item-icon-container">
-						<img src="${team.image || '/static/images/default-logo.png'}" alt="${team.name}" class="item-icon" onerror="this.src='/static/images/default-logo.png'">
+						<img src="${team.image || '/static/images/default-logo.png'}" alt="${team.name}" class="item-icon">
 					</div>
 					<span class="text-sm">${team.acronym ? team.acronym + ' - ' + team.name : team.name}</span>
 				</label>
 			</li>
 		`).join('');
 		teamList.innerHTML = html;
+		useDefaultLogoOnError(teamList);
 		highlightedTeamIndex = -1;
 		// Re-bind toggle functions
 		teamList.querySelectorAll('input[type="checkbox"]').forEach((cb, i) => {
@@ -351,31 +362,38 @@ function initGameSelection(gameId) {
 		const leagueHTML = allLeagues.filter(l => selectedLeagues.has(l.id)).map(league => `
 			<div class="badge badge-primary badge-lg gap-2 rounded-md py-3" data-league-id="${league.id}">
 				<div class="item-icon-container">
-					<img src="${league.image || '/static/images/default-logo.png'}" alt="${league.name}" class="item-icon-badge" onerror="this.src='/static/images/default-logo.png'">
+					<img src="${league.image || '/static/images/default-logo.png'}" alt="${league.name}" class="item-icon-badge">
 				</div>
 				<span class="text-sm">${league.name}</span>
-				<button class="btn btn-ghost btn-xs btn-circle ml-1" onclick="removeLeague(${league.id})">✕</button>
+				<button class="btn btn-ghost btn-xs btn-circle ml-1" data-remove-league="${league.id}">✕</button>
 			</div>
 		`).join('');
 
 		const teamHTML = allTeams.filter(t => selectedTeams.has(t.id)).map(team => `
 			<div class="badge badge-secondary badge-lg gap-2 rounded-md py-3" data-team-id="${team.id}">
 				<div class="item-icon-container">
-					<img src="${team.image || '/static/images/default-logo.png'}" alt="${team.name}" class="item-icon-badge" onerror="this.src='/static/images/default-logo.png'">
+					<img src="${team.image || '/static/images/default-logo.png'}" alt="${team.name}" class="item-icon-badge">
 				</div>
 				<span class="text-sm">${team.acronym ? team.acronym + ' - ' + team.name : team.name}</span>
-				<button class="btn btn-ghost btn-xs btn-circle ml-1" onclick="removeTeam(${team.id})">✕</button>
+				<button class="btn btn-ghost btn-xs btn-circle ml-1" data-remove-team="${team.id}">✕</button>
 			</div>
 		`).join('');
 
 		selectedCombinedContainer.innerHTML = leagueHTML + teamHTML;
+		useDefaultLogoOnError(selectedCombinedContainer);
+		selectedCombinedContainer.querySelectorAll('[data-remove-league]').forEach(btn => {
+			btn.addEventListener('click', () => removeLeague(Number(btn.dataset.removeLeague)));
+		});
+		selectedCombinedContainer.querySelectorAll('[data-remove-team]').forEach(btn => {
+			btn.addEventListener('click', () => removeTeam(Number(btn.dataset.removeTeam)));
+		});
 
 		// Update submit button state
 		checkAndUpdateSubmitButton();
 	}
 
 	// Helper functions for removal
-	window.removeLeague = function(id) {
+	function removeLeague(id) {
 		const league = allLeagues.find(l => l.id === id);
 		if (league) {
 			selectedLeagues.delete(id);
@@ -383,9 +401,9 @@ function initGameSelection(gameId) {
 			updateCombinedDisplay();
 			renderLeagues(filterLeagues(searchInput.value));
 		}
-	};
+	}
 
-	window.removeTeam = function(id) {
+	function removeTeam(id) {
 		const team = allTeams.find(t => t.id === id);
 		if (team) {
 			selectedTeams.delete(id);
@@ -393,7 +411,7 @@ function initGameSelection(gameId) {
 			updateCombinedDisplay();
 			renderTeams(filterTeams(searchTeamsInput.value));
 		}
-	};
+	}
 
 	// Filter teams based on search input
 	function filterTeams(query) {
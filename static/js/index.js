@@ -36,50 +36,11 @@
 		}
 	});
 
-	// Handle form submission
-	form.addEventListener('submit', async (e) => {
-		e.preventDefault();
-
-		// Get selected options
+	// Remember the selection; the form then loads /lts?options=... as a regular page
+	form.addEventListener('submit', () => {
 		const selectedOptions = Array.from(checkboxes)
 			.filter(cb => cb.checked)
 			.map(cb => cb.value);
-
-		// Save selections to sessionStorage
 		sessionStorage.setItem('selectedGameOptions', JSON.stringify(selectedOptions));
-
-		console.log('Submitting with options:', selectedOptions);
-
-		// Send POST request with JSON body
-		try {
-			const response = await fetch('/lts', {
-				method: 'POST',
-				headers: {
-					'Content-Type': 'application/json'
-				},
-				body: JSON.stringify({ options: selectedOptions })
-			});
-
-			console.log('Response status:', response.status);
-
-			if (response.ok) {
-				const html = await response.text();
-
-				// Replace entire page to update progress indicator
-				document.open();
-				document.write(html);
-				document.close();
-
-				// Update URL and title after document rewrite
-				window.history.pushState({}, '', '/lts');
-				document.title = 'Leagues & Teams - EsportsCalendar';
-			} else {
-				console.error('Request failed:', response.statusText);
-				alert('Request failed: ' + response.statusText);
-			}
-		} catch (error) {
-			console.error('Error:', error);
-			alert('Error: ' + error.message);
-		}
 	});
 })();
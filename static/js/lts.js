@@ -123,24 +123,20 @@
 				console.log('Response status:', response.status);
 
 				if (response.ok) {
-					const html = await response.text();
-
-					// Store current theme before replacing document
-					const currentTheme = localStorage.getItem('theme') || 'dark';
-					console.log('Current theme before navigation:', currentTheme);
-
-					// Replace entire page content
-					document.open();
-					document.write(html);
-					document.close();
-
-					// The BaseLayout's inline script will handle theme initialization
-					// We don't need to do anything here
-
-					window.history.pushState({}, '', '/preview');
+					// The selections are valid; open their preview page as a regular navigation
+					const data = await response.json();
+					window.location.href = data.url;
 				} else {
 					console.error('Request failed:', response.statusText);
-					alert('Failed to submit selections: ' + response.statusText);
+					// Rejected selections come back as {error, fields: [{field, message}]}
+					let message = response.statusText;
+					try {
+						const errorData = await response.json();
+						message = [errorData.error, ...(errorData.fields || []).map(f => f.message)].join('\n');
+					} catch (parseError) {
+						console.error('Failed to parse error response:', parseError);
+					}
+					alert('Failed to submit selections: ' + message);
 				}
 			} catch (error) {
 				console.error('Error:', error);
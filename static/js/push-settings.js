@@ -0,0 +1,60 @@
+// Settings page - subscribe this browser to push reminders for the calendar
+(function() {
+	const section = document.getElementById('push-settings');
+	const button = document.getElementById('push-enable');
+	const leadMinutes = document.getElementById('push-lead-minutes');
+	const status = document.getElementById('push-status');
+	if (!section || !button) {
+		return;
+	}
+
+	if (!('serviceWorker' in navigator) || !('PushManager' in window) || !('Notification' in window)) {
+		button.disabled = true;
+		status.textContent = 'This browser does not support push notifications.';
+		return;
+	}
+
+	// applicationServerKey must be a byte array, the server hands out base64url
+	function decodeKey(key) {
+		const base64 = (key + '='.repeat((4 - key.length % 4) % 4)).replace(/-/g, '+').replace(/_/g, '/');
+		return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0));
+	}
+
+	button.addEventListener('click', async () => {
+		button.disabled = true;
+		status.textContent = 'Enabling reminders...';
+		try {
+			const permission = await Notification.requestPermission();
+			if (permission !== 'granted') {
+				throw new Error('Notifications are blocked for this site. Allow them in your browser settings.');
+			}
+
+			await navigator.serviceWorker.register('/push-sw.js');
+			const registration = await navigator.serviceWorker.ready;
+			let subscription = await registration.pushManager.getSubscription();
+			if (!subscription) {
+				subscription = await registration.pushManager.subscribe({
+					userVisibleOnly: true,
+					applicationServerKey: decodeKey(section.dataset.vapidKey),
+				});
+			}
+
+			const body = subscription.toJSON();
+			body.lead_minutes = parseInt(leadMinutes.value, 10);
+			const response = await fetch('/api/push/' + encodeURIComponent(section.dataset.hash), {
+				method: 'POST',
+				headers: { 'Content-Type': 'application/json' },
+				body: JSON.stringify(body),
+			});
+			if (!response.ok) {
+				const result = await response.json().catch(() => ({}));
+				throw new Error(result.error || 'Failed to enable reminders.');
+			}
+			window.location.reload();
+		} catch (err) {
+			console.error('Push subscription failed:', err);
+			status.textContent = err.message;
+			button.disabled = false;
+		}
+	});
+})();
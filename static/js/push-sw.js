@@ -0,0 +1,27 @@
+// Service worker for match reminders. Served from /push-sw.js so its scope covers the whole site.
+self.addEventListener('push', (event) => {
+	let reminder = {};
+	if (event.data) {
+		try {
+			reminder = event.data.json();
+		} catch (err) {
+			reminder = { title: event.data.text() };
+		}
+	}
+
+	const options = {
+		body: reminder.body || '',
+		icon: '/static/images/favicon.svg',
+		tag: reminder.tag,
+		data: { url: reminder.url || '/' },
+	};
+	if (reminder.starts_at) {
+		options.timestamp = Date.parse(reminder.starts_at);
+	}
+	event.waitUntil(self.registration.showNotification(reminder.title || 'EsportsCalendar', options));
+});
+
+self.addEventListener('notificationclick', (event) => {
+	event.notification.close();
+	event.waitUntil(self.clients.openWindow(event.notification.data.url));
+});